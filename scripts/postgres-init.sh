@@ -170,4 +170,23 @@ ADD CONSTRAINT set_states_displayname_constrainte UNIQUE (version, app_ref_id, v
 
 alter table set_states owner to illa_builder;
 
+-- workflow_releases, the latest record of workflow is the current release
+create table if not exists workflow_releases (
+    id                      bigserial                       not null primary key,
+    uid                     uuid default gen_random_uuid()  not null,
+    team_id                 bigserial                       not null,
+    workflow_id             bigint                          not null,
+    version                 bigint                          not null,
+    trigger_mode            smallint                        not null,
+    created_at              timestamp                       not null,
+    created_by              bigint                          not null
+);
+
+CREATE INDEX IF NOT EXISTS workflow_releases_at_teamid_and_workflowid ON workflow_releases (team_id, workflow_id);
+
+-- a version can only be released once, the rollback records point to a released version
+CREATE UNIQUE INDEX IF NOT EXISTS workflow_releases_release_version_unique ON workflow_releases (team_id, workflow_id, version) WHERE trigger_mode = 1;
+
+alter table workflow_releases owner to illa_builder;
+
 EOF
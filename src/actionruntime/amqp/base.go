@@ -0,0 +1,161 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqp
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+	amqp091 "github.com/rabbitmq/amqp091-go"
+)
+
+const (
+	MANAGEMENT_API_LIST_QUEUES    = "/api/queues/%s"
+	MANAGEMENT_API_LIST_EXCHANGES = "/api/exchanges/%s"
+)
+
+func (a *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*amqp091.Connection, error) {
+	if err := mapstructure.Decode(resourceOptions, &a.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	uri, err := amqp091.ParseURI(a.ResourceOpts.URL)
+	if err != nil {
+		return nil, err
+	}
+	config := amqp091.Config{
+		Dial: amqp091.DefaultDial(DEFAULT_DIAL_TIMEOUT * time.Second),
+	}
+	if a.ResourceOpts.Vhost != "" {
+		config.Vhost = a.ResourceOpts.Vhost
+	}
+
+	// the TLS config only works with "amqps" scheme
+	if a.ResourceOpts.SSL.SSL {
+		var tlsConfig *tls.Config
+		tlsConfig, err = common.BuildTLSConfig(a.ResourceOpts.SSL.VerificationMode, a.ResourceOpts.SSL.CACert, a.ResourceOpts.SSL.ClientCert, a.ResourceOpts.SSL.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		if tlsConfig.ServerName == "" {
+			tlsConfig.ServerName = uri.Host
+		}
+		config.TLSClientConfig = tlsConfig
+		uri.Scheme = "amqps"
+	}
+
+	return amqp091.DialConfig(uri.String(), config)
+}
+
+// listQueuesAndExchanges fetch queues and exchanges of the vhost by RabbitMQ management API,
+// the AMQP 0-9-1 protocol itself have no method for listing them.
+func (a *Connector) listQueuesAndExchanges() (map[string]interface{}, error) {
+	uri, err := amqp091.ParseURI(a.ResourceOpts.URL)
+	if err != nil {
+		return nil, err
+	}
+	vhost := uri.Vhost
+	if a.ResourceOpts.Vhost != "" {
+		vhost = a.ResourceOpts.Vhost
+	}
+	baseURL := strings.TrimSuffix(a.ResourceOpts.ManagementURL, "/")
+	escapedVhost := url.PathEscape(vhost)
+
+	client := resty.New().SetBasicAuth(uri.Username, uri.Password).SetTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	queues, err := fetchManagementAPI(client, baseURL+fmt.Sprintf(MANAGEMENT_API_LIST_QUEUES, escapedVhost))
+	if err != nil {
+		return nil, err
+	}
+	exchanges, err := fetchManagementAPI(client, baseURL+fmt.Sprintf(MANAGEMENT_API_LIST_EXCHANGES, escapedVhost))
+	if err != nil {
+		return nil, err
+	}
+
+	// only keep the useful fields
+	queueList := make([]map[string]interface{}, 0, len(queues))
+	for _, queue := range queues {
+		queueList = append(queueList, map[string]interface{}{
+			"name":       queue["name"],
+			"durable":    queue["durable"],
+			"autoDelete": queue["auto_delete"],
+			"messages":   queue["messages"],
+			"consumers":  queue["consumers"],
+		})
+	}
+	exchangeList := make([]map[string]interface{}, 0, len(exchanges))
+	for _, exchange := range exchanges {
+		exchangeList = append(exchangeList, map[string]interface{}{
+			"name":       exchange["name"],
+			"type":       exchange["type"],
+			"durable":    exchange["durable"],
+			"autoDelete": exchange["auto_delete"],
+		})
+	}
+	return map[string]interface{}{"managementAPI": true, "queues": queueList, "exchanges": exchangeList}, nil
+}
+
+func fetchManagementAPI(client *resty.Client, uri string) ([]map[string]interface{}, error) {
+	resp, err := client.R().Get(uri)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode() != http.StatusOK {
+		return nil, errors.New("request RabbitMQ management API failed: " + resp.String())
+	}
+	var ret []map[string]interface{}
+	if err := json.Unmarshal(resp.Body(), &ret); err != nil {
+		return nil, err
+	}
+	return ret, nil
+}
+
+// buildArgumentsTable converts the whole number arguments from JSON to int64, like "x-message-ttl" and "x-max-length",
+// the broker rejects the float value for them.
+func buildArgumentsTable(arguments map[string]interface{}) amqp091.Table {
+	table := amqp091.Table{}
+	for key, value := range arguments {
+		table[key] = normalizeArgumentValue(value)
+	}
+	return table
+}
+
+func normalizeArgumentValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case float64:
+		if typedValue == math.Trunc(typedValue) && typedValue >= math.MinInt64 && typedValue <= math.MaxInt64 {
+			return int64(typedValue)
+		}
+		return typedValue
+	case map[string]interface{}:
+		return buildArgumentsTable(typedValue)
+	case []interface{}:
+		items := make([]interface{}, 0, len(typedValue))
+		for _, item := range typedValue {
+			items = append(items, normalizeArgumentValue(item))
+		}
+		return items
+	}
+	return value
+}
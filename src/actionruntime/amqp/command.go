@@ -0,0 +1,224 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	amqp091 "github.com/rabbitmq/amqp091-go"
+)
+
+type CommandExecutor struct {
+	channel *amqp091.Channel
+	command Action
+}
+
+func (c *CommandExecutor) publish() (common.RuntimeResult, error) {
+	var publishCommandArgs PublishCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &publishCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate amqp publish action options
+	validate := validator.New()
+	if err := validate.Struct(publishCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// build message
+	headers := amqp091.Table{}
+	for _, header := range publishCommandArgs.Headers {
+		if header["key"] != "" {
+			headers[header["key"]] = header["value"]
+		}
+	}
+	message := amqp091.Publishing{
+		Headers:       headers,
+		ContentType:   publishCommandArgs.ContentType,
+		Priority:      publishCommandArgs.Priority,
+		CorrelationId: publishCommandArgs.CorrelationID,
+		ReplyTo:       publishCommandArgs.ReplyTo,
+		Expiration:    publishCommandArgs.Expiration,
+		MessageId:     publishCommandArgs.MessageID,
+		Type:          publishCommandArgs.Type,
+		Timestamp:     time.Now().UTC(),
+		Body:          []byte(publishCommandArgs.Body),
+	}
+	if publishCommandArgs.Persistent {
+		message.DeliveryMode = amqp091.Persistent
+	}
+
+	// publish in confirm mode, so the broker refused or unroutable (when mandatory) message can be reported
+	if err := c.channel.Confirm(false); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	returns := c.channel.NotifyReturn(make(chan amqp091.Return, 1))
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	confirmation, err := c.channel.PublishWithDeferredConfirmWithContext(ctx, publishCommandArgs.Exchange, publishCommandArgs.RoutingKey, publishCommandArgs.Mandatory, false, message)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	acked, err := confirmation.WaitContext(ctx)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if !acked {
+		return common.RuntimeResult{Success: false}, errors.New("message was nacked by broker")
+	}
+	// the basic.return always arrives before basic.ack
+	select {
+	case returned := <-returns:
+		return common.RuntimeResult{Success: false}, fmt.Errorf("message was returned by broker: %d %s", returned.ReplyCode, returned.ReplyText)
+	default:
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": "Published 1 message."},
+	}, nil
+}
+
+func (c *CommandExecutor) get() (common.RuntimeResult, error) {
+	var getCommandArgs GetCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &getCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate amqp get action options
+	validate := validator.New()
+	if err := validate.Struct(getCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	limit := getCommandArgs.Limit
+	if limit <= 0 {
+		limit = DEFAULT_GET_LIMIT
+	}
+	if limit > MAX_GET_LIMIT {
+		limit = MAX_GET_LIMIT
+	}
+
+	// get messages without ack, the requeue mode must hold all of them until the end,
+	// or the requeued message will be got again.
+	rows := make([]map[string]interface{}, 0, limit)
+	lastDeliveryTag := uint64(0)
+	for len(rows) < limit {
+		delivery, ok, err := c.channel.Get(getCommandArgs.Queue, false)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		// queue is empty
+		if !ok {
+			break
+		}
+		rows = append(rows, exportDelivery(delivery))
+		lastDeliveryTag = delivery.DeliveryTag
+	}
+
+	// ack or requeue all got messages
+	if lastDeliveryTag > 0 {
+		var err error
+		if getCommandArgs.AckMode == ACK_MODE_ACK {
+			err = c.channel.Ack(lastDeliveryTag, true)
+		} else {
+			err = c.channel.Nack(lastDeliveryTag, true, true)
+		}
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{},
+	}, nil
+}
+
+func (c *CommandExecutor) declareQueue() (common.RuntimeResult, error) {
+	var declareQueueCommandArgs DeclareQueueCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &declareQueueCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate amqp declare queue action options
+	validate := validator.New()
+	if err := validate.Struct(declareQueueCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	queue, err := c.channel.QueueDeclare(
+		declareQueueCommandArgs.Queue,
+		declareQueueCommandArgs.Durable,
+		declareQueueCommandArgs.AutoDelete,
+		declareQueueCommandArgs.Exclusive,
+		false,
+		buildArgumentsTable(declareQueueCommandArgs.Arguments),
+	)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"queue": queue.Name, "messages": queue.Messages, "consumers": queue.Consumers}},
+		Extra:   map[string]interface{}{},
+	}, nil
+}
+
+func (c *CommandExecutor) purgeQueue() (common.RuntimeResult, error) {
+	var purgeQueueCommandArgs PurgeQueueCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &purgeQueueCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate amqp purge queue action options
+	validate := validator.New()
+	if err := validate.Struct(purgeQueueCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	purged, err := c.channel.QueuePurge(purgeQueueCommandArgs.Queue, false)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": fmt.Sprintf("Purged %d messages.", purged)},
+	}, nil
+}
+
+func exportDelivery(delivery amqp091.Delivery) map[string]interface{} {
+	return map[string]interface{}{
+		"exchange":      delivery.Exchange,
+		"routingKey":    delivery.RoutingKey,
+		"redelivered":   delivery.Redelivered,
+		"body":          string(delivery.Body),
+		"contentType":   delivery.ContentType,
+		"headers":       delivery.Headers,
+		"messageID":     delivery.MessageId,
+		"correlationID": delivery.CorrelationId,
+		"replyTo":       delivery.ReplyTo,
+		"type":          delivery.Type,
+		"timestamp":     delivery.Timestamp,
+		"messageCount":  delivery.MessageCount,
+	}
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package amqp
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (a *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &a.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate amqp options
+	validate := validator.New()
+	if err := validate.Struct(a.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (a *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &a.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate amqp options
+	validate := validator.New()
+	if err := validate.Struct(a.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (a *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get amqp connection
+	conn, err := a.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	// test amqp connection by opening a channel
+	channel, err := conn.Channel()
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	channel.Close()
+
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (a *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	if err := mapstructure.Decode(resourceOptions, &a.ResourceOpts); err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	// queues and exchanges can only be listed by management API, the empty lists do not mean the broker has none
+	if a.ResourceOpts.ManagementURL == "" {
+		return common.MetaInfoResult{
+			Success: true,
+			Schema: map[string]interface{}{
+				"managementAPI": false,
+				"message":       "queues and exchanges can not be listed without the RabbitMQ management API, please set the management URL of the resource",
+				"queues":        []interface{}{},
+				"exchanges":     []interface{}{},
+			},
+		}, nil
+	}
+	schema, err := a.listQueuesAndExchanges()
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  schema,
+	}, nil
+}
+
+func (a *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get amqp connection
+	conn, err := a.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get amqp connection: " + err.Error())
+	}
+	defer conn.Close()
+	channel, err := conn.Channel()
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	defer channel.Close()
+
+	// format amqp action
+	if err := mapstructure.Decode(actionOptions, &a.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{channel: channel, command: a.ActionOpts}
+	switch a.ActionOpts.Commands {
+	case PUBLISH_COMMAND:
+		result, err = commandExecutor.publish()
+	case GET_COMMAND:
+		result, err = commandExecutor.get()
+	case DECLARE_QUEUE_COMMAND:
+		result, err = commandExecutor.declareQueue()
+	case PURGE_QUEUE_COMMAND:
+		result, err = commandExecutor.purgeQueue()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported amqp command: "+a.ActionOpts.Commands)
+	}
+
+	return result, err
+}
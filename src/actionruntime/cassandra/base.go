@@ -0,0 +1,175 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"errors"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gocql/gocql"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+	"gopkg.in/inf.v0"
+)
+
+const (
+	keyspaceCQLStr = "SELECT keyspace_name FROM system_schema.keyspaces"
+	columnCQLStr   = "SELECT table_name, column_name, type, kind FROM system_schema.columns WHERE keyspace_name = ?"
+)
+
+func (c *Connector) getSessionWithOptions(resourceOptions map[string]interface{}) (*gocql.Session, error) {
+	if err := mapstructure.Decode(resourceOptions, &c.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	// the contact point without port uses the port field
+	port := c.ResourceOpts.Port
+	if port == 0 {
+		port = DEFAULT_PORT
+	}
+	hosts := make([]string, 0)
+	for _, host := range strings.Split(c.ResourceOpts.ContactPoints, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if _, _, errInSplit := net.SplitHostPort(host); errInSplit != nil {
+			host = net.JoinHostPort(host, strconv.Itoa(port))
+		}
+		hosts = append(hosts, host)
+	}
+
+	cluster := gocql.NewCluster(hosts...)
+	cluster.Keyspace = c.ResourceOpts.Keyspace
+	cluster.ConnectTimeout = DEFAULT_CONNECT_TIMEOUT * time.Second
+	cluster.Timeout = common.DEFAULT_QUERY_AND_EXEC_TIMEOUT
+	if c.ResourceOpts.Consistency != "" {
+		consistency, err := gocql.ParseConsistencyWrapper(c.ResourceOpts.Consistency)
+		if err != nil {
+			return nil, err
+		}
+		cluster.Consistency = consistency
+	}
+	if c.ResourceOpts.LocalDatacenter != "" {
+		cluster.PoolConfig.HostSelectionPolicy = gocql.TokenAwareHostPolicy(gocql.DCAwareRoundRobinPolicy(c.ResourceOpts.LocalDatacenter))
+	}
+	if c.ResourceOpts.Username != "" {
+		cluster.Authenticator = gocql.PasswordAuthenticator{
+			Username: c.ResourceOpts.Username,
+			Password: c.ResourceOpts.Password,
+		}
+	}
+	if c.ResourceOpts.SSL.SSL {
+		tlsConfig, err := common.BuildTLSConfig(c.ResourceOpts.SSL.VerificationMode, c.ResourceOpts.SSL.CACert, c.ResourceOpts.SSL.ClientCert, c.ResourceOpts.SSL.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		cluster.SslOpts = &gocql.SslOptions{
+			Config:                 tlsConfig,
+			EnableHostVerification: c.ResourceOpts.SSL.VerificationMode != common.TLS_VERIFY_MODE_SKIP,
+		}
+	}
+
+	return cluster.CreateSession()
+}
+
+func keyspacesInfo(session *gocql.Session) ([]string, error) {
+	keyspaceNames := make([]string, 0)
+	iter := session.Query(keyspaceCQLStr).Iter()
+	var keyspaceName string
+	for iter.Scan(&keyspaceName) {
+		// skip the system keyspaces, like "system", "system_schema" and "system_auth"
+		if strings.HasPrefix(keyspaceName, "system") {
+			continue
+		}
+		keyspaceNames = append(keyspaceNames, keyspaceName)
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return keyspaceNames, nil
+}
+
+// fieldsInfo returns the columns of each table in the keyspace, the "kind" is one of "partition_key", "clustering" and "regular".
+func fieldsInfo(session *gocql.Session, keyspaceName string) (map[string]interface{}, error) {
+	tables := make(map[string]interface{})
+	iter := session.Query(columnCQLStr, keyspaceName).Iter()
+	var tableName, columnName, columnType, columnKind string
+	for iter.Scan(&tableName, &columnName, &columnType, &columnKind) {
+		if _, hit := tables[tableName]; !hit {
+			tables[tableName] = make(map[string]interface{})
+		}
+		tables[tableName].(map[string]interface{})[columnName] = map[string]string{"data_type": columnType, "kind": columnKind}
+	}
+	if err := iter.Close(); err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// cqlNumber wraps the JSON number of the context, the JSON decoder always gives float64 but gocql only marshals
+// float64 into the double columns, so the whole number is marshalled as int64 for the integer columns, and the
+// number is converted to float32 and inf.Dec for the float and decimal columns.
+type cqlNumber float64
+
+func (n cqlNumber) MarshalCQL(info gocql.TypeInfo) ([]byte, error) {
+	value := float64(n)
+	switch info.Type() {
+	case gocql.TypeFloat:
+		return gocql.Marshal(info, float32(value))
+	case gocql.TypeDecimal:
+		decimal, ok := new(inf.Dec).SetString(strconv.FormatFloat(value, 'f', -1, 64))
+		if !ok {
+			return nil, errors.New("can not marshal " + strconv.FormatFloat(value, 'g', -1, 64) + " into decimal")
+		}
+		return gocql.Marshal(info, decimal)
+	}
+	if value == math.Trunc(value) && value >= math.MinInt64 && value <= math.MaxInt64 {
+		switch info.Type() {
+		case gocql.TypeInt, gocql.TypeBigInt, gocql.TypeSmallInt, gocql.TypeTinyInt, gocql.TypeVarint, gocql.TypeCounter, gocql.TypeTimestamp:
+			return gocql.Marshal(info, int64(value))
+		}
+	}
+	return gocql.Marshal(info, value)
+}
+
+// bindArgs wraps the float64 values, include the items of collections, as cqlNumber before binding.
+func bindArgs(args []interface{}) []interface{} {
+	ret := make([]interface{}, 0, len(args))
+	for _, arg := range args {
+		ret = append(ret, bindValue(arg))
+	}
+	return ret
+}
+
+func bindValue(value interface{}) interface{} {
+	switch typedValue := value.(type) {
+	case float64:
+		return cqlNumber(typedValue)
+	case []interface{}:
+		return bindArgs(typedValue)
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(typedValue))
+		for key, item := range typedValue {
+			ret[key] = bindValue(item)
+		}
+		return ret
+	}
+	return value
+}
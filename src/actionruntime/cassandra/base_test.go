@@ -0,0 +1,59 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"testing"
+
+	"github.com/gocql/gocql"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/inf.v0"
+)
+
+func TestCQLNumberMarshalCQL(t *testing.T) {
+	nativeType := func(typ gocql.Type) gocql.TypeInfo {
+		return gocql.NewNativeType(4, typ, "")
+	}
+
+	// the whole number is marshalled as integer
+	data, err := cqlNumber(42).MarshalCQL(nativeType(gocql.TypeInt))
+	assert.Nil(t, err)
+	var intValue int
+	assert.Nil(t, gocql.Unmarshal(nativeType(gocql.TypeInt), data, &intValue))
+	assert.Equal(t, 42, intValue)
+
+	// the float column takes float32
+	data, err = cqlNumber(1.5).MarshalCQL(nativeType(gocql.TypeFloat))
+	assert.Nil(t, err)
+	var floatValue float32
+	assert.Nil(t, gocql.Unmarshal(nativeType(gocql.TypeFloat), data, &floatValue))
+	assert.Equal(t, float32(1.5), floatValue)
+
+	// the decimal column takes inf.Dec, the whole number is not marshalled as integer
+	for number, expected := range map[cqlNumber]string{12.25: "12.25", 0.1: "0.1", 3: "3"} {
+		data, err = number.MarshalCQL(nativeType(gocql.TypeDecimal))
+		assert.Nil(t, err)
+		decimalValue := new(inf.Dec)
+		assert.Nil(t, gocql.Unmarshal(nativeType(gocql.TypeDecimal), data, decimalValue))
+		assert.Equal(t, expected, decimalValue.String())
+	}
+
+	// the double column takes float64
+	data, err = cqlNumber(2.75).MarshalCQL(nativeType(gocql.TypeDouble))
+	assert.Nil(t, err)
+	var doubleValue float64
+	assert.Nil(t, gocql.Unmarshal(nativeType(gocql.TypeDouble), data, &doubleValue))
+	assert.Equal(t, 2.75, doubleValue)
+}
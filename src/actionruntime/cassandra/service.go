@@ -0,0 +1,180 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	parser_sql "github.com/illacloud/builder-backend/src/utils/parser/sql"
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	Action       Query
+}
+
+func (c *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &c.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate cassandra options
+	validate := validator.New()
+	if err := validate.Struct(c.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (c *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format cql options
+	if err := mapstructure.Decode(actionOptions, &c.Action); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate cassandra options
+	validate := validator.New()
+	if err := validate.Struct(c.Action); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (c *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get cassandra session, the session creation connects to the contact points
+	session, err := c.getSessionWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer session.Close()
+
+	// test cassandra connection
+	if err := session.Query("SELECT release_version FROM system.local").Exec(); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (c *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get cassandra session
+	session, err := c.getSessionWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer session.Close()
+
+	// list the configured keyspace, or all the user keyspaces when the keyspace is not set
+	keyspaceNames := []string{c.ResourceOpts.Keyspace}
+	if c.ResourceOpts.Keyspace == "" {
+		keyspaceNames, err = keyspacesInfo(session)
+		if err != nil {
+			return common.MetaInfoResult{Success: false}, err
+		}
+	}
+	keyspaces := make(map[string]interface{})
+	for _, keyspaceName := range keyspaceNames {
+		tables, err := fieldsInfo(session, keyspaceName)
+		if err != nil {
+			return common.MetaInfoResult{Success: false}, err
+		}
+		keyspaces[keyspaceName] = tables
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  keyspaces,
+	}, nil
+}
+
+func (c *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get cassandra session
+	session, err := c.getSessionWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get cassandra session: " + err.Error())
+	}
+	defer session.Close()
+
+	// format query
+	if err := mapstructure.Decode(actionOptions, &c.Action); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// set context field
+	errInSetRawQuery := c.Action.SetRawQueryAndContext(rawActionOptions)
+	if errInSetRawQuery != nil {
+		return common.RuntimeResult{Success: false}, errInSetRawQuery
+	}
+
+	// run cql query
+	queryResult := common.RuntimeResult{
+		Success: false,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{},
+	}
+	// bind the template variables as "?" parameters in safe mode, the cqlArgs is empty in unsafe mode
+	sqlEscaper := parser_sql.NewSQLEscaper(resourcelist.TYPE_CASSANDRA_ID)
+	escapedCQL, cqlArgs, errInEscapeCQL := sqlEscaper.EscapeSQLActionTemplate(c.Action.RawQuery, c.Action.Context, c.Action.IsSafeMode())
+	if errInEscapeCQL != nil {
+		return queryResult, errInEscapeCQL
+	}
+
+	// resolve paging options
+	pageSize := c.Action.PageSize
+	if pageSize <= 0 {
+		pageSize = DEFAULT_PAGE_SIZE
+	}
+	if pageSize > MAX_PAGE_SIZE {
+		pageSize = MAX_PAGE_SIZE
+	}
+	pagingState := []byte{}
+	if c.Action.PagingState != "" {
+		pagingState, err = base64.StdEncoding.DecodeString(c.Action.PagingState)
+		if err != nil {
+			return queryResult, errors.New("invalid paging state: " + err.Error())
+		}
+	}
+
+	// start a default context
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+
+	// fetch a single page, the auto paging is disabled by setting the paging state
+	iter := session.Query(escapedCQL, bindArgs(cqlArgs)...).WithContext(ctx).PageSize(pageSize).PageState(pagingState).Iter()
+	nextPagingState := iter.PageState()
+	rows := make([]map[string]interface{}, 0, iter.NumRows())
+	for remaining := iter.NumRows(); remaining > 0; remaining-- {
+		row := make(map[string]interface{})
+		if !iter.MapScan(row) {
+			break
+		}
+		rows = append(rows, row)
+	}
+	if err := iter.Close(); err != nil {
+		return queryResult, err
+	}
+
+	queryResult.Success = true
+	queryResult.Rows = rows
+	queryResult.Extra["pagingState"] = base64.StdEncoding.EncodeToString(nextPagingState)
+	queryResult.Extra["hasMorePages"] = len(nextPagingState) > 0
+	return queryResult, nil
+}
@@ -0,0 +1,91 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cassandra
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+)
+
+const (
+	FIELD_CONTEXT = "context"
+	FIELD_QUERY   = "query"
+)
+
+const (
+	DEFAULT_PORT            = 9042
+	DEFAULT_PAGE_SIZE       = 1000
+	MAX_PAGE_SIZE           = 10000
+	DEFAULT_CONNECT_TIMEOUT = 10 // second
+)
+
+type Resource struct {
+	ContactPoints   string `validate:"required"` // comma separated host list, like "10.0.0.1,10.0.0.2:9043"
+	Port            int
+	Keyspace        string
+	Username        string
+	Password        string
+	LocalDatacenter string
+	Consistency     string `validate:"omitempty,oneof=any one two three quorum all local_quorum each_quorum local_one"`
+	SSL             SSLOptions
+}
+
+type SSLOptions struct {
+	SSL              bool
+	VerificationMode string `validate:"required_unless=SSL false,omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+// Query describe a CQL statement, the PageSize and PagingState fields are used for paging through large partitions,
+// the PagingState is the base64 encoded "pagingState" returned in the extra field of previous page.
+type Query struct {
+	Mode        string `validate:"required,oneof=sql sql-safe"`
+	Query       string
+	RawQuery    string
+	Context     map[string]interface{}
+	PageSize    int
+	PagingState string
+}
+
+func (q *Query) IsSafeMode() bool {
+	return q.Mode == common.MODE_SQL_SAFE
+}
+
+func (q *Query) SetRawQueryAndContext(rawTemplate map[string]interface{}) error {
+	queryRaw, hit := rawTemplate[FIELD_QUERY]
+	if !hit {
+		return errors.New("missing query field for SetRawQueryAndContext() in query")
+	}
+	queryAsserted, assertPass := queryRaw.(string)
+	if !assertPass {
+		return errors.New("query field assert failed in SetRawQueryAndContext() method")
+
+	}
+	q.RawQuery = queryAsserted
+	contextRaw, hit := rawTemplate[FIELD_CONTEXT]
+	if !hit {
+		return errors.New("missing context field SetRawQueryAndContext() in query")
+	}
+	contextAsserted, assertPass := contextRaw.(map[string]interface{})
+	if !assertPass {
+		return errors.New("context field assert failed in SetRawQueryAndContext() method")
+
+	}
+	q.Context = contextAsserted
+	return nil
+}
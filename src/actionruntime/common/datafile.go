@@ -0,0 +1,68 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const DEFAULT_DATA_FILE_DIR = "/opt/illa/illa-builder-backend/data"
+
+// DataFileDir returns the directory which local database files (sqlite, duckdb) are stored in,
+// it can be overridden by the ILLA_DATA_FILE_DIR environment variable.
+func DataFileDir() string {
+	if dir := os.Getenv("ILLA_DATA_FILE_DIR"); dir != "" {
+		return dir
+	}
+	return DEFAULT_DATA_FILE_DIR
+}
+
+// ResolveDataFilePath resolves the user provided relative path under the data file directory,
+// absolute path and path which escapes the directory with ".." are rejected.
+func ResolveDataFilePath(path string) (string, error) {
+	if path == "" {
+		return "", errors.New("database file path is required")
+	}
+	if filepath.IsAbs(path) || strings.HasPrefix(path, "/") || strings.HasPrefix(path, "\\") {
+		return "", errors.New("database file path must be relative to the data directory")
+	}
+	for _, element := range strings.FieldsFunc(path, func(r rune) bool { return r == '/' || r == '\\' }) {
+		if element == ".." {
+			return "", errors.New("database file path must not contain \"..\"")
+		}
+	}
+	baseDir, err := filepath.Abs(DataFileDir())
+	if err != nil {
+		return "", err
+	}
+	resolved := filepath.Join(baseDir, path)
+	if !strings.HasPrefix(resolved, baseDir+string(filepath.Separator)) {
+		return "", errors.New("database file path is out of the data directory")
+	}
+	// reject symlink which points out of the data directory
+	if realPath, errInEval := filepath.EvalSymlinks(resolved); errInEval == nil {
+		realBaseDir, errInEvalBase := filepath.EvalSymlinks(baseDir)
+		if errInEvalBase != nil {
+			return "", errInEvalBase
+		}
+		if !strings.HasPrefix(realPath, realBaseDir+string(filepath.Separator)) {
+			return "", errors.New("database file path is out of the data directory")
+		}
+	}
+	return resolved, nil
+}
@@ -0,0 +1,50 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package common
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+)
+
+const (
+	TLS_VERIFY_MODE_FULL = "full"
+	TLS_VERIFY_MODE_SKIP = "skip"
+)
+
+// BuildTLSConfig build tls config for the connectors which accept PEM format certs.
+// The CA cert is optional (system root CAs will be used), and the client cert pair is only loaded when both of them are provided.
+func BuildTLSConfig(verifyMode string, caCert string, clientCert string, clientKey string) (*tls.Config, error) {
+	cfg := &tls.Config{}
+	if verifyMode == TLS_VERIFY_MODE_SKIP {
+		cfg.InsecureSkipVerify = true
+	}
+	if caCert != "" {
+		pool := x509.NewCertPool()
+		if ok := pool.AppendCertsFromPEM([]byte(caCert)); !ok {
+			return nil, errors.New("error parsing CA cert")
+		}
+		cfg.RootCAs = pool
+	}
+	if clientCert != "" && clientKey != "" {
+		cert, err := tls.X509KeyPair([]byte(clientCert), []byte(clientKey))
+		if err != nil {
+			return nil, err
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
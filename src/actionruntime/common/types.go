@@ -20,6 +20,13 @@ const (
 	MODE_SQL_SAFE = "sql-safe"
 )
 
+// the document mode of NoSQL actions, the context values are bound into the query documents by the document escaper
+// in the safe mode
+const (
+	MODE_DOCUMENT      = "document"
+	MODE_DOCUMENT_SAFE = "document-safe"
+)
+
 type ValidateResult struct {
 	Valid bool
 	Extra map[string]interface{}
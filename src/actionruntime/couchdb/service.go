@@ -109,6 +109,13 @@ func (c *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		return common.RuntimeResult{Success: false}, err
 	}
 
+	// bind the context values into the parsed documents in safe mode
+	if c.actionOptions.IsSafeMode() {
+		if err := c.actionOptions.escapeOpts(rawActionOptions); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
 	// get database
 	db := client.DB(c.actionOptions.Database)
 
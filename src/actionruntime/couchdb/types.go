@@ -14,6 +14,25 @@
 
 package couchdb
 
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	parser_document "github.com/illacloud/builder-backend/src/utils/parser/document"
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+)
+
+const (
+	FIELD_OPTS = "opts"
+)
+
+// documentOpts lists the opts holding a document, they are rendered by the document escaper in safe mode.
+var documentOpts = map[string]bool{
+	"record":     true,
+	"mangoquery": true,
+}
+
 type resource struct {
 	Host     string `validate:"required"`
 	Port     string `validate:"required"`
@@ -26,4 +45,45 @@ type action struct {
 	Method   string `validate:"required,oneof=listRecords retrieveRecord createRecord updateRecord deleteRecord find getView"`
 	Database string
 	Opts     map[string]interface{}
+	Mode     string `validate:"omitempty,oneof=document document-safe"`
+}
+
+func (a *action) IsSafeMode() bool {
+	return a.Mode == common.MODE_DOCUMENT_SAFE
+}
+
+func (a *action) escapeOpts(rawTemplate map[string]interface{}) error {
+	rawOpts, assertPass := rawTemplate[FIELD_OPTS].(map[string]interface{})
+	if !assertPass {
+		return nil
+	}
+	context, err := parser_document.ExportContextFromRawTemplate(rawTemplate)
+	if err != nil {
+		return err
+	}
+	if a.Opts == nil {
+		a.Opts = make(map[string]interface{}, 0)
+	}
+	documentEscaper := parser_document.NewDocumentEscaper(resourcelist.TYPE_COUCHDB_ID)
+	for field, rawValue := range rawOpts {
+		if !documentOpts[strings.ToLower(field)] {
+			continue
+		}
+		escapedValue, err := documentEscaper.EscapeDocumentValue(rawValue, context)
+		if err != nil {
+			return err
+		}
+		// keep the decoded form when the rendered option is not a JSON string
+		if escapedDocument, isString := escapedValue.(string); isString {
+			if _, renderedIsString := a.Opts[field].(string); !renderedIsString {
+				var decodedValue interface{}
+				if err := json.Unmarshal([]byte(escapedDocument), &decodedValue); err != nil {
+					return err
+				}
+				escapedValue = decodedValue
+			}
+		}
+		a.Opts[field] = escapedValue
+	}
+	return nil
 }
@@ -0,0 +1,172 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package duckdb
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	illadrivesdk "github.com/illacloud/builder-backend/src/utils/illadrivesdk"
+	"github.com/mitchellh/mapstructure"
+)
+
+const (
+	tableSQLStr  = "SELECT table_name FROM information_schema.tables WHERE table_schema = ? ORDER BY table_name"
+	columnSQLStr = "SELECT column_name, data_type FROM information_schema.columns WHERE table_schema = ? AND table_name = ? ORDER BY ordinal_position"
+)
+
+const DEFAULT_SCHEMA = "main"
+
+var tableNameRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// getConnectionWithOptions open the duckdb database file, the returned release function closes the connection
+// and cleans the temporary database file which downloaded from team drive.
+// The data files are loaded into temporary tables which are bound to the connection, so a single connection
+// is returned instead of the pool.
+func (d *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*sql.Conn, func(), error) {
+	if err := mapstructure.Decode(resourceOptions, &d.Resource); err != nil {
+		return nil, nil, err
+	}
+
+	// resolve database file, the local file must be stored in data file directory
+	var databaseFile string
+	cleanDatabaseFile := func() {}
+	switch {
+	case d.Resource.IsMemorySource():
+		// the empty dsn opens an in-memory database
+	case d.Resource.IsDriveSource():
+		driveAPI := illadrivesdk.NewIllaDriveRestAPI(d.Resource.TeamID, 0, 0, 0)
+		tempFile, errInDownload := driveAPI.DownloadFileToTemp(d.Resource.FileID, "illa-duckdb-*.duckdb")
+		if errInDownload != nil {
+			return nil, nil, errInDownload
+		}
+		databaseFile = tempFile
+		cleanDatabaseFile = func() { os.Remove(tempFile) }
+	default:
+		resolvedPath, errInResolve := common.ResolveDataFilePath(d.Resource.Path)
+		if errInResolve != nil {
+			return nil, nil, errInResolve
+		}
+		databaseFile = resolvedPath
+	}
+
+	// open database
+	dsn := databaseFile
+	if d.Resource.IsReadOnly() {
+		dsn += "?" + url.Values{"access_mode": []string{"read_only"}}.Encode()
+	}
+	db, err := openDatabase(dsn)
+	if err != nil {
+		cleanDatabaseFile()
+		return nil, nil, err
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		cleanDatabaseFile()
+		return nil, nil, err
+	}
+	release := func() {
+		conn.Close()
+		db.Close()
+		cleanDatabaseFile()
+	}
+
+	// load data files, then disable the external access to prevent the query reading or writing other host files.
+	// duckdb allows disabling the external access at runtime, but it can not be enabled again.
+	if err := loadDataFiles(conn, d.Resource.DataFiles); err != nil {
+		release()
+		return nil, nil, err
+	}
+	if _, err := conn.ExecContext(context.Background(), "SET enable_external_access = false"); err != nil {
+		release()
+		return nil, nil, err
+	}
+	return conn, release, nil
+}
+
+// loadDataFiles copies the csv and parquet files in data file directory into temporary tables,
+// the path is resolved by ResolveDataFilePath and the table name is limited to a plain identifier.
+func loadDataFiles(conn *sql.Conn, dataFiles []DataFile) error {
+	for _, dataFile := range dataFiles {
+		if !tableNameRegexp.MatchString(dataFile.Table) {
+			return fmt.Errorf("invalid table name %q for data file, only letters, digits and underscores are allowed", dataFile.Table)
+		}
+		resolvedPath, errInResolve := common.ResolveDataFilePath(dataFile.Path)
+		if errInResolve != nil {
+			return errInResolve
+		}
+		readFunction := "read_csv_auto"
+		if dataFile.Format == DATA_FILE_FORMAT_PARQUET {
+			readFunction = "read_parquet"
+		}
+		loadSQL := fmt.Sprintf("CREATE TEMP TABLE \"%s\" AS SELECT * FROM %s('%s')", dataFile.Table, readFunction, strings.ReplaceAll(resolvedPath, "'", "''"))
+		if _, err := conn.ExecContext(context.Background(), loadSQL); err != nil {
+			return fmt.Errorf("failed to load data file %s: %s", dataFile.Path, err.Error())
+		}
+	}
+	return nil
+}
+
+func tablesInfo(conn *sql.Conn, tableSchema string) []string {
+	tableNames := make([]string, 0, 0)
+	tableRows, err := conn.QueryContext(context.Background(), tableSQLStr, tableSchema)
+	if err != nil {
+		return nil
+	}
+	defer tableRows.Close()
+	for tableRows.Next() {
+		var tableName string
+		err = tableRows.Scan(&tableName)
+		if err != nil {
+			return nil
+		}
+
+		tableNames = append(tableNames, tableName)
+	}
+
+	return tableNames
+}
+
+func fieldsInfo(conn *sql.Conn, tableSchema string, tableNames []string) map[string]interface{} {
+	columns := make(map[string]interface{})
+	for _, tableName := range tableNames {
+		columnRows, err := conn.QueryContext(context.Background(), columnSQLStr, tableSchema, tableName)
+		if err != nil {
+			return nil
+		}
+		tables := make(map[string]interface{})
+
+		for columnRows.Next() {
+			var columnName, columnType string
+			err = columnRows.Scan(&columnName, &columnType)
+			if err != nil {
+				columnRows.Close()
+				return nil
+			}
+			tables[columnName] = map[string]string{"data_type": columnType}
+
+		}
+		columnRows.Close()
+		columns[tableName] = tables
+	}
+	return columns
+}
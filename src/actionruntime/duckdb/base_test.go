@@ -0,0 +1,87 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+//go:build cgo
+
+package duckdb
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadDataFilesAndDisableExternalAccess(t *testing.T) {
+	dataDir := t.TempDir()
+	t.Setenv("ILLA_DATA_FILE_DIR", dataDir)
+	assert.Nil(t, os.WriteFile(filepath.Join(dataDir, "users.csv"), []byte("id,name\n1,Alice\n2,Bob\n"), 0644))
+
+	connector := &Connector{}
+	conn, release, err := connector.getConnectionWithOptions(map[string]interface{}{
+		"source": SOURCE_MEMORY,
+		"dataFiles": []interface{}{
+			map[string]interface{}{"table": "users", "path": "users.csv", "format": DATA_FILE_FORMAT_CSV},
+		},
+	})
+	assert.Nil(t, err)
+	defer release()
+
+	// the data file is queryable as a table
+	var count int
+	assert.Nil(t, conn.QueryRowContext(context.Background(), "SELECT count(*) FROM users").Scan(&count))
+	assert.Equal(t, 2, count)
+	assert.Contains(t, fieldsInfo(conn, DEFAULT_SCHEMA, tablesInfo(conn, DEFAULT_SCHEMA)), "users")
+
+	// the query can not read host files or attach other databases after the data files are loaded
+	_, err = conn.ExecContext(context.Background(), "SELECT * FROM read_csv_auto('"+filepath.Join(dataDir, "users.csv")+"')")
+	assert.NotNil(t, err)
+	_, err = conn.ExecContext(context.Background(), "ATTACH '"+filepath.Join(dataDir, "other.duckdb")+"'")
+	assert.NotNil(t, err)
+	_, err = conn.ExecContext(context.Background(), "SET enable_external_access = true")
+	assert.NotNil(t, err)
+}
+
+func TestLoadDataFilesRejectsUnsafeInput(t *testing.T) {
+	t.Setenv("ILLA_DATA_FILE_DIR", t.TempDir())
+	testCases := []struct {
+		name     string
+		dataFile map[string]interface{}
+	}{
+		{
+			name:     "path out of data directory",
+			dataFile: map[string]interface{}{"table": "passwd", "path": "../../etc/passwd", "format": DATA_FILE_FORMAT_CSV},
+		},
+		{
+			name:     "absolute path",
+			dataFile: map[string]interface{}{"table": "passwd", "path": "/etc/passwd", "format": DATA_FILE_FORMAT_CSV},
+		},
+		{
+			name:     "quoted table name",
+			dataFile: map[string]interface{}{"table": "a\" AS SELECT 1; --", "path": "users.csv", "format": DATA_FILE_FORMAT_CSV},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			connector := &Connector{}
+			_, _, err := connector.getConnectionWithOptions(map[string]interface{}{
+				"source":    SOURCE_MEMORY,
+				"dataFiles": []interface{}{testCase.dataFile},
+			})
+			assert.NotNil(t, err)
+		})
+	}
+}
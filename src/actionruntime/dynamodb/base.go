@@ -17,6 +17,7 @@ package dynamodb
 import (
 	"context"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
@@ -30,6 +31,21 @@ const (
 	GET_ITEM_METHOD    = "getItem"
 	UPDATE_ITEM_METHOD = "updateItem"
 	DELETE_ITEM_METHOD = "deleteItem"
+
+	BATCH_GET_ITEM_METHOD       = "batchGetItem"
+	BATCH_WRITE_ITEM_METHOD     = "batchWriteItem"
+	TRANSACT_GET_ITEMS_METHOD   = "transactGetItems"
+	TRANSACT_WRITE_ITEMS_METHOD = "transactWriteItems"
+	EXECUTE_STATEMENT_METHOD    = "executeStatement"
+)
+
+const (
+	BATCH_GET_ITEM_SIZE   = 100
+	BATCH_WRITE_ITEM_SIZE = 25
+	MAX_BATCH_RETRIES     = 5
+	DEFAULT_MAX_PAGES     = 10
+	MAX_PAGES             = 100
+	MAX_DESCRIBED_TABLES  = 100
 )
 
 func (d *Connector) getClientWithOptions(resourceOptions map[string]interface{}) (*dynamodb.Client, error) {
@@ -46,8 +62,12 @@ func (d *Connector) getClientWithOptions(resourceOptions map[string]interface{})
 		return nil, err
 	}
 
-	// Using the Config value, create the DynamoDB client
-	client := dynamodb.NewFromConfig(cfg)
+	// Using the Config value, create the DynamoDB client, the endpoint overrides the AWS endpoint, like DynamoDB Local
+	client := dynamodb.NewFromConfig(cfg, func(o *dynamodb.Options) {
+		if d.ResourceOpts.Endpoint != "" {
+			o.BaseEndpoint = aws.String(d.ResourceOpts.Endpoint)
+		}
+	})
 
 	return client, nil
 }
@@ -0,0 +1,869 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamodb
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/mitchellh/mapstructure"
+)
+
+// pageOptions reads the following pages after the LastEvaluatedKey in one run, up to MaxPages pages.
+// The pagination is automatic unless AutoPaginate is false, then one page is read and the cursor continues it.
+// The Limit keeps reading one page of at most Limit items unless AutoPaginate is true, then it is the page size.
+type pageOptions struct {
+	AutoPaginate *bool
+	MaxPages     int
+	Limit        int32
+}
+
+// decodePageOptions returns how many pages can be read in one run, DEFAULT_MAX_PAGES by default.
+func decodePageOptions(params map[string]interface{}) (int, error) {
+	var options pageOptions
+	if err := mapstructure.Decode(params, &options); err != nil {
+		return 0, err
+	}
+	if options.AutoPaginate == nil && options.Limit > 0 {
+		return 1, nil
+	}
+	if options.AutoPaginate != nil && !*options.AutoPaginate {
+		return 1, nil
+	}
+	if options.MaxPages <= 0 {
+		return DEFAULT_MAX_PAGES, nil
+	}
+	if options.MaxPages > MAX_PAGES {
+		return MAX_PAGES, nil
+	}
+	return options.MaxPages, nil
+}
+
+// encodeCursor encodes the LastEvaluatedKey as base64 DynamoDB JSON, the key attributes can only be S, N or B.
+func encodeCursor(lastEvaluatedKey map[string]types.AttributeValue) (string, error) {
+	if len(lastEvaluatedKey) == 0 {
+		return "", nil
+	}
+	key := make(map[string]map[string]string, len(lastEvaluatedKey))
+	for name, value := range lastEvaluatedKey {
+		switch typedValue := value.(type) {
+		case *types.AttributeValueMemberS:
+			key[name] = map[string]string{"S": typedValue.Value}
+		case *types.AttributeValueMemberN:
+			key[name] = map[string]string{"N": typedValue.Value}
+		case *types.AttributeValueMemberB:
+			key[name] = map[string]string{"B": base64.StdEncoding.EncodeToString(typedValue.Value)}
+		default:
+			return "", fmt.Errorf("unsupported key attribute type of %s", name)
+		}
+	}
+	cursor, err := json.Marshal(key)
+	if err != nil {
+		return "", err
+	}
+	return base64.URLEncoding.EncodeToString(cursor), nil
+}
+
+func decodeCursor(cursor string) (map[string]types.AttributeValue, error) {
+	raw, err := base64.URLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	var key map[string]map[string]string
+	if err := json.Unmarshal(raw, &key); err != nil {
+		return nil, errors.New("invalid cursor")
+	}
+	res := make(map[string]types.AttributeValue, len(key))
+	for name, value := range key {
+		if s, hit := value["S"]; hit {
+			res[name] = &types.AttributeValueMemberS{Value: s}
+		} else if n, hit := value["N"]; hit {
+			res[name] = &types.AttributeValueMemberN{Value: n}
+		} else if b, hit := value["B"]; hit {
+			decoded, err := base64.StdEncoding.DecodeString(b)
+			if err != nil {
+				return nil, errors.New("invalid cursor")
+			}
+			res[name] = &types.AttributeValueMemberB{Value: decoded}
+		} else {
+			return nil, errors.New("invalid cursor")
+		}
+	}
+	return res, nil
+}
+
+// buildExclusiveStartKey returns the start key from the cursor of the last page, or from the plain ExclusiveStartKey.
+func buildExclusiveStartKey(cursor string, exclusiveStartKey map[string]interface{}) (map[string]types.AttributeValue, error) {
+	if cursor != "" {
+		return decodeCursor(cursor)
+	}
+	if len(exclusiveStartKey) == 0 {
+		return nil, nil
+	}
+	return attributevalue.MarshalMap(exclusiveStartKey)
+}
+
+func buildPageResult(items []map[string]types.AttributeValue, lastEvaluatedKey map[string]types.AttributeValue, pages int, scannedCount int32) (common.RuntimeResult, error) {
+	rows := make([]map[string]interface{}, len(items))
+	if err := attributevalue.UnmarshalListOfMaps(items, &rows); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	cursor, err := encodeCursor(lastEvaluatedKey)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	lastKey := map[string]interface{}{}
+	if err := attributevalue.UnmarshalMap(lastEvaluatedKey, &lastKey); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra: map[string]interface{}{
+			"count":            len(rows),
+			"scannedCount":     scannedCount,
+			"pages":            pages,
+			"hasMore":          cursor != "",
+			"cursor":           cursor,
+			"lastEvaluatedKey": lastKey,
+		},
+	}, nil
+}
+
+func queryPages(ctx context.Context, svc *dynamodb.Client, in *dynamodb.QueryInput, params map[string]interface{}) (common.RuntimeResult, error) {
+	maxPages, err := decodePageOptions(params)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	items := []map[string]types.AttributeValue{}
+	pages, scannedCount := 0, int32(0)
+	for {
+		out, err := svc.Query(ctx, in)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		items = append(items, out.Items...)
+		pages++
+		scannedCount += out.ScannedCount
+		in.ExclusiveStartKey = out.LastEvaluatedKey
+		if len(out.LastEvaluatedKey) == 0 || pages >= maxPages {
+			break
+		}
+	}
+	return buildPageResult(items, in.ExclusiveStartKey, pages, scannedCount)
+}
+
+func scanPages(ctx context.Context, svc *dynamodb.Client, in *dynamodb.ScanInput, params map[string]interface{}) (common.RuntimeResult, error) {
+	maxPages, err := decodePageOptions(params)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	items := []map[string]types.AttributeValue{}
+	pages, scannedCount := 0, int32(0)
+	for {
+		out, err := svc.Scan(ctx, in)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		items = append(items, out.Items...)
+		pages++
+		scannedCount += out.ScannedCount
+		in.ExclusiveStartKey = out.LastEvaluatedKey
+		if len(out.LastEvaluatedKey) == 0 || pages >= maxPages {
+			break
+		}
+	}
+	return buildPageResult(items, in.ExclusiveStartKey, pages, scannedCount)
+}
+
+// waitBatchRetry backs off before retrying the unprocessed items, 50ms, 100ms, 200ms and so on.
+func waitBatchRetry(ctx context.Context, attempt int) error {
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-time.After(time.Duration(50<<attempt) * time.Millisecond):
+		return nil
+	}
+}
+
+type batchGetKey struct {
+	table string
+	key   map[string]types.AttributeValue
+}
+
+func batchGetItem(ctx context.Context, svc *dynamodb.Client, table string, params map[string]interface{}) (common.RuntimeResult, error) {
+	var batchGetItemParams BatchGetItemParams
+	if err := mapstructure.Decode(params, &batchGetItemParams); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// the keys of the action table, then the request items by table name
+	requestItems := batchGetItemParams.RequestItems
+	if requestItems == nil {
+		requestItems = map[string]BatchGetTableParams{}
+	}
+	if len(batchGetItemParams.Keys) != 0 {
+		if table == "" {
+			return common.RuntimeResult{Success: false}, errors.New("missing table of batchGetItem keys")
+		}
+		requestItems[table] = BatchGetTableParams{
+			Keys:                     batchGetItemParams.Keys,
+			ProjectionExpression:     batchGetItemParams.ProjectionExpression,
+			ExpressionAttributeNames: batchGetItemParams.ExpressionAttributeNames,
+			ConsistentRead:           batchGetItemParams.ConsistentRead,
+		}
+	}
+	tables := make([]string, 0, len(requestItems))
+	for tableName := range requestItems {
+		tables = append(tables, tableName)
+	}
+	sort.Strings(tables)
+	// the duplicate keys are dropped, dynamodb rejects the whole request with them
+	keys := []batchGetKey{}
+	seenKeys := map[string]bool{}
+	for _, tableName := range tables {
+		for _, key := range requestItems[tableName].Keys {
+			marshaledKey, err := attributevalue.MarshalMap(key)
+			if err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			identity, err := keyIdentity(tableName, marshaledKey, nil)
+			if err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			if seenKeys[identity] {
+				continue
+			}
+			seenKeys[identity] = true
+			keys = append(keys, batchGetKey{table: tableName, key: marshaledKey})
+		}
+	}
+	if len(keys) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("missing batchGetItem keys")
+	}
+
+	// get the items by chunks, and retry the unprocessed keys with back off
+	responses := map[string][]map[string]types.AttributeValue{}
+	unprocessed := map[string]types.KeysAndAttributes{}
+	for start := 0; start < len(keys); start += BATCH_GET_ITEM_SIZE {
+		end := start + BATCH_GET_ITEM_SIZE
+		if end > len(keys) {
+			end = len(keys)
+		}
+		chunk := map[string]types.KeysAndAttributes{}
+		for _, key := range keys[start:end] {
+			keysAndAttributes, hit := chunk[key.table]
+			if !hit {
+				tableParams := requestItems[key.table]
+				keysAndAttributes.ConsistentRead = aws.Bool(tableParams.ConsistentRead)
+				if tableParams.ProjectionExpression != "" {
+					keysAndAttributes.ProjectionExpression = aws.String(tableParams.ProjectionExpression)
+				}
+				if len(tableParams.ExpressionAttributeNames) != 0 {
+					keysAndAttributes.ExpressionAttributeNames = tableParams.ExpressionAttributeNames
+				}
+			}
+			keysAndAttributes.Keys = append(keysAndAttributes.Keys, key.key)
+			chunk[key.table] = keysAndAttributes
+		}
+		for attempt := 0; len(chunk) != 0; attempt++ {
+			if attempt > 0 {
+				if attempt > MAX_BATCH_RETRIES {
+					for tableName, keysAndAttributes := range chunk {
+						unprocessedKeys := unprocessed[tableName]
+						unprocessedKeys.Keys = append(unprocessedKeys.Keys, keysAndAttributes.Keys...)
+						unprocessed[tableName] = unprocessedKeys
+					}
+					break
+				}
+				if err := waitBatchRetry(ctx, attempt); err != nil {
+					return common.RuntimeResult{Success: false}, err
+				}
+			}
+			out, err := svc.BatchGetItem(ctx, &dynamodb.BatchGetItemInput{RequestItems: chunk})
+			if err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			for tableName, items := range out.Responses {
+				responses[tableName] = append(responses[tableName], items...)
+			}
+			chunk = out.UnprocessedKeys
+		}
+	}
+
+	// rows hold the items of all tables, and the responses hold the items by table name
+	rows := []map[string]interface{}{}
+	responsesByTable := map[string]interface{}{}
+	for _, tableName := range tables {
+		tableRows := make([]map[string]interface{}, len(responses[tableName]))
+		if err := attributevalue.UnmarshalListOfMaps(responses[tableName], &tableRows); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		rows = append(rows, tableRows...)
+		responsesByTable[tableName] = tableRows
+	}
+	unprocessedKeys := map[string]interface{}{}
+	for tableName, keysAndAttributes := range unprocessed {
+		tableKeys := make([]map[string]interface{}, len(keysAndAttributes.Keys))
+		if err := attributevalue.UnmarshalListOfMaps(keysAndAttributes.Keys, &tableKeys); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		unprocessedKeys[tableName] = tableKeys
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra: map[string]interface{}{
+			"responses":       responsesByTable,
+			"unprocessedKeys": unprocessedKeys,
+		},
+	}, nil
+}
+
+type batchWriteRequest struct {
+	table   string
+	request types.WriteRequest
+}
+
+func batchWriteItem(ctx context.Context, svc *dynamodb.Client, table string, params map[string]interface{}) (common.RuntimeResult, error) {
+	var batchWriteItemParams BatchWriteItemParams
+	if err := mapstructure.Decode(params, &batchWriteItemParams); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// the writes of the action table, then the request items by table name
+	requestItems := batchWriteItemParams.RequestItems
+	if requestItems == nil {
+		requestItems = map[string]BatchWriteTableParams{}
+	}
+	if len(batchWriteItemParams.PutItems) != 0 || len(batchWriteItemParams.DeleteKeys) != 0 {
+		if table == "" {
+			return common.RuntimeResult{Success: false}, errors.New("missing table of batchWriteItem items")
+		}
+		requestItems[table] = BatchWriteTableParams{
+			PutItems:   batchWriteItemParams.PutItems,
+			DeleteKeys: batchWriteItemParams.DeleteKeys,
+		}
+	}
+	tables := make([]string, 0, len(requestItems))
+	for tableName := range requestItems {
+		tables = append(tables, tableName)
+	}
+	sort.Strings(tables)
+	// the order of writes to the same item in a batch is undefined, so the duplicate keys are rejected
+	requests := []batchWriteRequest{}
+	seenKeys := map[string]bool{}
+	for _, tableName := range tables {
+		keyNames, err := tableKeyNames(ctx, svc, tableName)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		checkDuplicate := func(item map[string]types.AttributeValue) error {
+			identity, err := keyIdentity(tableName, item, keyNames)
+			if err != nil {
+				return err
+			}
+			if seenKeys[identity] {
+				return fmt.Errorf("duplicate key of table %s in batchWriteItem, an item can only be written once in a batch", tableName)
+			}
+			seenKeys[identity] = true
+			return nil
+		}
+		for _, item := range requestItems[tableName].PutItems {
+			marshaledItem, err := attributevalue.MarshalMap(item)
+			if err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			if err := checkDuplicate(marshaledItem); err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			requests = append(requests, batchWriteRequest{table: tableName, request: types.WriteRequest{PutRequest: &types.PutRequest{Item: marshaledItem}}})
+		}
+		for _, key := range requestItems[tableName].DeleteKeys {
+			marshaledKey, err := attributevalue.MarshalMap(key)
+			if err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			if err := checkDuplicate(marshaledKey); err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			requests = append(requests, batchWriteRequest{table: tableName, request: types.WriteRequest{DeleteRequest: &types.DeleteRequest{Key: marshaledKey}}})
+		}
+	}
+	if len(requests) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("missing batchWriteItem items")
+	}
+
+	// write the items by chunks, and retry the unprocessed items with back off
+	unprocessed := map[string][]types.WriteRequest{}
+	processedCount := 0
+	for start := 0; start < len(requests); start += BATCH_WRITE_ITEM_SIZE {
+		end := start + BATCH_WRITE_ITEM_SIZE
+		if end > len(requests) {
+			end = len(requests)
+		}
+		chunk := map[string][]types.WriteRequest{}
+		for _, request := range requests[start:end] {
+			chunk[request.table] = append(chunk[request.table], request.request)
+		}
+		for attempt := 0; len(chunk) != 0; attempt++ {
+			if attempt > 0 {
+				if attempt > MAX_BATCH_RETRIES {
+					for tableName, writeRequests := range chunk {
+						unprocessed[tableName] = append(unprocessed[tableName], writeRequests...)
+					}
+					break
+				}
+				if err := waitBatchRetry(ctx, attempt); err != nil {
+					return common.RuntimeResult{Success: false}, err
+				}
+			}
+			out, err := svc.BatchWriteItem(ctx, &dynamodb.BatchWriteItemInput{RequestItems: chunk})
+			if err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			processedCount += countWriteRequests(chunk) - countWriteRequests(out.UnprocessedItems)
+			chunk = out.UnprocessedItems
+		}
+	}
+
+	unprocessedItems := map[string]interface{}{}
+	for tableName, writeRequests := range unprocessed {
+		tableItems := make([]map[string]interface{}, 0, len(writeRequests))
+		for _, writeRequest := range writeRequests {
+			item := map[string]interface{}{}
+			if writeRequest.PutRequest != nil {
+				if err := attributevalue.UnmarshalMap(writeRequest.PutRequest.Item, &item); err != nil {
+					return common.RuntimeResult{Success: false}, err
+				}
+				tableItems = append(tableItems, map[string]interface{}{"putItem": item})
+			} else if writeRequest.DeleteRequest != nil {
+				if err := attributevalue.UnmarshalMap(writeRequest.DeleteRequest.Key, &item); err != nil {
+					return common.RuntimeResult{Success: false}, err
+				}
+				tableItems = append(tableItems, map[string]interface{}{"deleteKey": item})
+			}
+		}
+		unprocessedItems[tableName] = tableItems
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"message": "batch write item successfully"}},
+		Extra: map[string]interface{}{
+			"processedCount":   processedCount,
+			"unprocessedItems": unprocessedItems,
+		},
+	}, nil
+}
+
+// keyIdentity identifies the item by table and key attributes, all attributes are the key when keyNames is nil.
+func keyIdentity(table string, item map[string]types.AttributeValue, keyNames []string) (string, error) {
+	key := item
+	if keyNames != nil {
+		key = make(map[string]types.AttributeValue, len(keyNames))
+		for _, keyName := range keyNames {
+			value, hit := item[keyName]
+			if !hit {
+				return "", fmt.Errorf("missing key attribute %s of table %s", keyName, table)
+			}
+			key[keyName] = value
+		}
+	}
+	encodedKey, err := encodeCursor(key)
+	if err != nil {
+		return "", err
+	}
+	return table + "/" + encodedKey, nil
+}
+
+// tableKeyNames returns the partition key and sort key names of the table.
+func tableKeyNames(ctx context.Context, svc *dynamodb.Client, table string) ([]string, error) {
+	out, err := svc.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(table)})
+	if err != nil {
+		return nil, err
+	}
+	keyNames := make([]string, 0, len(out.Table.KeySchema))
+	for _, element := range out.Table.KeySchema {
+		keyNames = append(keyNames, aws.ToString(element.AttributeName))
+	}
+	return keyNames, nil
+}
+
+func countWriteRequests(requestItems map[string][]types.WriteRequest) int {
+	count := 0
+	for _, writeRequests := range requestItems {
+		count += len(writeRequests)
+	}
+	return count
+}
+
+func transactGetItems(ctx context.Context, svc *dynamodb.Client, table string, params map[string]interface{}) (common.RuntimeResult, error) {
+	var transactGetItemsParams TransactGetItemsParams
+	if err := mapstructure.Decode(params, &transactGetItemsParams); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if len(transactGetItemsParams.TransactItems) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("missing transactGetItems items")
+	}
+
+	in := &dynamodb.TransactGetItemsInput{}
+	for _, item := range transactGetItemsParams.TransactItems {
+		key, err := attributevalue.MarshalMap(item.Key)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		get := &types.Get{
+			TableName: aws.String(tableOrDefault(item.TableName, table)),
+			Key:       key,
+		}
+		if item.ProjectionExpression != "" {
+			get.ProjectionExpression = aws.String(item.ProjectionExpression)
+		}
+		if len(item.ExpressionAttributeNames) != 0 {
+			get.ExpressionAttributeNames = item.ExpressionAttributeNames
+		}
+		in.TransactItems = append(in.TransactItems, types.TransactGetItem{Get: get})
+	}
+	out, err := svc.TransactGetItems(ctx, in)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, transactionError(err)
+	}
+
+	// the rows keep the order of the transact items, a missing item is an empty row
+	rows := make([]map[string]interface{}, 0, len(out.Responses))
+	for _, response := range out.Responses {
+		row := map[string]interface{}{}
+		if err := attributevalue.UnmarshalMap(response.Item, &row); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		rows = append(rows, row)
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{},
+	}, nil
+}
+
+func transactWriteItems(ctx context.Context, svc *dynamodb.Client, table string, params map[string]interface{}) (common.RuntimeResult, error) {
+	var transactWriteItemsParams TransactWriteItemsParams
+	if err := mapstructure.Decode(params, &transactWriteItemsParams); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if len(transactWriteItemsParams.TransactItems) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("missing transactWriteItems items")
+	}
+
+	in := &dynamodb.TransactWriteItemsInput{}
+	if transactWriteItemsParams.ClientRequestToken != "" {
+		in.ClientRequestToken = aws.String(transactWriteItemsParams.ClientRequestToken)
+	}
+	for i, item := range transactWriteItemsParams.TransactItems {
+		transactItem, err := buildTransactWriteItem(table, item)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, fmt.Errorf("transact item %d: %w", i, err)
+		}
+		in.TransactItems = append(in.TransactItems, transactItem)
+	}
+	if _, err := svc.TransactWriteItems(ctx, in); err != nil {
+		return common.RuntimeResult{Success: false}, transactionError(err)
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"message": "transact write items successfully"}},
+		Extra:   map[string]interface{}{"count": len(in.TransactItems)},
+	}, nil
+}
+
+func buildTransactWriteItem(table string, item TransactWriteItem) (types.TransactWriteItem, error) {
+	operations := 0
+	for _, operation := range []*TransactWriteOperation{item.Put, item.Update, item.Delete, item.ConditionCheck} {
+		if operation != nil {
+			operations++
+		}
+	}
+	if operations != 1 {
+		return types.TransactWriteItem{}, errors.New("exactly one of Put, Update, Delete and ConditionCheck is required")
+	}
+
+	var res types.TransactWriteItem
+	var operation *TransactWriteOperation
+	switch {
+	case item.Put != nil:
+		operation = item.Put
+	case item.Update != nil:
+		operation = item.Update
+	case item.Delete != nil:
+		operation = item.Delete
+	default:
+		operation = item.ConditionCheck
+	}
+	tableName := aws.String(tableOrDefault(operation.TableName, table))
+	var conditionExpression *string
+	if operation.ConditionExpression != "" {
+		conditionExpression = aws.String(operation.ConditionExpression)
+	}
+	var expressionAttributeNames map[string]string
+	if len(operation.ExpressionAttributeNames) != 0 {
+		expressionAttributeNames = operation.ExpressionAttributeNames
+	}
+	var expressionAttributeValues map[string]types.AttributeValue
+	if len(operation.ExpressionAttributeValues) != 0 {
+		values, err := attributevalue.MarshalMap(operation.ExpressionAttributeValues)
+		if err != nil {
+			return res, err
+		}
+		expressionAttributeValues = values
+	}
+
+	if item.Put != nil {
+		itemValue, err := attributevalue.MarshalMap(operation.Item)
+		if err != nil {
+			return res, err
+		}
+		res.Put = &types.Put{
+			TableName:                 tableName,
+			Item:                      itemValue,
+			ConditionExpression:       conditionExpression,
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ExpressionAttributeValues: expressionAttributeValues,
+		}
+		return res, nil
+	}
+	key, err := attributevalue.MarshalMap(operation.Key)
+	if err != nil {
+		return res, err
+	}
+	switch {
+	case item.Update != nil:
+		if operation.UpdateExpression == "" {
+			return res, errors.New("missing UpdateExpression of Update")
+		}
+		res.Update = &types.Update{
+			TableName:                 tableName,
+			Key:                       key,
+			UpdateExpression:          aws.String(operation.UpdateExpression),
+			ConditionExpression:       conditionExpression,
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ExpressionAttributeValues: expressionAttributeValues,
+		}
+	case item.Delete != nil:
+		res.Delete = &types.Delete{
+			TableName:                 tableName,
+			Key:                       key,
+			ConditionExpression:       conditionExpression,
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ExpressionAttributeValues: expressionAttributeValues,
+		}
+	default:
+		if conditionExpression == nil {
+			return res, errors.New("missing ConditionExpression of ConditionCheck")
+		}
+		res.ConditionCheck = &types.ConditionCheck{
+			TableName:                 tableName,
+			Key:                       key,
+			ConditionExpression:       conditionExpression,
+			ExpressionAttributeNames:  expressionAttributeNames,
+			ExpressionAttributeValues: expressionAttributeValues,
+		}
+	}
+	return res, nil
+}
+
+func tableOrDefault(tableName string, table string) string {
+	if tableName != "" {
+		return tableName
+	}
+	return table
+}
+
+// transactionError appends the cancellation reasons of each transact item to the error.
+func transactionError(err error) error {
+	var canceledErr *types.TransactionCanceledException
+	if !errors.As(err, &canceledErr) {
+		return err
+	}
+	reasons := make([]string, 0, len(canceledErr.CancellationReasons))
+	for i, reason := range canceledErr.CancellationReasons {
+		code := aws.ToString(reason.Code)
+		if code == "" || code == "None" {
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("item %d: %s %s", i, code, aws.ToString(reason.Message)))
+	}
+	if len(reasons) == 0 {
+		return err
+	}
+	return fmt.Errorf("transaction canceled, %s", strings.Join(reasons, "; "))
+}
+
+func executeStatement(ctx context.Context, svc *dynamodb.Client, params map[string]interface{}) (common.RuntimeResult, error) {
+	var executeStatementParams ExecuteStatementParams
+	if err := mapstructure.Decode(params, &executeStatementParams); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if executeStatementParams.Statement == "" {
+		return common.RuntimeResult{Success: false}, errors.New("missing PartiQL statement")
+	}
+	maxPages, err := decodePageOptions(params)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	in := &dynamodb.ExecuteStatementInput{
+		Statement:      aws.String(executeStatementParams.Statement),
+		ConsistentRead: aws.Bool(executeStatementParams.ConsistentRead),
+	}
+	for _, parameter := range executeStatementParams.Parameters {
+		value, err := attributevalue.Marshal(parameter)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		in.Parameters = append(in.Parameters, value)
+	}
+	if executeStatementParams.Limit > 0 {
+		in.Limit = aws.Int32(executeStatementParams.Limit)
+	}
+	if executeStatementParams.NextToken != "" {
+		in.NextToken = aws.String(executeStatementParams.NextToken)
+	}
+
+	items := []map[string]types.AttributeValue{}
+	pages := 0
+	for {
+		out, err := svc.ExecuteStatement(ctx, in)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		items = append(items, out.Items...)
+		pages++
+		in.NextToken = out.NextToken
+		if aws.ToString(out.NextToken) == "" || pages >= maxPages {
+			break
+		}
+	}
+	rows := make([]map[string]interface{}, len(items))
+	if err := attributevalue.UnmarshalListOfMaps(items, &rows); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra: map[string]interface{}{
+			"count":     len(rows),
+			"pages":     pages,
+			"hasMore":   aws.ToString(in.NextToken) != "",
+			"nextToken": aws.ToString(in.NextToken),
+		},
+	}, nil
+}
+
+func listTables(ctx context.Context, svc *dynamodb.Client) ([]string, error) {
+	tableNames := []string{}
+	paginator := dynamodb.NewListTablesPaginator(svc, &dynamodb.ListTablesInput{})
+	for paginator.HasMorePages() {
+		out, err := paginator.NextPage(ctx)
+		if err != nil {
+			return nil, err
+		}
+		tableNames = append(tableNames, out.TableNames...)
+	}
+	return tableNames, nil
+}
+
+// describeTables returns the key schema, indexes and status of the first MAX_DESCRIBED_TABLES tables.
+func describeTables(ctx context.Context, svc *dynamodb.Client, tableNames []string) (map[string]interface{}, error) {
+	schema := map[string]interface{}{}
+	for i, tableName := range tableNames {
+		if i >= MAX_DESCRIBED_TABLES {
+			break
+		}
+		out, err := svc.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(tableName)})
+		if err != nil {
+			return nil, err
+		}
+		description := out.Table
+		attributeTypes := map[string]string{}
+		for _, attribute := range description.AttributeDefinitions {
+			attributeTypes[aws.ToString(attribute.AttributeName)] = string(attribute.AttributeType)
+		}
+		tableSchema := map[string]interface{}{
+			"status":      string(description.TableStatus),
+			"itemCount":   aws.ToInt64(description.ItemCount),
+			"keySchema":   buildKeySchema(description.KeySchema, attributeTypes),
+			"billingMode": string(types.BillingModeProvisioned),
+		}
+		if description.BillingModeSummary != nil {
+			tableSchema["billingMode"] = string(description.BillingModeSummary.BillingMode)
+		}
+		globalSecondaryIndexes := make([]map[string]interface{}, 0, len(description.GlobalSecondaryIndexes))
+		for _, index := range description.GlobalSecondaryIndexes {
+			globalSecondaryIndex := map[string]interface{}{
+				"name":      aws.ToString(index.IndexName),
+				"status":    string(index.IndexStatus),
+				"keySchema": buildKeySchema(index.KeySchema, attributeTypes),
+			}
+			if index.Projection != nil {
+				globalSecondaryIndex["projection"] = string(index.Projection.ProjectionType)
+			}
+			globalSecondaryIndexes = append(globalSecondaryIndexes, globalSecondaryIndex)
+		}
+		tableSchema["globalSecondaryIndexes"] = globalSecondaryIndexes
+		localSecondaryIndexes := make([]map[string]interface{}, 0, len(description.LocalSecondaryIndexes))
+		for _, index := range description.LocalSecondaryIndexes {
+			localSecondaryIndex := map[string]interface{}{
+				"name":      aws.ToString(index.IndexName),
+				"keySchema": buildKeySchema(index.KeySchema, attributeTypes),
+			}
+			if index.Projection != nil {
+				localSecondaryIndex["projection"] = string(index.Projection.ProjectionType)
+			}
+			localSecondaryIndexes = append(localSecondaryIndexes, localSecondaryIndex)
+		}
+		tableSchema["localSecondaryIndexes"] = localSecondaryIndexes
+		schema[tableName] = tableSchema
+	}
+	return schema, nil
+}
+
+func buildKeySchema(keySchema []types.KeySchemaElement, attributeTypes map[string]string) []map[string]interface{} {
+	res := make([]map[string]interface{}, 0, len(keySchema))
+	for _, element := range keySchema {
+		attributeName := aws.ToString(element.AttributeName)
+		res = append(res, map[string]interface{}{
+			"attributeName": attributeName,
+			"attributeType": attributeTypes[attributeName],
+			"keyType":       string(element.KeyType),
+		})
+	}
+	return res
+}
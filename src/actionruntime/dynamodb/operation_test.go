@@ -0,0 +1,204 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package dynamodb
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name string
+		key  map[string]types.AttributeValue
+	}{
+		{
+			name: "string partition key",
+			key:  map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: "user#1"}},
+		},
+		{
+			name: "number sort key",
+			key: map[string]types.AttributeValue{
+				"id":        &types.AttributeValueMemberS{Value: "user#1"},
+				"createdAt": &types.AttributeValueMemberN{Value: "1697587200.5"},
+			},
+		},
+		{
+			name: "binary key",
+			key:  map[string]types.AttributeValue{"hash": &types.AttributeValueMemberB{Value: []byte{0x00, 0xff, 0x10}}},
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			cursor, err := encodeCursor(testCase.key)
+			assert.Nil(t, err)
+			assert.NotEmpty(t, cursor)
+			decodedKey, err := decodeCursor(cursor)
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.key, decodedKey)
+		})
+	}
+}
+
+func TestEncodeCursorEmptyKey(t *testing.T) {
+	cursor, err := encodeCursor(nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "", cursor)
+}
+
+func TestEncodeCursorUnsupportedKeyType(t *testing.T) {
+	_, err := encodeCursor(map[string]types.AttributeValue{"flag": &types.AttributeValueMemberBOOL{Value: true}})
+	assert.NotNil(t, err)
+}
+
+func TestDecodeCursorInvalid(t *testing.T) {
+	testCases := []struct {
+		name   string
+		cursor string
+	}{
+		{name: "not base64", cursor: "not a cursor!"},
+		{name: "not json", cursor: "bm90IGpzb24="},
+		{name: "unknown attribute type", cursor: "eyJpZCI6eyJCT09MIjoidHJ1ZSJ9fQ=="},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := decodeCursor(testCase.cursor)
+			assert.NotNil(t, err)
+		})
+	}
+}
+
+func TestBuildTransactWriteItemOperationCount(t *testing.T) {
+	operation := &TransactWriteOperation{
+		Item:                map[string]interface{}{"id": "1"},
+		Key:                 map[string]interface{}{"id": "1"},
+		UpdateExpression:    "SET age = :age",
+		ConditionExpression: "attribute_exists(id)",
+		ExpressionAttributeValues: map[string]interface{}{
+			":age": 18,
+		},
+	}
+	testCases := []struct {
+		name    string
+		item    TransactWriteItem
+		isValid bool
+	}{
+		{name: "no operation", item: TransactWriteItem{}, isValid: false},
+		{name: "put", item: TransactWriteItem{Put: operation}, isValid: true},
+		{name: "update", item: TransactWriteItem{Update: operation}, isValid: true},
+		{name: "delete", item: TransactWriteItem{Delete: operation}, isValid: true},
+		{name: "condition check", item: TransactWriteItem{ConditionCheck: operation}, isValid: true},
+		{name: "put and delete", item: TransactWriteItem{Put: operation, Delete: operation}, isValid: false},
+		{name: "all operations", item: TransactWriteItem{Put: operation, Update: operation, Delete: operation, ConditionCheck: operation}, isValid: false},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			item, err := buildTransactWriteItem("users", testCase.item)
+			if !testCase.isValid {
+				assert.NotNil(t, err)
+				return
+			}
+			assert.Nil(t, err)
+			operations := 0
+			for _, built := range []bool{item.Put != nil, item.Update != nil, item.Delete != nil, item.ConditionCheck != nil} {
+				if built {
+					operations++
+				}
+			}
+			assert.Equal(t, 1, operations)
+		})
+	}
+}
+
+func TestBuildTransactWriteItemTableName(t *testing.T) {
+	item, err := buildTransactWriteItem("users", TransactWriteItem{Delete: &TransactWriteOperation{Key: map[string]interface{}{"id": "1"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "users", *item.Delete.TableName)
+
+	item, err = buildTransactWriteItem("users", TransactWriteItem{Delete: &TransactWriteOperation{TableName: "orders", Key: map[string]interface{}{"id": "1"}}})
+	assert.Nil(t, err)
+	assert.Equal(t, "orders", *item.Delete.TableName)
+}
+
+func TestKeyIdentity(t *testing.T) {
+	item := map[string]types.AttributeValue{
+		"id":   &types.AttributeValueMemberS{Value: "1"},
+		"name": &types.AttributeValueMemberS{Value: "pan"},
+	}
+	sameKeyItem := map[string]types.AttributeValue{
+		"id":   &types.AttributeValueMemberS{Value: "1"},
+		"name": &types.AttributeValueMemberS{Value: "li"},
+	}
+	identity, err := keyIdentity("users", item, []string{"id"})
+	assert.Nil(t, err)
+	sameKeyIdentity, err := keyIdentity("users", sameKeyItem, []string{"id"})
+	assert.Nil(t, err)
+	assert.Equal(t, identity, sameKeyIdentity)
+
+	otherTableIdentity, err := keyIdentity("orders", item, []string{"id"})
+	assert.Nil(t, err)
+	assert.NotEqual(t, identity, otherTableIdentity)
+
+	_, err = keyIdentity("users", item, []string{"id", "createdAt"})
+	assert.NotNil(t, err)
+}
+
+func TestDecodePageOptions(t *testing.T) {
+	testCases := []struct {
+		name     string
+		params   map[string]interface{}
+		maxPages int
+	}{
+		{
+			name:     "auto paginate by default",
+			params:   map[string]interface{}{},
+			maxPages: DEFAULT_MAX_PAGES,
+		},
+		{
+			name:     "auto paginate disabled",
+			params:   map[string]interface{}{"autoPaginate": false, "maxPages": 5},
+			maxPages: 1,
+		},
+		{
+			name:     "custom max pages",
+			params:   map[string]interface{}{"maxPages": 5},
+			maxPages: 5,
+		},
+		{
+			name:     "limit reads one page",
+			params:   map[string]interface{}{"limit": 10, "maxPages": 5},
+			maxPages: 1,
+		},
+		{
+			name:     "limit is the page size of auto pagination",
+			params:   map[string]interface{}{"limit": 10, "autoPaginate": true, "maxPages": 5},
+			maxPages: 5,
+		},
+		{
+			name:     "max pages is bounded",
+			params:   map[string]interface{}{"autoPaginate": true, "maxPages": MAX_PAGES + 1},
+			maxPages: MAX_PAGES,
+		},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			maxPages, err := decodePageOptions(testCase.params)
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.maxPages, maxPages)
+		})
+	}
+}
@@ -84,15 +84,21 @@ func (d *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.
 		return common.MetaInfoResult{Success: false}, err
 	}
 
-	// get dynamodb tables
-	resp, err := svc.ListTables(context.TODO(), nil)
+	// get dynamodb tables with their key schema and indexes
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	tableNames, err := listTables(ctx, svc)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	schema, err := describeTables(ctx, svc, tableNames)
 	if err != nil {
 		return common.MetaInfoResult{Success: false}, err
 	}
 
 	return common.MetaInfoResult{
 		Success: true,
-		Schema:  map[string]interface{}{"tables": resp.TableNames},
+		Schema:  map[string]interface{}{"tables": tableNames, "schema": schema},
 	}, nil
 }
 
@@ -107,6 +113,13 @@ func (d *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 	if err := mapstructure.Decode(actionOptions, &d.ActionOpts); err != nil {
 		return common.RuntimeResult{Success: false}, err
 	}
+
+	// bind the context values into the parsed parameters in safe mode
+	if d.ActionOpts.IsSafeMode() {
+		if err := d.ActionOpts.EscapeParameters(rawActionOptions); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
 	if d.ActionOpts.UseJson {
 		var res map[string]interface{}
 		if err := json.Unmarshal([]byte(d.ActionOpts.Parameters), &res); err != nil {
@@ -131,31 +144,13 @@ func (d *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		if err != nil {
 			return res, err
 		}
-		out, err := svc.Query(ctx, in)
-		if err != nil {
-			return res, err
-		}
-		rows := make([]map[string]interface{}, len(out.Items))
-		if err := attributevalue.UnmarshalListOfMaps(out.Items, &rows); err != nil {
-			return res, err
-		}
-		res.Success = true
-		res.Rows = rows
+		return queryPages(ctx, svc, in, d.ActionOpts.StructParams)
 	case SCAN_METHOD:
 		in, err := buildScanInput(d.ActionOpts.Table, d.ActionOpts.StructParams)
 		if err != nil {
 			return res, err
 		}
-		out, err := svc.Scan(ctx, in)
-		if err != nil {
-			return res, err
-		}
-		rows := make([]map[string]interface{}, len(out.Items))
-		if err := attributevalue.UnmarshalListOfMaps(out.Items, &rows); err != nil {
-			return res, err
-		}
-		res.Success = true
-		res.Rows = rows
+		return scanPages(ctx, svc, in, d.ActionOpts.StructParams)
 	case PUT_ITEM_METHOD:
 		in, err := buildPutItemInput(d.ActionOpts.Table, d.ActionOpts.StructParams)
 		if err != nil {
@@ -201,6 +196,16 @@ func (d *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		}
 		res.Success = true
 		res.Rows = append(res.Rows, map[string]interface{}{"message": "delete item successfully"})
+	case BATCH_GET_ITEM_METHOD:
+		return batchGetItem(ctx, svc, d.ActionOpts.Table, d.ActionOpts.StructParams)
+	case BATCH_WRITE_ITEM_METHOD:
+		return batchWriteItem(ctx, svc, d.ActionOpts.Table, d.ActionOpts.StructParams)
+	case TRANSACT_GET_ITEMS_METHOD:
+		return transactGetItems(ctx, svc, d.ActionOpts.Table, d.ActionOpts.StructParams)
+	case TRANSACT_WRITE_ITEMS_METHOD:
+		return transactWriteItems(ctx, svc, d.ActionOpts.Table, d.ActionOpts.StructParams)
+	case EXECUTE_STATEMENT_METHOD:
+		return executeStatement(ctx, svc, d.ActionOpts.StructParams)
 	default:
 		return res, errors.New("unsupported dynamodb method")
 	}
@@ -243,6 +248,11 @@ func buildQueryInput(table string, params map[string]interface{}) (*dynamodb.Que
 	if queryParams.Select != "" {
 		res.Select = types.Select(queryParams.Select)
 	}
+	exclusiveStartKey, err := buildExclusiveStartKey(queryParams.Cursor, queryParams.ExclusiveStartKey)
+	if err != nil {
+		return nil, err
+	}
+	res.ExclusiveStartKey = exclusiveStartKey
 
 	return res, nil
 }
@@ -281,6 +291,11 @@ func buildScanInput(table string, params map[string]interface{}) (*dynamodb.Scan
 	if scanParams.Select != "" {
 		res.Select = types.Select(scanParams.Select)
 	}
+	exclusiveStartKey, err := buildExclusiveStartKey(scanParams.Cursor, scanParams.ExclusiveStartKey)
+	if err != nil {
+		return nil, err
+	}
+	res.ExclusiveStartKey = exclusiveStartKey
 
 	return res, nil
 }
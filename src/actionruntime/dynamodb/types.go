@@ -14,18 +14,70 @@
 
 package dynamodb
 
+import (
+	"strings"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	parser_document "github.com/illacloud/builder-backend/src/utils/parser/document"
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+)
+
+const (
+	FIELD_PARAMETERS    = "parameters"
+	FIELD_STRUCT_PARAMS = "structparams"
+)
+
 type Resource struct {
 	Region          string `validate:"required"`
 	AccessKeyID     string `validate:"required"`
 	SecretAccessKey string `validate:"required"`
+	Endpoint        string `validate:"omitempty,url"`
 }
 
 type Action struct {
-	Method       string `validate:"required,oneof=query scan putItem getItem updateItem deleteItem"`
+	Method       string `validate:"required,oneof=query scan putItem getItem updateItem deleteItem batchGetItem batchWriteItem transactGetItems transactWriteItems executeStatement"`
 	Table        string
 	UseJson      bool
 	Parameters   string
 	StructParams map[string]interface{}
+	Mode         string `validate:"omitempty,oneof=document document-safe"`
+}
+
+func (a *Action) IsSafeMode() bool {
+	return a.Mode == common.MODE_DOCUMENT_SAFE
+}
+
+// EscapeParameters renders the parameters from the raw template with the document escaper, the context values can
+// only be bound as attribute values, never into the expressions.
+func (a *Action) EscapeParameters(rawTemplate map[string]interface{}) error {
+	context, err := parser_document.ExportContextFromRawTemplate(rawTemplate)
+	if err != nil {
+		return err
+	}
+	documentEscaper := parser_document.NewDocumentEscaper(resourcelist.TYPE_DYNAMODB_ID)
+	for field, rawValue := range rawTemplate {
+		switch strings.ToLower(field) {
+		case FIELD_PARAMETERS:
+			rawParameters, assertPass := rawValue.(string)
+			if !assertPass {
+				continue
+			}
+			if a.Parameters, err = documentEscaper.EscapeDocumentTemplate(rawParameters, context); err != nil {
+				return err
+			}
+		case FIELD_STRUCT_PARAMS:
+			rawStructParams, assertPass := rawValue.(map[string]interface{})
+			if !assertPass {
+				continue
+			}
+			escapedStructParams, err := documentEscaper.EscapeDocumentValue(rawStructParams, context)
+			if err != nil {
+				return err
+			}
+			a.StructParams = escapedStructParams.(map[string]interface{})
+		}
+	}
+	return nil
 }
 
 type QueryParams struct {
@@ -37,6 +89,10 @@ type QueryParams struct {
 	ExpressionAttributeValues map[string]interface{}
 	Limit                     int32
 	Select                    string
+	ExclusiveStartKey         map[string]interface{}
+	Cursor                    string
+	AutoPaginate              *bool // read the following pages up to MaxPages unless Limit is set, false reads one page
+	MaxPages                  int
 }
 
 type ScanParams struct {
@@ -47,6 +103,10 @@ type ScanParams struct {
 	ExpressionAttributeValues map[string]interface{}
 	Limit                     int32
 	Select                    string
+	ExclusiveStartKey         map[string]interface{}
+	Cursor                    string
+	AutoPaginate              *bool // read the following pages up to MaxPages unless Limit is set, false reads one page
+	MaxPages                  int
 }
 
 type PutItemParams struct {
@@ -76,3 +136,77 @@ type DeleteItemParams struct {
 	ExpressionAttributeNames  map[string]string
 	ExpressionAttributeValues map[string]interface{}
 }
+
+// BatchGetItemParams gets the Keys from the action table, or the RequestItems by table name.
+type BatchGetItemParams struct {
+	Keys                     []map[string]interface{}
+	ProjectionExpression     string
+	ExpressionAttributeNames map[string]string
+	ConsistentRead           bool
+	RequestItems             map[string]BatchGetTableParams
+}
+
+type BatchGetTableParams struct {
+	Keys                     []map[string]interface{}
+	ProjectionExpression     string
+	ExpressionAttributeNames map[string]string
+	ConsistentRead           bool
+}
+
+// BatchWriteItemParams puts the PutItems and deletes the DeleteKeys of the action table, or the RequestItems by
+// table name.
+type BatchWriteItemParams struct {
+	PutItems     []map[string]interface{}
+	DeleteKeys   []map[string]interface{}
+	RequestItems map[string]BatchWriteTableParams
+}
+
+type BatchWriteTableParams struct {
+	PutItems   []map[string]interface{}
+	DeleteKeys []map[string]interface{}
+}
+
+type TransactGetItemsParams struct {
+	TransactItems []TransactGetItem
+}
+
+type TransactGetItem struct {
+	TableName                string
+	Key                      map[string]interface{}
+	ProjectionExpression     string
+	ExpressionAttributeNames map[string]string
+}
+
+type TransactWriteItemsParams struct {
+	TransactItems      []TransactWriteItem
+	ClientRequestToken string
+}
+
+// TransactWriteItem holds one of the Put, Update, Delete and ConditionCheck, the TableName defaults to the
+// action table.
+type TransactWriteItem struct {
+	Put            *TransactWriteOperation
+	Update         *TransactWriteOperation
+	Delete         *TransactWriteOperation
+	ConditionCheck *TransactWriteOperation
+}
+
+type TransactWriteOperation struct {
+	TableName                 string
+	Item                      map[string]interface{}
+	Key                       map[string]interface{}
+	UpdateExpression          string
+	ConditionExpression       string
+	ExpressionAttributeNames  map[string]string
+	ExpressionAttributeValues map[string]interface{}
+}
+
+type ExecuteStatementParams struct {
+	Statement      string
+	Parameters     []interface{}
+	ConsistentRead bool
+	Limit          int32
+	NextToken      string
+	AutoPaginate   *bool // read the following pages up to MaxPages unless Limit is set, false reads one page
+	MaxPages       int
+}
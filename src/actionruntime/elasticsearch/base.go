@@ -15,7 +15,13 @@
 package elasticsearch
 
 import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
 	es "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -37,3 +43,208 @@ func (e *Connector) getConnectionWithOptions(resourceOptions map[string]interfac
 	}
 	return esClient, err
 }
+
+// decodeResponse decodes the response body, the error response like {"error": {"reason": "..."}} is returned as error.
+func decodeResponse(res *esapi.Response, err error, result interface{}) error {
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.IsError() {
+		errorBody := struct {
+			Error interface{} `json:"error"`
+		}{}
+		if errInDecode := json.NewDecoder(res.Body).Decode(&errorBody); errInDecode == nil && errorBody.Error != nil {
+			if detail, ok := errorBody.Error.(map[string]interface{}); ok && detail["reason"] != nil {
+				return fmt.Errorf("elasticsearch error: %s %v", res.Status(), detail["reason"])
+			}
+			return fmt.Errorf("elasticsearch error: %s %v", res.Status(), errorBody.Error)
+		}
+		return errors.New("elasticsearch error: " + res.Status())
+	}
+	return json.NewDecoder(res.Body).Decode(result)
+}
+
+// exportHit merges the metadata of hit into the source like {"_id": "1", "_index": "logs", "_score": 1.2, ...}.
+func exportHit(hit map[string]interface{}) map[string]interface{} {
+	row := make(map[string]interface{})
+	if source, ok := hit["_source"].(map[string]interface{}); ok {
+		for key, value := range source {
+			row[key] = value
+		}
+	}
+	for _, key := range []string{"_id", "_index", "_score", "sort"} {
+		if value, hit := hit[key]; hit {
+			row[key] = value
+		}
+	}
+	return row
+}
+
+// bucketMetaKeys are the keys of bucket which are not sub aggregations.
+var bucketMetaKeys = map[string]bool{"key": true, "key_as_string": true, "doc_count": true, "from": true, "from_as_string": true, "to": true, "to_as_string": true, "doc_count_error_upper_bound": true}
+
+// flattenAggregations flattens the aggregation tree into rows, each row is a leaf bucket with the keys of the parent
+// buckets (keyed by aggregation name), the "<name>.doc_count" and the metric values of the bucket.
+func flattenAggregations(aggregations map[string]interface{}) []map[string]interface{} {
+	return flattenAggregationLevel(aggregations, map[string]interface{}{})
+}
+
+func flattenAggregationLevel(aggregations map[string]interface{}, parent map[string]interface{}) []map[string]interface{} {
+	row := make(map[string]interface{}, len(parent))
+	for key, value := range parent {
+		row[key] = value
+	}
+
+	// collect metrics of this level, and the bucket aggregations to expand
+	names := make([]string, 0, len(aggregations))
+	for name := range aggregations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	bucketAggregations := make([]string, 0)
+	for _, name := range names {
+		aggregation, ok := aggregations[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		_, hasBuckets := aggregation["buckets"]
+		_, hasDocCount := aggregation["doc_count"]
+		if hasBuckets || hasDocCount {
+			bucketAggregations = append(bucketAggregations, name)
+			continue
+		}
+		addMetric(row, name, aggregation)
+	}
+	if len(bucketAggregations) == 0 {
+		return []map[string]interface{}{row}
+	}
+
+	rows := make([]map[string]interface{}, 0)
+	for _, name := range bucketAggregations {
+		aggregation := aggregations[name].(map[string]interface{})
+		for _, bucket := range normalizeBuckets(aggregation) {
+			bucketRow := make(map[string]interface{}, len(row)+2)
+			for key, value := range row {
+				bucketRow[key] = value
+			}
+			if key, hit := bucket["key_as_string"]; hit {
+				bucketRow[name] = key
+			} else if key, hit := bucket["key"]; hit {
+				bucketRow[name] = key
+			}
+			bucketRow[name+".doc_count"] = bucket["doc_count"]
+			subAggregations := make(map[string]interface{})
+			for key, value := range bucket {
+				if _, isMap := value.(map[string]interface{}); isMap && !bucketMetaKeys[key] {
+					subAggregations[key] = value
+				}
+			}
+			rows = append(rows, flattenAggregationLevel(subAggregations, bucketRow)...)
+		}
+	}
+	// keep the parent bucket when the sub aggregations have no bucket
+	if len(rows) == 0 {
+		return []map[string]interface{}{row}
+	}
+	return rows
+}
+
+// normalizeBuckets converts the buckets of multi bucket aggregation (array, or keyed object like the filters
+// aggregation) and the single bucket aggregation (like the filter aggregation) into bucket list.
+func normalizeBuckets(aggregation map[string]interface{}) []map[string]interface{} {
+	buckets := make([]map[string]interface{}, 0)
+	switch rawBuckets := aggregation["buckets"].(type) {
+	case []interface{}:
+		for _, rawBucket := range rawBuckets {
+			if bucket, ok := rawBucket.(map[string]interface{}); ok {
+				buckets = append(buckets, bucket)
+			}
+		}
+	case map[string]interface{}:
+		keys := make([]string, 0, len(rawBuckets))
+		for key := range rawBuckets {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			if bucket, ok := rawBuckets[key].(map[string]interface{}); ok {
+				if _, hit := bucket["key"]; !hit {
+					bucket["key"] = key
+				}
+				buckets = append(buckets, bucket)
+			}
+		}
+	case nil:
+		buckets = append(buckets, aggregation)
+	}
+	return buckets
+}
+
+// addMetric adds the metric aggregation value, the multi value metric (like stats and percentiles) is added as
+// "<name>.<key>" columns.
+func addMetric(row map[string]interface{}, name string, aggregation map[string]interface{}) {
+	if value, hit := aggregation["value"]; hit {
+		row[name] = value
+		return
+	}
+	if values, ok := aggregation["values"].(map[string]interface{}); ok {
+		for key, value := range values {
+			row[name+"."+key] = value
+		}
+		return
+	}
+	if hits, ok := aggregation["hits"].(map[string]interface{}); ok {
+		exported := make([]map[string]interface{}, 0)
+		if hitList, ok := hits["hits"].([]interface{}); ok {
+			for _, rawHit := range hitList {
+				if hit, ok := rawHit.(map[string]interface{}); ok {
+					exported = append(exported, exportHit(hit))
+				}
+			}
+		}
+		row[name] = exported
+		return
+	}
+	for key, value := range aggregation {
+		switch value.(type) {
+		case map[string]interface{}, []interface{}:
+		default:
+			if key != "meta" {
+				row[name+"."+key] = value
+			}
+		}
+	}
+}
+
+// flattenMappingProperties flattens the mapping properties into fields like [{"name": "user.name", "type": "text"}],
+// the multi fields like "user.name.keyword" are included.
+func flattenMappingProperties(properties map[string]interface{}, prefix string) []map[string]interface{} {
+	names := make([]string, 0, len(properties))
+	for name := range properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fields := make([]map[string]interface{}, 0)
+	for _, name := range names {
+		property, ok := properties[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		fieldName := prefix + name
+		fieldType, _ := property["type"].(string)
+		if subProperties, ok := property["properties"].(map[string]interface{}); ok {
+			if fieldType == "" {
+				fieldType = "object"
+			}
+			fields = append(fields, map[string]interface{}{"name": fieldName, "type": fieldType})
+			fields = append(fields, flattenMappingProperties(subProperties, fieldName+".")...)
+			continue
+		}
+		fields = append(fields, map[string]interface{}{"name": fieldName, "type": fieldType})
+		if multiFields, ok := property["fields"].(map[string]interface{}); ok {
+			fields = append(fields, flattenMappingProperties(multiFields, fieldName+".")...)
+		}
+	}
+	return fields
+}
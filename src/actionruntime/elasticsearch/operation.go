@@ -17,11 +17,17 @@ package elasticsearch
 import (
 	"bytes"
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/illacloud/builder-backend/src/actionruntime/common"
 
 	es "github.com/elastic/go-elasticsearch/v8"
+	"github.com/elastic/go-elasticsearch/v8/esapi"
 )
 
 type OperationRunner struct {
@@ -173,3 +179,424 @@ func (o *OperationRunner) delete() (common.RuntimeResult, error) {
 
 	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{result}}, nil
 }
+
+// parseQueryBody parses the Query as JSON object, the empty Query is an empty object.
+func (o *OperationRunner) parseQueryBody() (map[string]interface{}, error) {
+	queryBody := make(map[string]interface{})
+	if strings.TrimSpace(o.operation.Query) == "" {
+		return queryBody, nil
+	}
+	if err := json.Unmarshal([]byte(o.operation.Query), &queryBody); err != nil {
+		return nil, errors.New("invalid query: " + err.Error())
+	}
+	return queryBody, nil
+}
+
+func (o *OperationRunner) pageSize() int {
+	if o.operation.PageSize <= 0 {
+		return DEFAULT_PAGE_SIZE
+	}
+	if o.operation.PageSize > MAX_PAGE_SIZE {
+		return MAX_PAGE_SIZE
+	}
+	return o.operation.PageSize
+}
+
+func (o *OperationRunner) keepAlive() string {
+	if o.operation.KeepAlive == "" {
+		return DEFAULT_KEEP_ALIVE
+	}
+	return o.operation.KeepAlive
+}
+
+// elasticsearchTimeUnits are the time units accepted by elasticsearch, longer suffixes come first
+var elasticsearchTimeUnits = []struct {
+	suffix string
+	unit   time.Duration
+}{
+	{"micros", time.Microsecond},
+	{"nanos", time.Nanosecond},
+	{"ms", time.Millisecond},
+	{"d", 24 * time.Hour},
+	{"h", time.Hour},
+	{"m", time.Minute},
+	{"s", time.Second},
+}
+
+// parseKeepAlive parses an elasticsearch time value like "1d", "30m" or "500ms".
+func parseKeepAlive(keepAlive string) (time.Duration, error) {
+	for _, timeUnit := range elasticsearchTimeUnits {
+		if !strings.HasSuffix(keepAlive, timeUnit.suffix) {
+			continue
+		}
+		value, err := strconv.ParseInt(strings.TrimSuffix(keepAlive, timeUnit.suffix), 10, 64)
+		if err != nil || value <= 0 {
+			break
+		}
+		return time.Duration(value) * timeUnit.unit, nil
+	}
+	return 0, errors.New("invalid keep alive: " + keepAlive)
+}
+
+func encodeBody(body interface{}) (*bytes.Buffer, error) {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(body); err != nil {
+		return nil, err
+	}
+	return &buf, nil
+}
+
+func (o *OperationRunner) bulk() (common.RuntimeResult, error) {
+	if o.operation.Index == "" {
+		return common.RuntimeResult{Success: false}, errors.New("index is required for bulk operation")
+	}
+	var documents []map[string]interface{}
+	if err := json.Unmarshal([]byte(o.operation.Body), &documents); err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("the body of bulk operation should be an array of documents: " + err.Error())
+	}
+	if len(documents) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("no document to index")
+	}
+
+	// build the NDJSON body, the "_id" field of document is the document ID
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, document := range documents {
+		meta := map[string]interface{}{"_index": o.operation.Index}
+		if id, hit := document["_id"]; hit {
+			meta["_id"] = id
+			delete(document, "_id")
+		}
+		if err := encoder.Encode(map[string]interface{}{"index": meta}); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		if err := encoder.Encode(document); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
+	// perform the bulk request
+	options := []func(*esapi.BulkRequest){o.client.Bulk.WithContext(context.Background()), o.client.Bulk.WithIndex(o.operation.Index)}
+	if o.operation.Refresh != "" {
+		options = append(options, o.client.Bulk.WithRefresh(o.operation.Refresh))
+	}
+	bulkResponse := struct {
+		Took   int                                 `json:"took"`
+		Errors bool                                `json:"errors"`
+		Items  []map[string]map[string]interface{} `json:"items"`
+	}{}
+	res, err := o.client.Bulk(&buf, options...)
+	if err := decodeResponse(res, err, &bulkResponse); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// format the result of each document
+	rows := make([]map[string]interface{}, 0, len(bulkResponse.Items))
+	failed := 0
+	for _, item := range bulkResponse.Items {
+		for _, itemResult := range item {
+			row := map[string]interface{}{"_id": itemResult["_id"], "status": itemResult["status"], "result": itemResult["result"], "error": nil}
+			if itemError, ok := itemResult["error"].(map[string]interface{}); ok {
+				row["error"] = itemError["reason"]
+				failed++
+			}
+			rows = append(rows, row)
+		}
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{"took": bulkResponse.Took, "errors": bulkResponse.Errors, "failed": failed},
+	}, nil
+}
+
+func (o *OperationRunner) aggregate() (common.RuntimeResult, error) {
+	queryBody, err := o.parseQueryBody()
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if _, hit := queryBody["size"]; !hit {
+		queryBody["size"] = 0
+	}
+	buf, err := encodeBody(queryBody)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// perform the search request with aggregations
+	searchResponse := struct {
+		Hits struct {
+			Total interface{} `json:"total"`
+		} `json:"hits"`
+		Aggregations map[string]interface{} `json:"aggregations"`
+	}{}
+	res, err := o.client.Search(
+		o.client.Search.WithContext(context.Background()),
+		o.client.Search.WithIndex(o.operation.Index),
+		o.client.Search.WithBody(buf),
+		o.client.Search.WithTrackTotalHits(true),
+	)
+	if err := decodeResponse(res, err, &searchResponse); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if len(searchResponse.Aggregations) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("no aggregation in the response, the query should contain \"aggs\"")
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    flattenAggregations(searchResponse.Aggregations),
+		Extra:   map[string]interface{}{"aggregations": searchResponse.Aggregations, "total": searchResponse.Hits.Total},
+	}, nil
+}
+
+// paginationCursor is the state of deep pagination, it is returned as the base64 encoded "cursor" in the extra field.
+type paginationCursor struct {
+	Mode        string        `json:"mode"`
+	ScrollID    string        `json:"scrollId,omitempty"`
+	PitID       string        `json:"pitId,omitempty"`
+	SearchAfter []interface{} `json:"searchAfter,omitempty"`
+}
+
+func (c *paginationCursor) encode() string {
+	encoded, _ := json.Marshal(c)
+	return base64.StdEncoding.EncodeToString(encoded)
+}
+
+func decodeCursor(cursor string) (*paginationCursor, error) {
+	decoded, err := base64.StdEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, errors.New("invalid cursor: " + err.Error())
+	}
+	var paginationCursor paginationCursor
+	if err := json.Unmarshal(decoded, &paginationCursor); err != nil {
+		return nil, errors.New("invalid cursor: " + err.Error())
+	}
+	return &paginationCursor, nil
+}
+
+type scrollResponse struct {
+	ScrollID string `json:"_scroll_id"`
+	PitID    string `json:"pit_id"`
+	Hits     struct {
+		Total interface{}              `json:"total"`
+		Hits  []map[string]interface{} `json:"hits"`
+	} `json:"hits"`
+}
+
+// scroll returns a page of hits, the first page starts a scroll or opens a point in time, and the cursor of previous
+// page continues. The scroll or point in time is released after the last page.
+func (o *OperationRunner) scroll() (common.RuntimeResult, error) {
+	ctx := context.Background()
+	pageSize := o.pageSize()
+	keepAlive := o.keepAlive()
+	cursor := &paginationCursor{Mode: o.operation.PaginationMode}
+	if cursor.Mode == "" {
+		cursor.Mode = PAGINATION_MODE_SCROLL
+	}
+	if o.operation.Cursor != "" {
+		var err error
+		if cursor, err = decodeCursor(o.operation.Cursor); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+	queryBody, err := o.parseQueryBody()
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	queryBody["size"] = pageSize
+
+	var response scrollResponse
+	switch cursor.Mode {
+	case PAGINATION_MODE_SCROLL:
+		keepAliveDuration, err := parseKeepAlive(keepAlive)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		var res *esapi.Response
+		if cursor.ScrollID == "" {
+			buf, err := encodeBody(queryBody)
+			if err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			res, err = o.client.Search(
+				o.client.Search.WithContext(ctx),
+				o.client.Search.WithIndex(o.operation.Index),
+				o.client.Search.WithBody(buf),
+				o.client.Search.WithScroll(keepAliveDuration),
+				o.client.Search.WithTrackTotalHits(true),
+			)
+		} else {
+			res, err = o.client.Scroll(
+				o.client.Scroll.WithContext(ctx),
+				o.client.Scroll.WithScrollID(cursor.ScrollID),
+				o.client.Scroll.WithScroll(keepAliveDuration),
+			)
+		}
+		if err := decodeResponse(res, err, &response); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		cursor.ScrollID = response.ScrollID
+	case PAGINATION_MODE_PIT:
+		if cursor.PitID == "" {
+			if o.operation.Index == "" {
+				return common.RuntimeResult{Success: false}, errors.New("index is required for point in time")
+			}
+			pitResponse := struct {
+				ID string `json:"id"`
+			}{}
+			res, err := o.client.OpenPointInTime([]string{o.operation.Index}, keepAlive, o.client.OpenPointInTime.WithContext(ctx))
+			if err := decodeResponse(res, err, &pitResponse); err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			cursor.PitID = pitResponse.ID
+		}
+		// the search with point in time should not specify index, and should be sorted for search after
+		queryBody["pit"] = map[string]interface{}{"id": cursor.PitID, "keep_alive": keepAlive}
+		if _, hit := queryBody["sort"]; !hit {
+			queryBody["sort"] = []interface{}{map[string]interface{}{"_shard_doc": "asc"}}
+		}
+		if len(cursor.SearchAfter) > 0 {
+			queryBody["search_after"] = cursor.SearchAfter
+		}
+		buf, err := encodeBody(queryBody)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		res, err := o.client.Search(
+			o.client.Search.WithContext(ctx),
+			o.client.Search.WithBody(buf),
+			o.client.Search.WithTrackTotalHits(true),
+		)
+		if err := decodeResponse(res, err, &response); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		if response.PitID != "" {
+			cursor.PitID = response.PitID
+		}
+		if len(response.Hits.Hits) > 0 {
+			cursor.SearchAfter, _ = response.Hits.Hits[len(response.Hits.Hits)-1]["sort"].([]interface{})
+		}
+	default:
+		return common.RuntimeResult{Success: false}, errors.New("unsupported pagination mode: " + cursor.Mode)
+	}
+
+	rows := make([]map[string]interface{}, 0, len(response.Hits.Hits))
+	for _, hit := range response.Hits.Hits {
+		rows = append(rows, exportHit(hit))
+	}
+
+	// release the scroll or point in time after the last page
+	hasMore := len(rows) == pageSize
+	nextCursor := ""
+	if hasMore {
+		nextCursor = cursor.encode()
+	} else {
+		o.releaseCursor(ctx, cursor)
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{"cursor": nextCursor, "hasMore": hasMore, "total": response.Hits.Total},
+	}, nil
+}
+
+func (o *OperationRunner) releaseCursor(ctx context.Context, cursor *paginationCursor) {
+	var res *esapi.Response
+	var err error
+	switch {
+	case cursor.ScrollID != "":
+		res, err = o.client.ClearScroll(o.client.ClearScroll.WithContext(ctx), o.client.ClearScroll.WithScrollID(cursor.ScrollID))
+	case cursor.PitID != "":
+		buf, errInEncode := encodeBody(map[string]interface{}{"id": cursor.PitID})
+		if errInEncode != nil {
+			return
+		}
+		res, err = o.client.ClosePointInTime(o.client.ClosePointInTime.WithContext(ctx), o.client.ClosePointInTime.WithBody(buf))
+	}
+	if err == nil && res != nil {
+		res.Body.Close()
+	}
+}
+
+// sqlCursor keeps the column names with the SQL cursor, the columns are only returned in the first page.
+type sqlCursor struct {
+	Cursor  string   `json:"cursor"`
+	Columns []string `json:"columns"`
+}
+
+// sql runs the Elasticsearch SQL query, the "cursor" of the extra field continues the next page.
+func (o *OperationRunner) sql() (common.RuntimeResult, error) {
+	sqlRequest := map[string]interface{}{}
+	var previous sqlCursor
+	if o.operation.Cursor != "" {
+		decoded, err := base64.StdEncoding.DecodeString(o.operation.Cursor)
+		if err == nil {
+			err = json.Unmarshal(decoded, &previous)
+		}
+		if err != nil {
+			return common.RuntimeResult{Success: false}, errors.New("invalid cursor: " + err.Error())
+		}
+		sqlRequest["cursor"] = previous.Cursor
+	} else {
+		if strings.TrimSpace(o.operation.Query) == "" {
+			return common.RuntimeResult{Success: false}, errors.New("sql query is required")
+		}
+		sqlRequest["query"] = o.operation.Query
+		sqlRequest["fetch_size"] = o.pageSize()
+	}
+	buf, err := encodeBody(sqlRequest)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// perform the sql request
+	sqlResponse := struct {
+		Columns []struct {
+			Name string `json:"name"`
+			Type string `json:"type"`
+		} `json:"columns"`
+		Rows   [][]interface{} `json:"rows"`
+		Cursor string          `json:"cursor"`
+	}{}
+	res, err := o.client.SQL.Query(buf, o.client.SQL.Query.WithContext(context.Background()), o.client.SQL.Query.WithFormat("json"))
+	if err := decodeResponse(res, err, &sqlResponse); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	extra := map[string]interface{}{"cursor": "", "hasMore": sqlResponse.Cursor != ""}
+	columnNames := previous.Columns
+	if len(sqlResponse.Columns) > 0 {
+		columnNames = make([]string, 0, len(sqlResponse.Columns))
+		columns := make([]map[string]interface{}, 0, len(sqlResponse.Columns))
+		for _, column := range sqlResponse.Columns {
+			columnNames = append(columnNames, column.Name)
+			columns = append(columns, map[string]interface{}{"name": column.Name, "type": column.Type})
+		}
+		extra["columns"] = columns
+	}
+	if sqlResponse.Cursor != "" {
+		encoded, _ := json.Marshal(sqlCursor{Cursor: sqlResponse.Cursor, Columns: columnNames})
+		extra["cursor"] = base64.StdEncoding.EncodeToString(encoded)
+	}
+	rows := make([]map[string]interface{}, 0, len(sqlResponse.Rows))
+	for _, values := range sqlResponse.Rows {
+		row := make(map[string]interface{}, len(values))
+		for i, value := range values {
+			if i < len(columnNames) {
+				row[columnNames[i]] = value
+			} else {
+				row["column"+strconv.Itoa(i)] = value
+			}
+		}
+		rows = append(rows, row)
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   extra,
+	}, nil
+}
@@ -17,6 +17,8 @@ package elasticsearch
 import (
 	"context"
 	"errors"
+	"sort"
+	"strings"
 
 	"github.com/elastic/go-elasticsearch/v8/esapi"
 	"github.com/go-playground/validator/v10"
@@ -80,9 +82,55 @@ func (e *Connector) TestConnection(resourceOptions map[string]interface{}) (comm
 }
 
 func (e *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get es connection
+	esClient, err := e.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	// list indices, the hidden and system indices are skipped
+	indices := make([]map[string]interface{}, 0)
+	res, err := esClient.Cat.Indices(
+		esClient.Cat.Indices.WithContext(context.TODO()),
+		esClient.Cat.Indices.WithFormat("json"),
+		esClient.Cat.Indices.WithH("index", "health", "status", "docs.count", "store.size"),
+	)
+	if err := decodeResponse(res, err, &indices); err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	// get the mapping fields of indices
+	mappings := make(map[string]struct {
+		Mappings struct {
+			Properties map[string]interface{} `json:"properties"`
+		} `json:"mappings"`
+	})
+	res, err = esClient.Indices.GetMapping(esClient.Indices.GetMapping.WithContext(context.TODO()))
+	if err := decodeResponse(res, err, &mappings); err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	exportedIndices := make([]map[string]interface{}, 0, len(indices))
+	for _, index := range indices {
+		name, _ := index["index"].(string)
+		if strings.HasPrefix(name, ".") {
+			continue
+		}
+		exportedIndices = append(exportedIndices, map[string]interface{}{
+			"name":      name,
+			"health":    index["health"],
+			"status":    index["status"],
+			"docsCount": index["docs.count"],
+			"storeSize": index["store.size"],
+			"fields":    flattenMappingProperties(mappings[name].Mappings.Properties, ""),
+		})
+	}
+	sort.Slice(exportedIndices, func(i, j int) bool {
+		return exportedIndices[i]["name"].(string) < exportedIndices[j]["name"].(string)
+	})
+
 	return common.MetaInfoResult{
 		Success: true,
-		Schema:  nil,
+		Schema:  map[string]interface{}{"indices": exportedIndices},
 	}, nil
 }
 
@@ -98,6 +146,13 @@ func (e *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		return common.RuntimeResult{Success: false}, err
 	}
 
+	// bind the context values into the parsed documents in safe mode
+	if e.ActionOpts.IsSafeMode() {
+		if err := e.ActionOpts.EscapeDocuments(rawActionOptions); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
 	var result common.RuntimeResult
 	operationRunner := OperationRunner{client: esClient, operation: e.ActionOpts}
 	switch e.ActionOpts.Operation {
@@ -111,6 +166,14 @@ func (e *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		result, err = operationRunner.update()
 	case DELETE_OPERATION:
 		result, err = operationRunner.delete()
+	case BULK_OPERATION:
+		result, err = operationRunner.bulk()
+	case AGGREGATE_OPERATION:
+		result, err = operationRunner.aggregate()
+	case SCROLL_OPERATION:
+		result, err = operationRunner.scroll()
+	case SQL_OPERATION:
+		result, err = operationRunner.sql()
 	default:
 		result.Success = false
 		err = errors.New("unsupported elasticsearch operation")
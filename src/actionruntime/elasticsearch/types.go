@@ -14,12 +14,41 @@
 
 package elasticsearch
 
+import (
+	"errors"
+	"strings"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	parser_document "github.com/illacloud/builder-backend/src/utils/parser/document"
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+)
+
+const (
+	SEARCH_OPERATION    = "search"
+	INSERT_OPERATION    = "insert"
+	GET_OPERATION       = "get"
+	UPDATE_OPERATION    = "update"
+	DELETE_OPERATION    = "delete"
+	BULK_OPERATION      = "bulk"
+	AGGREGATE_OPERATION = "aggregate"
+	SCROLL_OPERATION    = "scroll"
+	SQL_OPERATION       = "sql"
+)
+
+const (
+	PAGINATION_MODE_SCROLL = "scroll"
+	PAGINATION_MODE_PIT    = "pit"
+)
+
+const (
+	DEFAULT_PAGE_SIZE  = 1000
+	MAX_PAGE_SIZE      = 10000
+	DEFAULT_KEEP_ALIVE = "1m"
+)
+
 const (
-	SEARCH_OPERATION = "search"
-	INSERT_OPERATION = "insert"
-	GET_OPERATION    = "get"
-	UPDATE_OPERATION = "update"
-	DELETE_OPERATION = "delete"
+	FIELD_BODY  = "body"
+	FIELD_QUERY = "query"
 )
 
 type Resource struct {
@@ -29,10 +58,57 @@ type Resource struct {
 	Password string `validate:"required"`
 }
 
+// Action describe an elasticsearch operation. The bulk operation indexes the documents of the Body which is a JSON array,
+// the "_id" field of document is used as the document ID. The aggregate operation runs the Query and flattens the
+// aggregation buckets into rows. The scroll operation pages through the hits of the Query by scroll or point in time,
+// and the "cursor" of the extra field continues the next page. The sql operation runs the Query as Elasticsearch SQL.
+// In the document-safe mode the Body and Query documents are rendered from the raw template by the document escaper.
 type Action struct {
-	Operation string `validate:"required,oneof=search insert get update delete"`
-	Index     string
-	ID        string
-	Body      string
-	Query     string
+	Operation      string `validate:"required,oneof=search insert get update delete bulk aggregate scroll sql"`
+	Index          string
+	ID             string
+	Body           string
+	Query          string
+	PaginationMode string `validate:"omitempty,oneof=scroll pit"`
+	PageSize       int    `validate:"gte=0"`
+	Cursor         string
+	KeepAlive      string // elasticsearch time value like "1m" or "1d", for scroll and point in time
+	Refresh        string `validate:"omitempty,oneof=true false wait_for"` // for bulk
+	Mode           string `validate:"omitempty,oneof=document document-safe"`
+}
+
+func (a *Action) IsSafeMode() bool {
+	return a.Mode == common.MODE_DOCUMENT_SAFE
+}
+
+func (a *Action) EscapeDocuments(rawTemplate map[string]interface{}) error {
+	context, err := parser_document.ExportContextFromRawTemplate(rawTemplate)
+	if err != nil {
+		return err
+	}
+	documentEscaper := parser_document.NewDocumentEscaper(resourcelist.TYPE_ELASTICSEARCH_ID)
+	for field, rawValue := range rawTemplate {
+		rawDocument, assertPass := rawValue.(string)
+		if !assertPass {
+			continue
+		}
+		switch strings.ToLower(field) {
+		case FIELD_BODY:
+			if a.Body, err = documentEscaper.EscapeDocumentTemplate(rawDocument, context); err != nil {
+				return err
+			}
+		case FIELD_QUERY:
+			// the sql query is not a JSON document, variables can not be bound into it safely
+			if a.Operation == SQL_OPERATION {
+				if strings.Contains(rawDocument, "{{") {
+					return errors.New("variables in the sql query are not supported in safe mode")
+				}
+				continue
+			}
+			if a.Query, err = documentEscaper.EscapeDocumentTemplate(rawDocument, context); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
 }
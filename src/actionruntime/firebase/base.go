@@ -35,8 +35,8 @@ func (f *Connector) getConnectionWithOptions(resourceOptions map[string]interfac
 	}
 	sa := option.WithCredentialsJSON(privateKey)
 
-	// build firebase config for realtime database
-	config := &firebase.Config{DatabaseURL: f.ResourceOpts.DatabaseURL}
+	// build firebase config for realtime database and cloud storage
+	config := &firebase.Config{DatabaseURL: f.ResourceOpts.DatabaseURL, StorageBucket: f.ResourceOpts.StorageBucket}
 
 	// new firebase app
 	app, err := firebase.NewApp(context.Background(), config, sa)
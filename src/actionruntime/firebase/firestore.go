@@ -15,8 +15,11 @@
 package firebase
 
 import (
+	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"strings"
 
 	"github.com/illacloud/builder-backend/src/actionruntime/common"
@@ -25,6 +28,8 @@ import (
 	firebase "firebase.google.com/go/v4"
 	"github.com/go-playground/validator/v10"
 	"github.com/mitchellh/mapstructure"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
@@ -35,6 +40,19 @@ const (
 	FS_DELETE_DOC_OP = "delete_doc"
 	FS_GET_COLLS_OP  = "get_colls"
 	FS_QUERY_COLL_OP = "query_coll"
+
+	FS_BATCH_WRITE_OP = "batch_write"
+	FS_TRANSACTION_OP = "transaction"
+)
+
+const (
+	FS_WRITE_SET    = "set"
+	FS_WRITE_CREATE = "create"
+	FS_WRITE_UPDATE = "update"
+	FS_WRITE_DELETE = "delete"
+
+	// FS_MAX_WRITES is the write limit of a firestore commit
+	FS_MAX_WRITES = 500
 )
 
 type FirestoreOperationRunner struct {
@@ -52,6 +70,7 @@ type FSQueryOptions struct {
 	OrderDirection string
 	StartAt        SimpleCursor `validate:"required"`
 	EndAt          SimpleCursor `validate:"required"`
+	Cursor         string
 }
 
 type QueryCondition struct {
@@ -80,6 +99,34 @@ type FSGetCollsOptions struct {
 	Parent string
 }
 
+// FSWriteOperation writes one document, the ID is generated when it is empty for the set and create writes.
+type FSWriteOperation struct {
+	Type       string `validate:"required,oneof=set create update delete"`
+	Collection string `validate:"required"`
+	ID         string
+	Value      map[string]interface{}
+	Merge      bool
+}
+
+type FSBatchWriteOptions struct {
+	Writes []FSWriteOperation `validate:"required,min=1,max=500,dive"`
+}
+
+// FSTransactionRead reads one document in the transaction, the transaction fails when the document does not match
+// the Exists or Equals conditions.
+type FSTransactionRead struct {
+	Collection string `validate:"required"`
+	ID         string `validate:"required"`
+	Exists     *bool
+	Equals     map[string]interface{}
+}
+
+type FSTransactionOptions struct {
+	Reads       []FSTransactionRead `validate:"dive"`
+	Writes      []FSWriteOperation  `validate:"max=500,dive"`
+	MaxAttempts int                 `validate:"gte=0"`
+}
+
 func (f *FirestoreOperationRunner) run() (common.RuntimeResult, error) {
 	var result common.RuntimeResult
 	var err error
@@ -98,6 +145,10 @@ func (f *FirestoreOperationRunner) run() (common.RuntimeResult, error) {
 		result, err = f.getCollections()
 	case FS_QUERY_COLL_OP:
 		result, err = f.queryCollectionGroup()
+	case FS_BATCH_WRITE_OP:
+		result, err = f.batchWrite()
+	case FS_TRANSACTION_OP:
+		result, err = f.transaction()
 	default:
 		result.Success = false
 		err = errors.New("unsupported operation")
@@ -133,8 +184,9 @@ func (f *FirestoreOperationRunner) queryFirestore() (common.RuntimeResult, error
 		query = query.Where(queryFSOptions.Where[i].Field, queryFSOptions.Where[i].Condition, queryFSOptions.Where[i].Value)
 	}
 
+	// read one more document to know if there is a next page
 	if queryFSOptions.Limit > 0 {
-		query = query.Limit(queryFSOptions.Limit)
+		query = query.Limit(queryFSOptions.Limit + 1)
 	}
 
 	if queryFSOptions.OrderBy != "" {
@@ -146,23 +198,28 @@ func (f *FirestoreOperationRunner) queryFirestore() (common.RuntimeResult, error
 	}
 
 	if queryFSOptions.StartAt.Trigger {
-		query = query.EndAt(queryFSOptions.StartAt.Value)
+		query = query.StartAt(queryFSOptions.StartAt.Value)
 	}
 
 	if queryFSOptions.EndAt.Trigger {
 		query = query.EndAt(queryFSOptions.EndAt.Value)
 	}
 
+	// the cursor of the last page overrides the start at value
+	if queryFSOptions.Cursor != "" {
+		cursorDoc, err := client.Doc(queryFSOptions.Cursor).Get(ctx)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		query = query.StartAfter(cursorDoc)
+	}
+
 	docs, err := query.Documents(ctx).GetAll()
 	if err != nil {
 		return common.RuntimeResult{Success: false}, err
 	}
-	res := make([]map[string]interface{}, 0, len(docs))
-	for _, doc := range docs {
-		res = append(res, doc.Data())
-	}
 
-	return common.RuntimeResult{Success: true, Rows: res}, err
+	return buildQueryPageResult(docs, queryFSOptions.Limit), nil
 }
 
 func (f *FirestoreOperationRunner) insertDoc() (common.RuntimeResult, error) {
@@ -341,8 +398,9 @@ func (f *FirestoreOperationRunner) queryCollectionGroup() (common.RuntimeResult,
 		query = query.Where(queryCGOptions.Where[i].Field, queryCGOptions.Where[i].Condition, queryCGOptions.Where[i].Value)
 	}
 
+	// read one more document to know if there is a next page
 	if queryCGOptions.Limit > 0 {
-		query = query.Limit(queryCGOptions.Limit)
+		query = query.Limit(queryCGOptions.Limit + 1)
 	}
 
 	if queryCGOptions.OrderBy != "" {
@@ -361,14 +419,245 @@ func (f *FirestoreOperationRunner) queryCollectionGroup() (common.RuntimeResult,
 		query = query.EndAt(queryCGOptions.EndAt.Value)
 	}
 
+	// the cursor of the last page overrides the start at value
+	if queryCGOptions.Cursor != "" {
+		cursorDoc, err := client.Doc(queryCGOptions.Cursor).Get(ctx)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		query = query.StartAfter(cursorDoc)
+	}
+
 	docs, err := query.Documents(ctx).GetAll()
 	if err != nil {
 		return common.RuntimeResult{Success: false}, err
 	}
+
+	return buildQueryPageResult(docs, queryCGOptions.Limit), nil
+}
+
+// buildQueryPageResult trims the extra document of the page, the cursor is the path of the last document and starts
+// the next page after it.
+func buildQueryPageResult(docs []*firestore.DocumentSnapshot, limit int) common.RuntimeResult {
+	hasMore := limit > 0 && len(docs) > limit
+	if hasMore {
+		docs = docs[:limit]
+	}
 	res := make([]map[string]interface{}, 0, len(docs))
+	paths := make([]string, 0, len(docs))
 	for _, doc := range docs {
 		res = append(res, doc.Data())
+		paths = append(paths, documentPath(doc.Ref))
+	}
+	cursor := ""
+	if hasMore {
+		cursor = paths[len(paths)-1]
 	}
 
-	return common.RuntimeResult{Success: true, Rows: res}, err
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    res,
+		Extra:   map[string]interface{}{"paths": paths, "cursor": cursor, "hasMore": hasMore},
+	}
+}
+
+// documentPath returns the document path relative to the database, like "users/alice/orders/1".
+func documentPath(doc *firestore.DocumentRef) string {
+	const documentsSegment = "/documents/"
+	if i := strings.Index(doc.Path, documentsSegment); i >= 0 {
+		return doc.Path[i+len(documentsSegment):]
+	}
+	return doc.Path
+}
+
+func (f *FirestoreOperationRunner) batchWrite() (common.RuntimeResult, error) {
+	var batchWriteOptions FSBatchWriteOptions
+	if err := mapstructure.Decode(f.options, &batchWriteOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate Firebase Firestore `batch write` action options
+	validate := validator.New()
+	if err := validate.Struct(batchWriteOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// build batch write action, the writes are committed atomically in a transaction without reads
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	client, err := f.client.Firestore(ctx)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	defer client.Close()
+
+	refs, err := buildWriteRefs(client, batchWriteOptions.Writes)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		return applyWrites(tx, batchWriteOptions.Writes, refs)
+	})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"message": "batch write successfully"}},
+		Extra:   map[string]interface{}{"paths": refPaths(refs)},
+	}, nil
+}
+
+func (f *FirestoreOperationRunner) transaction() (common.RuntimeResult, error) {
+	var transactionOptions FSTransactionOptions
+	if err := mapstructure.Decode(f.options, &transactionOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate Firebase Firestore `transaction` action options
+	validate := validator.New()
+	if err := validate.Struct(transactionOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if len(transactionOptions.Reads) == 0 && len(transactionOptions.Writes) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("transaction requires reads or writes")
+	}
+
+	// build transaction action
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	client, err := f.client.Firestore(ctx)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	defer client.Close()
+
+	refs, err := buildWriteRefs(client, transactionOptions.Writes)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	txOptions := []firestore.TransactionOption{}
+	if transactionOptions.MaxAttempts > 0 {
+		txOptions = append(txOptions, firestore.MaxAttempts(transactionOptions.MaxAttempts))
+	}
+	if len(transactionOptions.Writes) == 0 {
+		txOptions = append(txOptions, firestore.ReadOnly)
+	}
+
+	// the reads lock the documents until the writes are committed, and the transaction is retried on contention
+	var rows []map[string]interface{}
+	err = client.RunTransaction(ctx, func(ctx context.Context, tx *firestore.Transaction) error {
+		rows = make([]map[string]interface{}, 0, len(transactionOptions.Reads))
+		for _, read := range transactionOptions.Reads {
+			ref := client.Collection(read.Collection).Doc(read.ID)
+			doc, err := tx.Get(ref)
+			if err != nil && status.Code(err) != codes.NotFound {
+				return err
+			}
+			if err := checkReadConditions(read, doc); err != nil {
+				return err
+			}
+			row := map[string]interface{}{}
+			if doc.Exists() {
+				row = doc.Data()
+			}
+			rows = append(rows, row)
+		}
+		return applyWrites(tx, transactionOptions.Writes, refs)
+	}, txOptions...)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{"paths": refPaths(refs)},
+	}, nil
+}
+
+// buildWriteRefs resolves the document of each write before the transaction, so a retried transaction writes the
+// same generated IDs.
+func buildWriteRefs(client *firestore.Client, writes []FSWriteOperation) ([]*firestore.DocumentRef, error) {
+	refs := make([]*firestore.DocumentRef, 0, len(writes))
+	for i, write := range writes {
+		collection := client.Collection(write.Collection)
+		if collection == nil {
+			return nil, fmt.Errorf("write %d: invalid collection path %s", i, write.Collection)
+		}
+		if write.ID != "" {
+			refs = append(refs, collection.Doc(write.ID))
+			continue
+		}
+		if write.Type != FS_WRITE_SET && write.Type != FS_WRITE_CREATE {
+			return nil, fmt.Errorf("write %d: document id required", i)
+		}
+		refs = append(refs, collection.NewDoc())
+	}
+	return refs, nil
+}
+
+func applyWrites(tx *firestore.Transaction, writes []FSWriteOperation, refs []*firestore.DocumentRef) error {
+	for i, write := range writes {
+		var err error
+		switch write.Type {
+		case FS_WRITE_SET:
+			if write.Merge {
+				err = tx.Set(refs[i], write.Value, firestore.MergeAll)
+			} else {
+				err = tx.Set(refs[i], write.Value)
+			}
+		case FS_WRITE_CREATE:
+			err = tx.Create(refs[i], write.Value)
+		case FS_WRITE_UPDATE:
+			// the keys of the value are field paths, like "address.city"
+			updates := make([]firestore.Update, 0, len(write.Value))
+			for path, value := range write.Value {
+				updates = append(updates, firestore.Update{Path: path, Value: value})
+			}
+			err = tx.Update(refs[i], updates)
+		case FS_WRITE_DELETE:
+			err = tx.Delete(refs[i])
+		}
+		if err != nil {
+			return fmt.Errorf("write %d: %w", i, err)
+		}
+	}
+	return nil
+}
+
+// checkReadConditions compares the Equals values in JSON, so the numbers read from firestore match the JSON numbers.
+func checkReadConditions(read FSTransactionRead, doc *firestore.DocumentSnapshot) error {
+	path := read.Collection + "/" + read.ID
+	if read.Exists != nil && *read.Exists != doc.Exists() {
+		if *read.Exists {
+			return fmt.Errorf("document %s does not exist", path)
+		}
+		return fmt.Errorf("document %s already exists", path)
+	}
+	if len(read.Equals) == 0 {
+		return nil
+	}
+	if !doc.Exists() {
+		return fmt.Errorf("document %s does not exist", path)
+	}
+	for field, expected := range read.Equals {
+		actual, err := doc.DataAtPath(strings.Split(field, "."))
+		if err != nil {
+			return fmt.Errorf("document %s field %s does not exist", path, field)
+		}
+		expectedJSON, errE := json.Marshal(expected)
+		actualJSON, errA := json.Marshal(actual)
+		if errE != nil || errA != nil || !bytes.Equal(expectedJSON, actualJSON) {
+			return fmt.Errorf("document %s field %s does not match, expected %s, got %s", path, field, expectedJSON, actualJSON)
+		}
+	}
+	return nil
+}
+
+func refPaths(refs []*firestore.DocumentRef) []string {
+	paths := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		paths = append(paths, documentPath(ref))
+	}
+	return paths
 }
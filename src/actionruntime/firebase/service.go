@@ -133,6 +133,9 @@ func (f *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 	case FIRESTORE_SERVICE:
 		operationRunner := &FirestoreOperationRunner{client: app, operation: f.ActionOpts.Operation, options: f.ActionOpts.Options}
 		result, err = operationRunner.run()
+	case STORAGE_SERVICE:
+		operationRunner := &StorageOperationRunner{client: app, operation: f.ActionOpts.Operation, options: f.ActionOpts.Options, serviceAccount: f.ResourceOpts.PrivateKey}
+		result, err = operationRunner.run()
 	default:
 		result.Success = false
 		err = errors.New("unsupported operation")
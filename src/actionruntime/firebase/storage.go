@@ -0,0 +1,356 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package firebase
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	gcs "cloud.google.com/go/storage"
+	firebase "firebase.google.com/go/v4"
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"google.golang.org/api/iterator"
+)
+
+const (
+	STORAGE_LIST_FILES_OP     = "list_files"
+	STORAGE_UPLOAD_FILE_OP    = "upload_file"
+	STORAGE_DOWNLOAD_FILE_OP  = "download_file"
+	STORAGE_DELETE_FILE_OP    = "delete_file"
+	STORAGE_GET_SIGNED_URL_OP = "get_signed_url"
+)
+
+const (
+	STORAGE_DEFAULT_LIST_LIMIT      = 100
+	STORAGE_MAX_LIST_LIMIT          = 1000
+	STORAGE_MAX_DOWNLOAD_SIZE       = 16 * 1024 * 1024
+	STORAGE_DEFAULT_SIGNED_URL_TIME = 15 * 60
+	STORAGE_MAX_SIGNED_URL_TIME     = 7 * 24 * 60 * 60
+)
+
+type StorageOperationRunner struct {
+	client         *firebase.App
+	operation      string
+	options        map[string]interface{}
+	serviceAccount map[string]interface{}
+}
+
+// StorageListOptions lists the files of the bucket, the Bucket defaults to the storage bucket of the resource.
+type StorageListOptions struct {
+	Bucket    string
+	Prefix    string
+	Delimiter string
+	Limit     int
+	PageToken string
+}
+
+type StorageUploadOptions struct {
+	Bucket       string
+	Name         string `validate:"required"`
+	Data         string `validate:"required"` // base64 encoded
+	ContentType  string
+	CacheControl string
+	Metadata     map[string]string
+}
+
+type StorageFileOptions struct {
+	Bucket string
+	Name   string `validate:"required"`
+}
+
+type StorageSignedURLOptions struct {
+	Bucket      string
+	Name        string `validate:"required"`
+	Method      string `validate:"omitempty,oneof=GET PUT DELETE"`
+	Expires     int    `validate:"gte=0"` // seconds
+	ContentType string
+}
+
+func (s *StorageOperationRunner) run() (common.RuntimeResult, error) {
+	var result common.RuntimeResult
+	var err error
+	switch s.operation {
+	case STORAGE_LIST_FILES_OP:
+		result, err = s.listFiles()
+	case STORAGE_UPLOAD_FILE_OP:
+		result, err = s.uploadFile()
+	case STORAGE_DOWNLOAD_FILE_OP:
+		result, err = s.downloadFile()
+	case STORAGE_DELETE_FILE_OP:
+		result, err = s.deleteFile()
+	case STORAGE_GET_SIGNED_URL_OP:
+		result, err = s.getSignedURL()
+	default:
+		result.Success = false
+		err = errors.New("unsupported operation")
+	}
+	return result, err
+}
+
+// getBucket returns the bucket by name, or the storage bucket of the resource.
+func (s *StorageOperationRunner) getBucket(ctx context.Context, name string) (*gcs.BucketHandle, error) {
+	client, err := s.client.Storage(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if name != "" {
+		return client.Bucket(name)
+	}
+	return client.DefaultBucket()
+}
+
+func (s *StorageOperationRunner) listFiles() (common.RuntimeResult, error) {
+	var listOptions StorageListOptions
+	if err := mapstructure.Decode(s.options, &listOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate Firebase Storage `list files` action options
+	validate := validator.New()
+	if err := validate.Struct(listOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// build list files action
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	bucket, err := s.getBucket(ctx, listOptions.Bucket)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	pageSize := STORAGE_DEFAULT_LIST_LIMIT
+	if listOptions.Limit > 0 {
+		pageSize = listOptions.Limit
+	}
+	if pageSize > STORAGE_MAX_LIST_LIMIT {
+		pageSize = STORAGE_MAX_LIST_LIMIT
+	}
+	iter := bucket.Objects(ctx, &gcs.Query{Prefix: listOptions.Prefix, Delimiter: listOptions.Delimiter})
+	pager := iterator.NewPager(iter, pageSize, listOptions.PageToken)
+	var objects []*gcs.ObjectAttrs
+	nextPageToken, err := pager.NextPage(&objects)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// the objects under a delimiter only hold the prefix
+	files := make([]map[string]interface{}, 0, len(objects))
+	prefixes := []string{}
+	for _, object := range objects {
+		if object.Prefix != "" {
+			prefixes = append(prefixes, object.Prefix)
+			continue
+		}
+		files = append(files, buildFileAttrs(object))
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    files,
+		Extra: map[string]interface{}{
+			"prefixes":      prefixes,
+			"nextPageToken": nextPageToken,
+			"hasMore":       nextPageToken != "",
+		},
+	}, nil
+}
+
+func (s *StorageOperationRunner) uploadFile() (common.RuntimeResult, error) {
+	var uploadOptions StorageUploadOptions
+	if err := mapstructure.Decode(s.options, &uploadOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate Firebase Storage `upload file` action options
+	validate := validator.New()
+	if err := validate.Struct(uploadOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	data, err := base64.StdEncoding.DecodeString(uploadOptions.Data)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("file data must be base64 encoded: " + err.Error())
+	}
+
+	// build upload file action
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	bucket, err := s.getBucket(ctx, uploadOptions.Bucket)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	writer := bucket.Object(uploadOptions.Name).NewWriter(ctx)
+	writer.ContentType = uploadOptions.ContentType
+	if writer.ContentType == "" {
+		writer.ContentType = http.DetectContentType(data)
+	}
+	writer.CacheControl = uploadOptions.CacheControl
+	writer.Metadata = uploadOptions.Metadata
+	if _, err := io.Copy(writer, bytes.NewReader(data)); err != nil {
+		writer.Close()
+		return common.RuntimeResult{Success: false}, err
+	}
+	if err := writer.Close(); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{buildFileAttrs(writer.Attrs())}}, nil
+}
+
+func (s *StorageOperationRunner) downloadFile() (common.RuntimeResult, error) {
+	var downloadOptions StorageFileOptions
+	if err := mapstructure.Decode(s.options, &downloadOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate Firebase Storage `download file` action options
+	validate := validator.New()
+	if err := validate.Struct(downloadOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// build download file action
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	bucket, err := s.getBucket(ctx, downloadOptions.Bucket)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	object := bucket.Object(downloadOptions.Name)
+	attrs, err := object.Attrs(ctx)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if attrs.Size > STORAGE_MAX_DOWNLOAD_SIZE {
+		return common.RuntimeResult{Success: false}, fmt.Errorf("file size %d exceeds the download limit %d, please use a signed url instead", attrs.Size, STORAGE_MAX_DOWNLOAD_SIZE)
+	}
+	reader, err := object.Generation(attrs.Generation).NewReader(ctx)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	file := buildFileAttrs(attrs)
+	file["data"] = base64.StdEncoding.EncodeToString(data)
+	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{file}}, nil
+}
+
+func (s *StorageOperationRunner) deleteFile() (common.RuntimeResult, error) {
+	var deleteOptions StorageFileOptions
+	if err := mapstructure.Decode(s.options, &deleteOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate Firebase Storage `delete file` action options
+	validate := validator.New()
+	if err := validate.Struct(deleteOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// build delete file action
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	bucket, err := s.getBucket(ctx, deleteOptions.Bucket)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if err := bucket.Object(deleteOptions.Name).Delete(ctx); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{{"message": "delete file successfully"}}}, nil
+}
+
+// getSignedURL signs the url with the private key of the resource service account, so no IAM signBlob permission
+// is required.
+func (s *StorageOperationRunner) getSignedURL() (common.RuntimeResult, error) {
+	var signedURLOptions StorageSignedURLOptions
+	if err := mapstructure.Decode(s.options, &signedURLOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate Firebase Storage `get signed url` action options
+	validate := validator.New()
+	if err := validate.Struct(signedURLOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	clientEmail, _ := s.serviceAccount["client_email"].(string)
+	privateKey, _ := s.serviceAccount["private_key"].(string)
+	if clientEmail == "" || privateKey == "" {
+		return common.RuntimeResult{Success: false}, errors.New("missing client_email or private_key in the service account")
+	}
+
+	// build get signed url action
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	bucket, err := s.getBucket(ctx, signedURLOptions.Bucket)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	expires := STORAGE_DEFAULT_SIGNED_URL_TIME
+	if signedURLOptions.Expires > 0 {
+		expires = signedURLOptions.Expires
+	}
+	if expires > STORAGE_MAX_SIGNED_URL_TIME {
+		return common.RuntimeResult{Success: false}, fmt.Errorf("signed url expires can not exceed %d seconds", STORAGE_MAX_SIGNED_URL_TIME)
+	}
+	method := signedURLOptions.Method
+	if method == "" {
+		method = http.MethodGet
+	}
+	expiresAt := time.Now().Add(time.Duration(expires) * time.Second)
+	url, err := bucket.SignedURL(signedURLOptions.Name, &gcs.SignedURLOptions{
+		GoogleAccessID: clientEmail,
+		PrivateKey:     []byte(privateKey),
+		Method:         method,
+		Expires:        expiresAt,
+		ContentType:    signedURLOptions.ContentType,
+		Scheme:         gcs.SigningSchemeV4,
+	})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"signedURL": url, "method": method, "expiresAt": expiresAt.UTC().Format(time.RFC3339)}},
+	}, nil
+}
+
+func buildFileAttrs(attrs *gcs.ObjectAttrs) map[string]interface{} {
+	return map[string]interface{}{
+		"bucket":       attrs.Bucket,
+		"name":         attrs.Name,
+		"size":         attrs.Size,
+		"contentType":  attrs.ContentType,
+		"cacheControl": attrs.CacheControl,
+		"md5":          base64.StdEncoding.EncodeToString(attrs.MD5),
+		"generation":   attrs.Generation,
+		"metadata":     attrs.Metadata,
+		"created":      attrs.Created,
+		"updated":      attrs.Updated,
+	}
+}
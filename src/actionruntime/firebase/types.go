@@ -18,16 +18,18 @@ const (
 	AUTH_SERVICE      = "auth"
 	DATABASE_SERVICE  = "database"
 	FIRESTORE_SERVICE = "firestore"
+	STORAGE_SERVICE   = "storage"
 )
 
 type Resource struct {
-	DatabaseURL string                 `validate:"required,url"`
-	ProjectID   string                 `validate:"required"`
-	PrivateKey  map[string]interface{} `validate:"required"`
+	DatabaseURL   string                 `validate:"required,url"`
+	ProjectID     string                 `validate:"required"`
+	PrivateKey    map[string]interface{} `validate:"required"`
+	StorageBucket string
 }
 
 type Action struct {
-	Service   string                 `validate:"required,oneof=firestore database auth"`
+	Service   string                 `validate:"required,oneof=firestore database auth storage"`
 	Operation string                 `validate:"required"`
 	Options   map[string]interface{} `validate:"required"`
 }
@@ -20,6 +20,7 @@ import (
 	"fmt"
 	"strconv"
 
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
 	"github.com/mitchellh/mapstructure"
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
@@ -41,6 +42,11 @@ const (
 	COPY_ACTION       = "copy"
 	LIST_ACTION       = "list"
 	GET_ACTION        = "get"
+
+	BATCH_UPDATE_VALUES_ACTION = "batchUpdateValues"
+
+	DEFAULT_SHEET_NAME = "Sheet1"
+	MAX_READ_ROWS      = 10000 // data rows read by the limit range without limit, or scanned by the filters
 )
 
 func (g *Connector) getSheetsWithOpts(resourceOptions map[string]interface{}) (*sheets.Service, error) {
@@ -53,7 +59,7 @@ func (g *Connector) getSheetsWithOpts(resourceOptions map[string]interface{}) (*
 		if err := mapstructure.Decode(g.resourceOptions.Opts, &saOpts); err != nil {
 			return nil, err
 		}
-		return getSheetsWithKey(saOpts.PrivateKey)
+		return getSheetsWithKey(saOpts)
 	case OAUTH2_AUTH:
 		var oauth2Opts OAuth2Opts
 		if err := mapstructure.Decode(g.resourceOptions.Opts, &oauth2Opts); err != nil {
@@ -65,11 +71,12 @@ func (g *Connector) getSheetsWithOpts(resourceOptions map[string]interface{}) (*
 	}
 }
 
-func getSheetsWithKey(privateKey string) (*sheets.Service, error) {
-	config, err := google.JWTConfigFromJSON([]byte(privateKey), sheets.SpreadsheetsScope)
+func getSheetsWithKey(opts SAOpts) (*sheets.Service, error) {
+	config, err := google.JWTConfigFromJSON([]byte(opts.PrivateKey), sheets.SpreadsheetsScope)
 	if err != nil {
 		return nil, err
 	}
+	config.Subject = opts.Subject
 
 	// create an OAuth2 client using JWT configuration.
 	ctx := context.Background()
@@ -106,7 +113,7 @@ func (g *Connector) getDriveWithOpts(resourceOptions map[string]interface{}) (*d
 		if err := mapstructure.Decode(g.resourceOptions.Opts, &saOpts); err != nil {
 			return nil, err
 		}
-		return getDriveWithKey(saOpts.PrivateKey)
+		return getDriveWithKey(saOpts)
 	case OAUTH2_AUTH:
 		var oauth2Opts OAuth2Opts
 		if err := mapstructure.Decode(g.resourceOptions.Opts, &oauth2Opts); err != nil {
@@ -118,11 +125,12 @@ func (g *Connector) getDriveWithOpts(resourceOptions map[string]interface{}) (*d
 	}
 }
 
-func getDriveWithKey(privateKey string) (*drive.Service, error) {
-	config, err := google.JWTConfigFromJSON([]byte(privateKey), drive.DriveScope)
+func getDriveWithKey(opts SAOpts) (*drive.Service, error) {
+	config, err := google.JWTConfigFromJSON([]byte(opts.PrivateKey), drive.DriveScope)
 	if err != nil {
 		return nil, err
 	}
+	config.Subject = opts.Subject
 
 	// create an OAuth2 client using JWT configuration.
 	ctx := context.Background()
@@ -148,6 +156,21 @@ func getDriveWithOAuth2(opts OAuth2Opts) (*drive.Service, error) {
 	return srv, nil
 }
 
+// testServiceAccount exchanges the service account key for an access token, so an invalid or deleted key, or a
+// subject without domain-wide delegation, fails before any sheet is read.
+func testServiceAccount(opts SAOpts) error {
+	config, err := google.JWTConfigFromJSON([]byte(opts.PrivateKey), sheets.SpreadsheetsScope)
+	if err != nil {
+		return err
+	}
+	config.Subject = opts.Subject
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	_, err = config.TokenSource(ctx).Token()
+	return err
+}
+
 func interfaceToString(i interface{}) string {
 	switch v := i.(type) {
 	case string:
@@ -0,0 +1,182 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package googlesheets
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	FILTER_EQUAL            = "="
+	FILTER_NOT_EQUAL        = "!="
+	FILTER_GREATER          = ">"
+	FILTER_GREATER_OR_EQUAL = ">="
+	FILTER_LESS             = "<"
+	FILTER_LESS_OR_EQUAL    = "<="
+	FILTER_CONTAINS         = "contains"
+	FILTER_NOT_CONTAINS     = "notContains"
+	FILTER_STARTS_WITH      = "startsWith"
+	FILTER_ENDS_WITH        = "endsWith"
+	FILTER_IS_EMPTY         = "isEmpty"
+	FILTER_IS_NOT_EMPTY     = "isNotEmpty"
+)
+
+// columnFilter is a Filter with the column index resolved from the header row.
+type columnFilter struct {
+	Filter
+	columnIndex int
+}
+
+// compileFilters resolves the filter columns by the header row, the filters without key are skipped.
+func compileFilters(header []interface{}, filters []Filter) ([]columnFilter, error) {
+	res := make([]columnFilter, 0, len(filters))
+	for _, filter := range filters {
+		if filter.Key == "" {
+			continue
+		}
+		if filter.Operator == "" {
+			filter.Operator = FILTER_EQUAL
+		}
+		switch filter.Operator {
+		case FILTER_EQUAL, FILTER_NOT_EQUAL, FILTER_GREATER, FILTER_GREATER_OR_EQUAL, FILTER_LESS, FILTER_LESS_OR_EQUAL,
+			FILTER_CONTAINS, FILTER_NOT_CONTAINS, FILTER_STARTS_WITH, FILTER_ENDS_WITH, FILTER_IS_EMPTY, FILTER_IS_NOT_EMPTY:
+		default:
+			return nil, fmt.Errorf("unsupported filter operator %s", filter.Operator)
+		}
+		columnIndex := getColumnIndex(header, filter.Key)
+		if columnIndex == -1 {
+			return nil, fmt.Errorf("column %s not found in the header row", filter.Key)
+		}
+		res = append(res, columnFilter{Filter: filter, columnIndex: columnIndex})
+	}
+	return res, nil
+}
+
+// matchFilters returns true when the row matches all the filters.
+func matchFilters(row []interface{}, filters []columnFilter) bool {
+	for _, filter := range filters {
+		if !filter.match(row) {
+			return false
+		}
+	}
+	return true
+}
+
+// match compares the cell as a number when both the cell and the value are numbers, otherwise as a string.
+func (f columnFilter) match(row []interface{}) bool {
+	cell := ""
+	if f.columnIndex < len(row) {
+		cell = interfaceToString(row[f.columnIndex])
+	}
+	switch f.Operator {
+	case FILTER_IS_EMPTY:
+		return cell == ""
+	case FILTER_IS_NOT_EMPTY:
+		return cell != ""
+	case FILTER_CONTAINS:
+		return strings.Contains(cell, f.Value)
+	case FILTER_NOT_CONTAINS:
+		return !strings.Contains(cell, f.Value)
+	case FILTER_STARTS_WITH:
+		return strings.HasPrefix(cell, f.Value)
+	case FILTER_ENDS_WITH:
+		return strings.HasSuffix(cell, f.Value)
+	}
+
+	compared := strings.Compare(cell, f.Value)
+	cellNumber, errC := strconv.ParseFloat(cell, 64)
+	valueNumber, errV := strconv.ParseFloat(f.Value, 64)
+	if errC == nil && errV == nil {
+		switch {
+		case cellNumber < valueNumber:
+			compared = -1
+		case cellNumber > valueNumber:
+			compared = 1
+		default:
+			compared = 0
+		}
+	}
+	switch f.Operator {
+	case FILTER_EQUAL:
+		return compared == 0
+	case FILTER_NOT_EQUAL:
+		return compared != 0
+	case FILTER_GREATER:
+		return compared > 0
+	case FILTER_GREATER_OR_EQUAL:
+		return compared >= 0
+	case FILTER_LESS:
+		return compared < 0
+	case FILTER_LESS_OR_EQUAL:
+		return compared <= 0
+	}
+	return false
+}
+
+// quoteSheetName quotes the sheet name for A1 notation, like 'My Sheet'!A1.
+func quoteSheetName(sheetName string) string {
+	return "'" + strings.ReplaceAll(sheetName, "'", "''") + "'"
+}
+
+// sheetNameOfRange returns the sheet name of an A1 notation range, like "Sheet1" of "'Sheet1'!A2:C".
+func sheetNameOfRange(a1Notation string) (string, error) {
+	i := strings.LastIndex(a1Notation, "!")
+	if i <= 0 {
+		return "", fmt.Errorf("range %s has no sheet name", a1Notation)
+	}
+	sheetName := a1Notation[:i]
+	if len(sheetName) >= 2 && sheetName[0] == '\'' && sheetName[len(sheetName)-1] == '\'' {
+		sheetName = strings.ReplaceAll(sheetName[1:len(sheetName)-1], "''", "'")
+	}
+	return sheetName, nil
+}
+
+// rowToObject keys the cells by the column names of the header row, the columns without name are skipped.
+func rowToObject(header []interface{}, row []interface{}) map[string]interface{} {
+	res := make(map[string]interface{}, len(header))
+	for i, column := range header {
+		columnName := interfaceToString(column)
+		if columnName == "" {
+			continue
+		}
+		if i < len(row) {
+			res[columnName] = row[i]
+		} else {
+			res[columnName] = ""
+		}
+	}
+	return res
+}
+
+// objectsToValues orders the object values by the header row, a missing column is written as null, which keeps the
+// cell unchanged.
+func objectsToValues(header []interface{}, objects []map[string]interface{}) ([][]interface{}, error) {
+	res := make([][]interface{}, 0, len(objects))
+	for _, object := range objects {
+		for key := range object {
+			if getColumnIndex(header, key) == -1 {
+				return nil, fmt.Errorf("column %s not found in the header row", key)
+			}
+		}
+		rowValues := make([]interface{}, len(header))
+		for i, column := range header {
+			rowValues[i] = object[interfaceToString(column)]
+		}
+		res = append(res, rowValues)
+	}
+	return res, nil
+}
@@ -22,6 +22,7 @@ import (
 	"github.com/go-playground/validator/v10"
 	"github.com/illacloud/builder-backend/src/actionruntime/common"
 	"github.com/mitchellh/mapstructure"
+	"golang.org/x/oauth2/google"
 	"google.golang.org/api/sheets/v4"
 )
 
@@ -46,6 +47,20 @@ func (g *Connector) ValidateResourceOptions(resourceOptions map[string]interface
 	if err := validate.Struct(g.resourceOptions); err != nil {
 		return common.ValidateResult{Valid: false}, err
 	}
+
+	// validate the service account key
+	if g.resourceOptions.Authentication == SERVICE_ACCOUNT_AUTH {
+		var saOpts SAOpts
+		if err := mapstructure.Decode(g.resourceOptions.Opts, &saOpts); err != nil {
+			return common.ValidateResult{Valid: false}, err
+		}
+		if err := validate.Struct(saOpts); err != nil {
+			return common.ValidateResult{Valid: false}, err
+		}
+		if _, err := google.JWTConfigFromJSON([]byte(saOpts.PrivateKey)); err != nil {
+			return common.ValidateResult{Valid: false}, err
+		}
+	}
 	return common.ValidateResult{Valid: true}, nil
 }
 
@@ -64,6 +79,21 @@ func (g *Connector) ValidateActionTemplate(actionOptions map[string]interface{})
 }
 
 func (g *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	if err := mapstructure.Decode(resourceOptions, &g.resourceOptions); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+
+	// the oauth2 token is authorized after the resource is created, so only the service account can be tested
+	if g.resourceOptions.Authentication != SERVICE_ACCOUNT_AUTH {
+		return common.ConnectionResult{Success: true}, nil
+	}
+	var saOpts SAOpts
+	if err := mapstructure.Decode(g.resourceOptions.Opts, &saOpts); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	if err := testServiceAccount(saOpts); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
 	return common.ConnectionResult{Success: true}, nil
 }
 
@@ -143,6 +173,12 @@ func (g *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 			res.Success = false
 			return res, err
 		}
+	case BATCH_UPDATE_VALUES_ACTION:
+		res, err = actionRunner.BatchUpdateValues()
+		if err != nil {
+			res.Success = false
+			return res, err
+		}
 	case DELETE_ACTION:
 		res, err = actionRunner.DeleteSingleRow()
 		if err != nil {
@@ -205,75 +241,110 @@ func (r *ActionRunner) Read() (common.RuntimeResult, error) {
 		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
 	}
 
-	readRange := readOpts.A1Notation
+	// the offset and limit of limit range count the data rows below the header row
 	if readOpts.RangeType == "limit" {
-		sheetName := ""
-		if readOpts.SheetName == "" {
-			sheetName = "Sheet1"
+		sheetName := DEFAULT_SHEET_NAME
+		if readOpts.SheetName != "" {
+			sheetName = readOpts.SheetName
 		}
-
-		// get the total number of rows of a spreadsheet
-		resp, err := r.service.Spreadsheets.Get(readOpts.Spreadsheet).Do()
-		if err != nil {
-			return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+		if len(readOpts.Filters) == 0 {
+			return r.readLimitRange(readOpts, quoteSheetName(sheetName))
 		}
+		return r.readFilteredLimitRange(readOpts, quoteSheetName(sheetName))
+	}
 
-		totalRows := 0
-		for _, sheet := range resp.Sheets {
-			if sheet.Properties.Title == sheetName {
-				totalRows = int(sheet.Properties.GridProperties.RowCount)
-				break
-			}
-		}
+	valuesResp, err := r.service.Spreadsheets.Values.Get(readOpts.Spreadsheet, readOpts.A1Notation).Do()
+	if err != nil {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+	}
 
-		// calculate the range in A1 notation
-		startRow := readOpts.Offset + 1
-		endRow := 0
-		if readOpts.Limit == 0 {
-			endRow = totalRows
-		} else {
-			endRow = startRow + readOpts.Limit
-		}
-		if endRow > totalRows {
-			endRow = totalRows
-		}
+	if len(valuesResp.Values) == 0 {
+		return common.RuntimeResult{Success: true}, nil
+	}
 
-		if endRow == 0 {
-			readRange = fmt.Sprintf("%s!A%d:Z", sheetName, startRow)
-		} else {
-			readRange = fmt.Sprintf("%s!A%d:Z%d", sheetName, startRow, endRow)
+	// the first row of the range is the header row, and the data rows are filtered by its columns
+	headers := valuesResp.Values[0]
+	filters, err := compileFilters(headers, readOpts.Filters)
+	if err != nil {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+	}
+	data := make([]map[string]interface{}, 0, len(valuesResp.Values)-1)
+	for _, row := range valuesResp.Values[1:] {
+		if matchFilters(row, filters) {
+			data = append(data, rowToObject(headers, row))
 		}
-
 	}
 
-	valuesResp, err := r.service.Spreadsheets.Values.Get(readOpts.Spreadsheet, readRange).Do()
+	return common.RuntimeResult{Success: true, Rows: data, Extra: map[string]interface{}{"total": len(data)}}, nil
+}
+
+// readLimitRange reads the header row and the bounded rows of the page in a single request.
+func (r *ActionRunner) readLimitRange(readOpts ReadOpts, sheetName string) (common.RuntimeResult, error) {
+	offset := readOpts.Offset
+	if offset < 0 {
+		offset = 0
+	}
+	limit := readOpts.Limit
+	if limit <= 0 || limit > MAX_READ_ROWS {
+		limit = MAX_READ_ROWS
+	}
+	// the header is row 1, so the data rows start from row 2
+	startRow := offset + 2
+	endRow := startRow + limit - 1
+	valuesResp, err := r.service.Spreadsheets.Values.BatchGet(readOpts.Spreadsheet).
+		Ranges(fmt.Sprintf("%s!1:1", sheetName), fmt.Sprintf("%s!%d:%d", sheetName, startRow, endRow)).Do()
 	if err != nil {
 		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
 	}
+	if len(valuesResp.ValueRanges) != 2 || len(valuesResp.ValueRanges[0].Values) == 0 {
+		return common.RuntimeResult{Success: true}, nil
+	}
 
+	headers := valuesResp.ValueRanges[0].Values[0]
+	rows := valuesResp.ValueRanges[1].Values
+	data := make([]map[string]interface{}, 0, len(rows))
+	for _, row := range rows {
+		data = append(data, rowToObject(headers, row))
+	}
+	return common.RuntimeResult{Success: true, Rows: data}, nil
+}
+
+// readFilteredLimitRange scans at most MAX_READ_ROWS data rows with the filters, then pages the matched rows.
+func (r *ActionRunner) readFilteredLimitRange(readOpts ReadOpts, sheetName string) (common.RuntimeResult, error) {
+	valuesResp, err := r.service.Spreadsheets.Values.Get(readOpts.Spreadsheet, fmt.Sprintf("%s!1:%d", sheetName, MAX_READ_ROWS+1)).Do()
+	if err != nil {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+	}
 	if len(valuesResp.Values) == 0 {
 		return common.RuntimeResult{Success: true}, nil
 	}
 
-	// Create a slice of maps to store the data
-	data := make([]map[string]interface{}, len(valuesResp.Values)-1)
-
-	// Extract column headers
 	headers := valuesResp.Values[0]
-
-	// Iterate through the rows, skipping the header row
-	for i, row := range valuesResp.Values[1:] {
-		data[i] = make(map[string]interface{}, len(headers))
-		for j, cell := range row {
-			if j >= len(headers) {
-				break
-			}
-			header := interfaceToString(headers[j])
-			data[i][header] = cell
+	filters, err := compileFilters(headers, readOpts.Filters)
+	if err != nil {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+	}
+	data := make([]map[string]interface{}, 0)
+	for _, row := range valuesResp.Values[1:] {
+		if matchFilters(row, filters) {
+			data = append(data, rowToObject(headers, row))
 		}
 	}
+	total := len(data)
 
-	return common.RuntimeResult{Success: true, Rows: data}, nil
+	start := readOpts.Offset
+	if start < 0 || start > len(data) {
+		start = len(data)
+	}
+	end := len(data)
+	if readOpts.Limit > 0 && start+readOpts.Limit < end {
+		end = start + readOpts.Limit
+	}
+	data = data[start:end]
+
+	// the rows below MAX_READ_ROWS are not scanned, so the total is the lower bound when truncated
+	truncated := len(valuesResp.Values)-1 >= MAX_READ_ROWS
+	return common.RuntimeResult{Success: true, Rows: data, Extra: map[string]interface{}{"total": total, "truncated": truncated}}, nil
 }
 
 func (r *ActionRunner) Append() (common.RuntimeResult, error) {
@@ -379,7 +450,7 @@ func (r *ActionRunner) Update() (common.RuntimeResult, error) {
 	}
 
 	// get the header row in the sheet
-	readRange := fmt.Sprintf("%s!A1:Z1", updateOpts.SheetName)
+	readRange := quoteSheetName(updateOpts.SheetName) + "!1:1"
 	resp, err := r.service.Spreadsheets.Values.Get(updateOpts.Spreadsheet, readRange).Do()
 	if err != nil {
 		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
@@ -411,6 +482,9 @@ func (r *ActionRunner) Update() (common.RuntimeResult, error) {
 		}
 
 		resp, err := r.service.Spreadsheets.Values.Update(updateOpts.Spreadsheet, updateOpts.A1Notation, rb).ValueInputOption("RAW").Do()
+		if err != nil {
+			return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+		}
 		res[0] = map[string]interface{}{
 			"spreadsheetId": resp.SpreadsheetId,
 			"updates": map[string]interface{}{
@@ -421,9 +495,6 @@ func (r *ActionRunner) Update() (common.RuntimeResult, error) {
 				"updatedCells":   resp.UpdatedCells,
 			},
 		}
-		if err != nil {
-			return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
-		}
 	} else if updateOpts.FilterType == "filter" {
 		return updateSpreadsheetByFilters(r.service, updateOpts.Spreadsheet, updateOpts.SheetName, updateOpts.Filters, updateOpts.Values)
 	}
@@ -547,6 +618,90 @@ func (r *ActionRunner) BulkUpdate() (common.RuntimeResult, error) {
 	return common.RuntimeResult{Success: true, Rows: res}, nil
 }
 
+func (r *ActionRunner) BatchUpdateValues() (common.RuntimeResult, error) {
+	// format batchUpdateValues action options
+	var batchUpdateValuesOpts BatchUpdateValuesOpts
+	if err := mapstructure.Decode(r.opts, &batchUpdateValuesOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate batchUpdateValues action options
+	validate := validator.New()
+	if err := validate.Struct(batchUpdateValuesOpts); err != nil {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+	}
+
+	valueInputOption := batchUpdateValuesOpts.ValueInputOption
+	if valueInputOption == "" {
+		valueInputOption = "RAW"
+	}
+
+	// build the value ranges, the rows are ordered by the header row of their sheet
+	headers := make(map[string][]interface{})
+	data := make([]*sheets.ValueRange, 0, len(batchUpdateValuesOpts.Data))
+	for i, updateRange := range batchUpdateValuesOpts.Data {
+		values := updateRange.Values
+		if len(updateRange.Rows) != 0 {
+			sheetName, err := sheetNameOfRange(updateRange.Range)
+			if err != nil {
+				return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+			}
+			header, hit := headers[sheetName]
+			if !hit {
+				headerResp, err := r.service.Spreadsheets.Values.Get(batchUpdateValuesOpts.Spreadsheet, quoteSheetName(sheetName)+"!1:1").Do()
+				if err != nil {
+					return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+				}
+				if len(headerResp.Values) != 0 {
+					header = headerResp.Values[0]
+				}
+				headers[sheetName] = header
+			}
+			values, err = objectsToValues(header, updateRange.Rows)
+			if err != nil {
+				return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+			}
+		}
+		if len(values) == 0 {
+			return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": fmt.Sprintf("no values to update in range %d", i)}}}, nil
+		}
+		data = append(data, &sheets.ValueRange{
+			Range:          updateRange.Range,
+			MajorDimension: "ROWS",
+			Values:         values,
+		})
+	}
+
+	batchUpdate := &sheets.BatchUpdateValuesRequest{
+		ValueInputOption: valueInputOption,
+		Data:             data,
+	}
+	resp, err := r.service.Spreadsheets.Values.BatchUpdate(batchUpdateValuesOpts.Spreadsheet, batchUpdate).Do()
+	if err != nil {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+	}
+	res := make([]map[string]interface{}, 0, len(resp.Responses))
+	for _, updated := range resp.Responses {
+		res = append(res, map[string]interface{}{
+			"updatedRange":   updated.UpdatedRange,
+			"updatedRows":    updated.UpdatedRows,
+			"updatedColumns": updated.UpdatedColumns,
+			"updatedCells":   updated.UpdatedCells,
+		})
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    res,
+		Extra: map[string]interface{}{
+			"spreadsheetId":  resp.SpreadsheetId,
+			"updatedSheets":  resp.TotalUpdatedSheets,
+			"updatedRows":    resp.TotalUpdatedRows,
+			"updatedColumns": resp.TotalUpdatedColumns,
+			"updatedCells":   resp.TotalUpdatedCells,
+		},
+	}, nil
+}
+
 func (r *ActionRunner) DeleteSingleRow() (common.RuntimeResult, error) {
 	// format delete action options
 	var deleteOpts DeleteOpts
@@ -746,21 +901,41 @@ func (r *ActionRunner) GetSpreadsheetInfo() (common.RuntimeResult, error) {
 
 func updateSpreadsheetByFilters(srv *sheets.Service, spreadsheetID, sheetName string, filters []Filter, values []map[string]interface{}) (common.RuntimeResult, error) {
 	// get the sheet data
-	readRange := fmt.Sprintf("%s!A1:Z", sheetName)
-	response, err := srv.Spreadsheets.Values.Get(spreadsheetID, readRange).Do()
+	response, err := srv.Spreadsheets.Values.Get(spreadsheetID, quoteSheetName(sheetName)).Do()
 	if err != nil {
 		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
 	}
+	if len(response.Values) == 0 {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": "no data found"}}}, nil
+	}
+	if len(values) == 0 {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": "no values to update"}}}, nil
+	}
 
 	// Find the matching rows
-	matchingRows := findMatchingRows(response.Values, filters)
+	matchingRows, err := findMatchingRows(response.Values, filters)
+	if err != nil {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+	}
+	if len(matchingRows) == 0 {
+		return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{0: {"message": "no matching rows"}}}, nil
+	}
 
-	// Update the matching rows with the new values
+	// Update the matching rows with the new values, a single value object updates all the matching rows
+	header := response.Values[0]
 	var updateRows []*sheets.ValueRange
 	for i, rowIndex := range matchingRows {
-		row := response.Values[rowIndex]
-		updateValues(row, values[i], response.Values[0])
-		updateRange := fmt.Sprintf("%s!A%d:Z%d", sheetName, rowIndex+1, rowIndex+1)
+		rowValues := values[0]
+		if len(values) > 1 {
+			if i >= len(values) {
+				break
+			}
+			rowValues = values[i]
+		}
+		row := make([]interface{}, len(header))
+		copy(row, response.Values[rowIndex])
+		updateValues(row, rowValues, header)
+		updateRange := fmt.Sprintf("%s!A%d", quoteSheetName(sheetName), rowIndex+1)
 		updateRow := &sheets.ValueRange{
 			Range:  updateRange,
 			Values: [][]interface{}{row},
@@ -775,6 +950,9 @@ func updateSpreadsheetByFilters(srv *sheets.Service, spreadsheetID, sheetName st
 	}
 
 	resp, err := srv.Spreadsheets.Values.BatchUpdate(spreadsheetID, batchUpdate).Do()
+	if err != nil {
+		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
+	}
 	res := make([]map[string]interface{}, 1, 1)
 	res[0] = map[string]interface{}{
 		"spreadsheetId": resp.SpreadsheetId,
@@ -786,32 +964,28 @@ func updateSpreadsheetByFilters(srv *sheets.Service, spreadsheetID, sheetName st
 			"updatedCells":   resp.TotalUpdatedCells,
 		},
 	}
-	if err != nil {
-		return common.RuntimeResult{Success: false, Rows: []map[string]interface{}{0: {"message": err.Error()}}}, nil
-	}
 
 	return common.RuntimeResult{Success: true, Rows: res}, nil
 }
 
-// findMatchingRows is a helper function that returns the indices of rows matching the filters.
-func findMatchingRows(sheetData [][]interface{}, filters []Filter) []int {
-	var matchingRows []int
+// findMatchingRows is a helper function that returns the indices of data rows matching the filters.
+func findMatchingRows(sheetData [][]interface{}, filters []Filter) ([]int, error) {
+	columnFilters, err := compileFilters(sheetData[0], filters)
+	if err != nil {
+		return nil, err
+	}
 
+	var matchingRows []int
 	for rowIndex, row := range sheetData {
-		matches := true
-		for _, filter := range filters {
-			columnIndex := getColumnIndex(sheetData[0], filter.Key)
-			if columnIndex == -1 || interfaceToString(row[columnIndex]) != filter.Value {
-				matches = false
-				break
-			}
+		if rowIndex == 0 {
+			continue
 		}
-		if matches {
+		if matchFilters(row, columnFilters) {
 			matchingRows = append(matchingRows, rowIndex)
 		}
 	}
 
-	return matchingRows
+	return matchingRows, nil
 }
 
 // getColumnIndex is a helper function that returns the index of a column by its name.
@@ -19,8 +19,11 @@ type Resource struct {
 	Opts           map[string]interface{} `validate:"required"`
 }
 
+// SAOpts holds the service account JSON key, the Subject impersonates a user of the workspace domain when the
+// service account has domain-wide delegation.
 type SAOpts struct {
 	PrivateKey string `validate:"required"`
+	Subject    string `validate:"omitempty,email"`
 }
 
 type OAuth2Opts struct {
@@ -32,7 +35,7 @@ type OAuth2Opts struct {
 }
 
 type Action struct {
-	Method string                 `validate:"required,oneof=read append update bulkUpdate delete create copy list get"`
+	Method string                 `validate:"required,oneof=read append update bulkUpdate batchUpdateValues delete create copy list get"`
 	Opts   map[string]interface{} `validate:"required_unless=Method list"`
 }
 
@@ -43,6 +46,7 @@ type ReadOpts struct {
 	Offset      int
 	RangeType   string `validate:"required,oneof=a1 limit"`
 	A1Notation  string
+	Filters     []Filter
 }
 
 type AppendOpts struct {
@@ -60,6 +64,7 @@ type UpdateOpts struct {
 	Filters     []Filter
 }
 
+// Filter matches the cells of the Key column, the Operator defaults to "=".
 type Filter struct {
 	Key      string
 	Operator string
@@ -73,6 +78,21 @@ type BulkUpdateOpts struct {
 	RowsArray   []map[string]interface{}
 }
 
+// BatchUpdateValuesOpts updates multiple ranges in one request.
+type BatchUpdateValuesOpts struct {
+	Spreadsheet      string             `validate:"required"`
+	ValueInputOption string             `validate:"omitempty,oneof=RAW USER_ENTERED"`
+	Data             []BatchUpdateRange `validate:"required,min=1,dive"`
+}
+
+// BatchUpdateRange writes the Values as they are, or the Rows keyed by the column names of the header row, the
+// Rows start at the first column of the sheet.
+type BatchUpdateRange struct {
+	Range  string `validate:"required"`
+	Values [][]interface{}
+	Rows   []map[string]interface{}
+}
+
 type DeleteOpts struct {
 	Spreadsheet string `validate:"required"`
 	SheetName   string
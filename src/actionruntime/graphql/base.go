@@ -28,6 +28,31 @@ const (
 	AUTH_APIKEY = "apiKey"
 )
 
+// buildResourceRequestOptions collects the URL params, headers and cookies of resource without template.
+func (g *Connector) buildResourceRequestOptions() (map[string]string, map[string]string, map[string]string) {
+	queryParams := make(map[string]string)
+	headers := make(map[string]string)
+	cookies := make(map[string]string)
+	for _, param := range g.ResourceOpts.URLParams {
+		if param["key"] != "" {
+			queryParams[param["key"]] = param["value"]
+		}
+	}
+
+	for _, header := range g.ResourceOpts.Headers {
+		if header["key"] != "" {
+			headers[header["key"]] = header["value"]
+		}
+	}
+
+	for _, cookie := range g.ResourceOpts.Cookies {
+		if cookie["key"] != "" {
+			cookies[cookie["key"]] = cookie["value"]
+		}
+	}
+	return queryParams, headers, cookies
+}
+
 func (g *Connector) doQuery(baseURL string, queryParams, headers, cookies map[string]string, authentication string,
 	authContent map[string]string, query string, vars map[string]interface{}) (*resty.Response, error) {
 
@@ -0,0 +1,305 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package graphql
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"time"
+)
+
+const INTROSPECTION_CACHE_TTL = 10 * time.Minute
+
+// INTROSPECTION_QUERY is the standard introspection query, the type reference is unwrapped up to 7 levels like [[Int!]!]!.
+const INTROSPECTION_QUERY = `query IntrospectionQuery {
+  __schema {
+    queryType { name }
+    mutationType { name }
+    subscriptionType { name }
+    types { ...FullType }
+  }
+}
+fragment FullType on __Type {
+  kind
+  name
+  description
+  fields(includeDeprecated: true) {
+    name
+    description
+    args { ...InputValue }
+    type { ...TypeRef }
+    isDeprecated
+    deprecationReason
+  }
+  inputFields { ...InputValue }
+  interfaces { ...TypeRef }
+  enumValues(includeDeprecated: true) { name description isDeprecated deprecationReason }
+  possibleTypes { ...TypeRef }
+}
+fragment InputValue on __InputValue {
+  name
+  description
+  type { ...TypeRef }
+  defaultValue
+}
+fragment TypeRef on __Type {
+  kind
+  name
+  ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name ofType { kind name } } } } } } }
+}`
+
+type introspectionResponse struct {
+	Data struct {
+		Schema struct {
+			QueryType        *namedType         `json:"queryType"`
+			MutationType     *namedType         `json:"mutationType"`
+			SubscriptionType *namedType         `json:"subscriptionType"`
+			Types            []introspectedType `json:"types"`
+		} `json:"__schema"`
+	} `json:"data"`
+	Errors []struct {
+		Message string `json:"message"`
+	} `json:"errors"`
+}
+
+type namedType struct {
+	Name string `json:"name"`
+}
+
+type typeRef struct {
+	Kind   string   `json:"kind"`
+	Name   string   `json:"name"`
+	OfType *typeRef `json:"ofType"`
+}
+
+type inputValue struct {
+	Name         string  `json:"name"`
+	Description  string  `json:"description"`
+	Type         typeRef `json:"type"`
+	DefaultValue *string `json:"defaultValue"`
+}
+
+type introspectedField struct {
+	Name              string       `json:"name"`
+	Description       string       `json:"description"`
+	Args              []inputValue `json:"args"`
+	Type              typeRef      `json:"type"`
+	IsDeprecated      bool         `json:"isDeprecated"`
+	DeprecationReason string       `json:"deprecationReason"`
+}
+
+type introspectedType struct {
+	Kind        string              `json:"kind"`
+	Name        string              `json:"name"`
+	Description string              `json:"description"`
+	Fields      []introspectedField `json:"fields"`
+	InputFields []inputValue        `json:"inputFields"`
+	Interfaces  []typeRef           `json:"interfaces"`
+	EnumValues  []struct {
+		Name string `json:"name"`
+	} `json:"enumValues"`
+	PossibleTypes []typeRef `json:"possibleTypes"`
+}
+
+// String formats the type reference in SDL notation like "[User!]!".
+func (t typeRef) String() string {
+	switch t.Kind {
+	case "NON_NULL":
+		if t.OfType != nil {
+			return t.OfType.String() + "!"
+		}
+	case "LIST":
+		if t.OfType != nil {
+			return "[" + t.OfType.String() + "]"
+		}
+	}
+	return t.Name
+}
+
+// named returns the innermost named type of the type reference.
+func (t typeRef) named() typeRef {
+	for t.OfType != nil && (t.Kind == "NON_NULL" || t.Kind == "LIST") {
+		t = *t.OfType
+	}
+	return t
+}
+
+func exportTypeRef(t typeRef) map[string]interface{} {
+	named := t.named()
+	return map[string]interface{}{"type": t.String(), "baseType": named.Name, "baseKind": named.Kind}
+}
+
+func exportInputValues(values []inputValue) []map[string]interface{} {
+	exported := make([]map[string]interface{}, 0, len(values))
+	for _, value := range values {
+		exportedValue := exportTypeRef(value.Type)
+		exportedValue["name"] = value.Name
+		exportedValue["description"] = value.Description
+		exportedValue["required"] = value.Type.Kind == "NON_NULL" && value.DefaultValue == nil
+		if value.DefaultValue != nil {
+			exportedValue["defaultValue"] = *value.DefaultValue
+		}
+		exported = append(exported, exportedValue)
+	}
+	return exported
+}
+
+func exportFields(fields []introspectedField) []map[string]interface{} {
+	exported := make([]map[string]interface{}, 0, len(fields))
+	for _, field := range fields {
+		exportedField := exportTypeRef(field.Type)
+		exportedField["name"] = field.Name
+		exportedField["description"] = field.Description
+		exportedField["args"] = exportInputValues(field.Args)
+		exportedField["deprecated"] = field.IsDeprecated
+		if field.IsDeprecated {
+			exportedField["deprecationReason"] = field.DeprecationReason
+		}
+		exported = append(exported, exportedField)
+	}
+	return exported
+}
+
+// normalizeIntrospection converts the introspection result into the root operations and the named types, the
+// introspection types like "__Schema" are skipped.
+func normalizeIntrospection(response *introspectionResponse) map[string]interface{} {
+	schema := response.Data.Schema
+	rootTypeName := func(t *namedType) string {
+		if t == nil {
+			return ""
+		}
+		return t.Name
+	}
+	rootTypeNames := map[string]string{
+		"queries":       rootTypeName(schema.QueryType),
+		"mutations":     rootTypeName(schema.MutationType),
+		"subscriptions": rootTypeName(schema.SubscriptionType),
+	}
+
+	normalized := map[string]interface{}{
+		"queryType":        rootTypeNames["queries"],
+		"mutationType":     rootTypeNames["mutations"],
+		"subscriptionType": rootTypeNames["subscriptions"],
+		"queries":          []map[string]interface{}{},
+		"mutations":        []map[string]interface{}{},
+		"subscriptions":    []map[string]interface{}{},
+	}
+	types := make([]map[string]interface{}, 0, len(schema.Types))
+	for _, introspected := range schema.Types {
+		if strings.HasPrefix(introspected.Name, "__") {
+			continue
+		}
+		for key, rootTypeName := range rootTypeNames {
+			if rootTypeName != "" && rootTypeName == introspected.Name {
+				normalized[key] = exportFields(introspected.Fields)
+			}
+		}
+		exportedType := map[string]interface{}{
+			"name":        introspected.Name,
+			"kind":        introspected.Kind,
+			"description": introspected.Description,
+		}
+		switch introspected.Kind {
+		case "OBJECT", "INTERFACE":
+			exportedType["fields"] = exportFields(introspected.Fields)
+			interfaces := make([]string, 0, len(introspected.Interfaces))
+			for _, implemented := range introspected.Interfaces {
+				interfaces = append(interfaces, implemented.Name)
+			}
+			exportedType["interfaces"] = interfaces
+		case "INPUT_OBJECT":
+			exportedType["inputFields"] = exportInputValues(introspected.InputFields)
+		case "ENUM":
+			enumValues := make([]string, 0, len(introspected.EnumValues))
+			for _, enumValue := range introspected.EnumValues {
+				enumValues = append(enumValues, enumValue.Name)
+			}
+			exportedType["enumValues"] = enumValues
+		}
+		if introspected.Kind == "UNION" || introspected.Kind == "INTERFACE" {
+			possibleTypes := make([]string, 0, len(introspected.PossibleTypes))
+			for _, possibleType := range introspected.PossibleTypes {
+				possibleTypes = append(possibleTypes, possibleType.Name)
+			}
+			exportedType["possibleTypes"] = possibleTypes
+		}
+		types = append(types, exportedType)
+	}
+	normalized["types"] = types
+	return normalized
+}
+
+func parseIntrospection(body []byte) (map[string]interface{}, error) {
+	var response introspectionResponse
+	if err := json.Unmarshal(body, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Errors) > 0 {
+		messages := make([]string, 0, len(response.Errors))
+		for _, responseError := range response.Errors {
+			messages = append(messages, responseError.Message)
+		}
+		return nil, errors.New("introspection failed: " + strings.Join(messages, "; "))
+	}
+	if len(response.Data.Schema.Types) == 0 {
+		return nil, errors.New("introspection failed: empty schema")
+	}
+	return normalizeIntrospection(&response), nil
+}
+
+// introspectionCache caches the normalized schema per resource, the resource is identified by the options which
+// affect the request, so the cached schema is dropped when the resource is modified.
+var introspectionCache = struct {
+	sync.Mutex
+	entries map[string]introspectionCacheEntry
+}{entries: make(map[string]introspectionCacheEntry)}
+
+type introspectionCacheEntry struct {
+	schema    map[string]interface{}
+	expiredAt time.Time
+}
+
+func introspectionCacheKey(resource Resource) string {
+	serialized, _ := json.Marshal(resource)
+	digest := sha256.Sum256(serialized)
+	return hex.EncodeToString(digest[:])
+}
+
+func getCachedIntrospection(key string) (map[string]interface{}, bool) {
+	introspectionCache.Lock()
+	defer introspectionCache.Unlock()
+	entry, hit := introspectionCache.entries[key]
+	if !hit || time.Now().After(entry.expiredAt) {
+		delete(introspectionCache.entries, key)
+		return nil, false
+	}
+	return entry.schema, true
+}
+
+func setCachedIntrospection(key string, schema map[string]interface{}) {
+	introspectionCache.Lock()
+	defer introspectionCache.Unlock()
+	now := time.Now()
+	for cachedKey, entry := range introspectionCache.entries {
+		if now.After(entry.expiredAt) {
+			delete(introspectionCache.entries, cachedKey)
+		}
+	}
+	introspectionCache.entries[key] = introspectionCacheEntry{schema: schema, expiredAt: now.Add(INTROSPECTION_CACHE_TTL)}
+}
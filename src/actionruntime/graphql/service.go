@@ -68,27 +68,7 @@ func (g *Connector) TestConnection(resourceOptions map[string]interface{}) (comm
 		return common.ConnectionResult{Success: false}, err
 	}
 
-	queryParams := make(map[string]string)
-	headers := make(map[string]string)
-	cookies := make(map[string]string)
-	for _, param := range g.ResourceOpts.URLParams {
-		if param["key"] != "" {
-			queryParams[param["key"]] = param["value"]
-		}
-	}
-
-	for _, header := range g.ResourceOpts.Headers {
-		if header["key"] != "" {
-			headers[header["key"]] = header["value"]
-		}
-	}
-
-	for _, cookie := range g.ResourceOpts.Cookies {
-		if cookie["key"] != "" {
-			cookies[cookie["key"]] = cookie["value"]
-		}
-	}
-
+	queryParams, headers, cookies := g.buildResourceRequestOptions()
 	resp, err := g.doQuery(g.ResourceOpts.BaseURL, queryParams, headers, cookies, g.ResourceOpts.Authentication,
 		g.ResourceOpts.AuthContent, "{__typename}", nil)
 	if err != nil {
@@ -103,9 +83,47 @@ func (g *Connector) TestConnection(resourceOptions map[string]interface{}) (comm
 }
 
 func (g *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &g.ResourceOpts); err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	// the schema is not available when the introspection is disabled
+	if g.ResourceOpts.DisableIntrospection {
+		return common.MetaInfoResult{
+			Success: true,
+			Schema:  nil,
+		}, nil
+	}
+
+	// use the cached schema of resource
+	cacheKey := introspectionCacheKey(g.ResourceOpts)
+	if schema, hit := getCachedIntrospection(cacheKey); hit {
+		return common.MetaInfoResult{
+			Success: true,
+			Schema:  schema,
+		}, nil
+	}
+
+	// run introspection query
+	queryParams, headers, cookies := g.buildResourceRequestOptions()
+	resp, err := g.doQuery(g.ResourceOpts.BaseURL, queryParams, headers, cookies, g.ResourceOpts.Authentication,
+		g.ResourceOpts.AuthContent, INTROSPECTION_QUERY, nil)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	if resp.IsError() {
+		return common.MetaInfoResult{Success: false}, errors.New("introspection failed: " + resp.Status())
+	}
+	schema, err := parseIntrospection(resp.Body())
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	setCachedIntrospection(cacheKey, schema)
+
 	return common.MetaInfoResult{
 		Success: true,
-		Schema:  nil,
+		Schema:  schema,
 	}, nil
 }
 
@@ -0,0 +1,360 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/bufbuild/protocompile"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	rpb "google.golang.org/grpc/reflection/grpc_reflection_v1alpha"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protodesc"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+const reflectionServicePrefix = "grpc.reflection."
+
+func (g *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*grpcgo.ClientConn, error) {
+	if err := mapstructure.Decode(resourceOptions, &g.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	transportCredentials := insecure.NewCredentials()
+	if g.ResourceOpts.SSL.SSL {
+		tlsConfig, err := common.BuildTLSConfig(g.ResourceOpts.SSL.VerificationMode, g.ResourceOpts.SSL.CACert, g.ResourceOpts.SSL.ClientCert, g.ResourceOpts.SSL.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		transportCredentials = credentials.NewTLS(tlsConfig)
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), DEFAULT_CONNECT_TIMEOUT*time.Second)
+	defer cancel()
+	return grpcgo.DialContext(ctx, g.ResourceOpts.Target,
+		grpcgo.WithTransportCredentials(transportCredentials),
+		grpcgo.WithBlock(),
+		grpcgo.WithReturnConnectionError())
+}
+
+func buildMetadata(metadataLists ...[]map[string]string) metadata.MD {
+	md := metadata.MD{}
+	for _, metadataList := range metadataLists {
+		for _, pair := range metadataList {
+			if pair["key"] == "" {
+				continue
+			}
+			md.Append(strings.ToLower(pair["key"]), pair["value"])
+		}
+	}
+	return md
+}
+
+// loadDescriptors resolves the file descriptors and returns them with the service names.
+func (g *Connector) loadDescriptors(ctx context.Context, conn *grpcgo.ClientConn) (*protoregistry.Files, []string, error) {
+	switch g.ResourceOpts.DescriptorSource {
+	case DESCRIPTOR_SOURCE_REFLECTION:
+		return loadDescriptorsByReflection(ctx, conn)
+	case DESCRIPTOR_SOURCE_PROTO:
+		return loadDescriptorsFromProtoFiles(ctx, g.ResourceOpts.ProtoFiles)
+	case DESCRIPTOR_SOURCE_DESCRIPTOR_SET:
+		return loadDescriptorsFromDescriptorSet(g.ResourceOpts.DescriptorSet)
+	default:
+		return nil, nil, errors.New("unsupported descriptor source: " + g.ResourceOpts.DescriptorSource)
+	}
+}
+
+func loadDescriptorsByReflection(ctx context.Context, conn *grpcgo.ClientConn) (*protoregistry.Files, []string, error) {
+	stream, err := rpb.NewServerReflectionClient(conn).ServerReflectionInfo(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer stream.CloseSend()
+	fileDescriptorProtos := make(map[string]*descriptorpb.FileDescriptorProto)
+	request := func(req *rpb.ServerReflectionRequest) (*rpb.ServerReflectionResponse, error) {
+		if err := stream.Send(req); err != nil {
+			return nil, err
+		}
+		resp, err := stream.Recv()
+		if err != nil {
+			return nil, err
+		}
+		if errorResp := resp.GetErrorResponse(); errorResp != nil {
+			return nil, errors.New("server reflection error: " + errorResp.GetErrorMessage())
+		}
+		// cache the file descriptors, the server may return the dependencies together
+		if fileDescriptorResp := resp.GetFileDescriptorResponse(); fileDescriptorResp != nil {
+			for _, rawFileDescriptor := range fileDescriptorResp.GetFileDescriptorProto() {
+				fileDescriptorProto := &descriptorpb.FileDescriptorProto{}
+				if err := proto.Unmarshal(rawFileDescriptor, fileDescriptorProto); err != nil {
+					return nil, err
+				}
+				fileDescriptorProtos[fileDescriptorProto.GetName()] = fileDescriptorProto
+			}
+		}
+		return resp, nil
+	}
+
+	// list services
+	resp, err := request(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_ListServices{}})
+	if err != nil {
+		return nil, nil, err
+	}
+	serviceNames := make([]string, 0)
+	rootFiles := make([]string, 0)
+	for _, service := range resp.GetListServicesResponse().GetService() {
+		if strings.HasPrefix(service.GetName(), reflectionServicePrefix) {
+			continue
+		}
+		serviceNames = append(serviceNames, service.GetName())
+		fileResp, err := request(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_FileContainingSymbol{FileContainingSymbol: service.GetName()}})
+		if err != nil {
+			return nil, nil, err
+		}
+		rawFileDescriptors := fileResp.GetFileDescriptorResponse().GetFileDescriptorProto()
+		if len(rawFileDescriptors) > 0 {
+			fileDescriptorProto := &descriptorpb.FileDescriptorProto{}
+			if err := proto.Unmarshal(rawFileDescriptors[0], fileDescriptorProto); err != nil {
+				return nil, nil, err
+			}
+			rootFiles = append(rootFiles, fileDescriptorProto.GetName())
+		}
+	}
+
+	// fetch the missing dependencies by file name
+	files, err := buildRegistry(rootFiles, func(name string) (*descriptorpb.FileDescriptorProto, error) {
+		if fileDescriptorProto, hit := fileDescriptorProtos[name]; hit {
+			return fileDescriptorProto, nil
+		}
+		if _, err := request(&rpb.ServerReflectionRequest{MessageRequest: &rpb.ServerReflectionRequest_FileByFilename{FileByFilename: name}}); err != nil {
+			return nil, err
+		}
+		if fileDescriptorProto, hit := fileDescriptorProtos[name]; hit {
+			return fileDescriptorProto, nil
+		}
+		return nil, errors.New("file descriptor not found: " + name)
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, serviceNames, nil
+}
+
+func loadDescriptorsFromProtoFiles(ctx context.Context, protoFiles []ProtoFile) (*protoregistry.Files, []string, error) {
+	sources := make(map[string]string, len(protoFiles))
+	fileNames := make([]string, 0, len(protoFiles))
+	for _, protoFile := range protoFiles {
+		sources[protoFile.Name] = protoFile.Content
+		fileNames = append(fileNames, protoFile.Name)
+	}
+	compiler := protocompile.Compiler{
+		Resolver: protocompile.WithStandardImports(&protocompile.SourceResolver{Accessor: protocompile.SourceAccessorFromMap(sources)}),
+	}
+	compiledFiles, err := compiler.Compile(ctx, fileNames...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	fileDescriptors := make(map[string]protoreflect.FileDescriptor)
+	var collect func(fileDescriptor protoreflect.FileDescriptor)
+	collect = func(fileDescriptor protoreflect.FileDescriptor) {
+		if _, hit := fileDescriptors[fileDescriptor.Path()]; hit {
+			return
+		}
+		fileDescriptors[fileDescriptor.Path()] = fileDescriptor
+		imports := fileDescriptor.Imports()
+		for i := 0; i < imports.Len(); i++ {
+			collect(imports.Get(i).FileDescriptor)
+		}
+	}
+	for _, compiledFile := range compiledFiles {
+		collect(compiledFile)
+	}
+	files, err := buildRegistry(fileNames, func(name string) (*descriptorpb.FileDescriptorProto, error) {
+		fileDescriptor, hit := fileDescriptors[name]
+		if !hit {
+			return nil, errors.New("file descriptor not found: " + name)
+		}
+		return protodesc.ToFileDescriptorProto(fileDescriptor), nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, serviceNamesOf(files, fileNames), nil
+}
+
+func loadDescriptorsFromDescriptorSet(descriptorSet string) (*protoregistry.Files, []string, error) {
+	rawDescriptorSet, err := base64.StdEncoding.DecodeString(descriptorSet)
+	if err != nil {
+		return nil, nil, errors.New("descriptor set is not base64 encoded: " + err.Error())
+	}
+	fileDescriptorSet := &descriptorpb.FileDescriptorSet{}
+	if err := proto.Unmarshal(rawDescriptorSet, fileDescriptorSet); err != nil {
+		return nil, nil, err
+	}
+	fileDescriptorProtos := make(map[string]*descriptorpb.FileDescriptorProto, len(fileDescriptorSet.GetFile()))
+	fileNames := make([]string, 0, len(fileDescriptorSet.GetFile()))
+	for _, fileDescriptorProto := range fileDescriptorSet.GetFile() {
+		fileDescriptorProtos[fileDescriptorProto.GetName()] = fileDescriptorProto
+		fileNames = append(fileNames, fileDescriptorProto.GetName())
+	}
+	files, err := buildRegistry(fileNames, func(name string) (*descriptorpb.FileDescriptorProto, error) {
+		fileDescriptorProto, hit := fileDescriptorProtos[name]
+		if !hit {
+			return nil, errors.New("file descriptor not found: " + name)
+		}
+		return fileDescriptorProto, nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return files, serviceNamesOf(files, fileNames), nil
+}
+
+// buildRegistry registers the root files and their dependencies, the well-known types missing from the source are
+// taken from the types linked into the binary.
+func buildRegistry(rootFiles []string, fetch func(name string) (*descriptorpb.FileDescriptorProto, error)) (*protoregistry.Files, error) {
+	files := &protoregistry.Files{}
+	var register func(name string, visiting map[string]bool) error
+	register = func(name string, visiting map[string]bool) error {
+		if _, err := files.FindFileByPath(name); err == nil {
+			return nil
+		}
+		if visiting[name] {
+			return errors.New("import cycle in " + name)
+		}
+		visiting[name] = true
+		defer delete(visiting, name)
+
+		fileDescriptorProto, err := fetch(name)
+		if err != nil {
+			if globalFile, errInFindGlobal := protoregistry.GlobalFiles.FindFileByPath(name); errInFindGlobal == nil {
+				return files.RegisterFile(globalFile)
+			}
+			return err
+		}
+		for _, dependency := range fileDescriptorProto.GetDependency() {
+			if err := register(dependency, visiting); err != nil {
+				return err
+			}
+		}
+		fileDescriptor, err := protodesc.NewFile(fileDescriptorProto, files)
+		if err != nil {
+			return err
+		}
+		return files.RegisterFile(fileDescriptor)
+	}
+	for _, rootFile := range rootFiles {
+		if err := register(rootFile, map[string]bool{}); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+func serviceNamesOf(files *protoregistry.Files, fileNames []string) []string {
+	serviceNames := make([]string, 0)
+	for _, fileName := range fileNames {
+		fileDescriptor, err := files.FindFileByPath(fileName)
+		if err != nil {
+			continue
+		}
+		services := fileDescriptor.Services()
+		for i := 0; i < services.Len(); i++ {
+			serviceNames = append(serviceNames, string(services.Get(i).FullName()))
+		}
+	}
+	return serviceNames
+}
+
+func exportServices(files *protoregistry.Files, serviceNames []string) map[string]interface{} {
+	services := make(map[string]interface{}, len(serviceNames))
+	for _, serviceName := range serviceNames {
+		descriptor, err := files.FindDescriptorByName(protoreflect.FullName(serviceName))
+		if err != nil {
+			continue
+		}
+		serviceDescriptor, ok := descriptor.(protoreflect.ServiceDescriptor)
+		if !ok {
+			continue
+		}
+		methods := make([]map[string]interface{}, 0, serviceDescriptor.Methods().Len())
+		for i := 0; i < serviceDescriptor.Methods().Len(); i++ {
+			methodDescriptor := serviceDescriptor.Methods().Get(i)
+			methods = append(methods, map[string]interface{}{
+				"name":            string(methodDescriptor.Name()),
+				"fullMethod":      serviceName + "/" + string(methodDescriptor.Name()),
+				"inputType":       string(methodDescriptor.Input().FullName()),
+				"outputType":      string(methodDescriptor.Output().FullName()),
+				"clientStreaming": methodDescriptor.IsStreamingClient(),
+				"serverStreaming": methodDescriptor.IsStreamingServer(),
+			})
+		}
+		services[serviceName] = methods
+	}
+	return services
+}
+
+// findMethod accepts the method name like "package.Service/Method", "/package.Service/Method" or "package.Service.Method".
+func findMethod(files *protoregistry.Files, method string) (protoreflect.MethodDescriptor, error) {
+	fullName := strings.ReplaceAll(strings.TrimPrefix(method, "/"), "/", ".")
+	descriptor, err := files.FindDescriptorByName(protoreflect.FullName(fullName))
+	if err != nil {
+		return nil, errors.New("method not found: " + method)
+	}
+	methodDescriptor, ok := descriptor.(protoreflect.MethodDescriptor)
+	if !ok {
+		return nil, errors.New(method + " is not a method")
+	}
+	return methodDescriptor, nil
+}
+
+func exportMessage(message proto.Message, files *protoregistry.Files) (map[string]interface{}, error) {
+	rawMessage, err := protojson.MarshalOptions{EmitUnpopulated: true, Resolver: dynamicpb.NewTypes(files)}.Marshal(message)
+	if err != nil {
+		return nil, err
+	}
+	exported := make(map[string]interface{})
+	if err := json.Unmarshal(rawMessage, &exported); err != nil {
+		return nil, err
+	}
+	return exported, nil
+}
+
+func exportMetadata(md metadata.MD) map[string]interface{} {
+	exported := make(map[string]interface{}, len(md))
+	for key, values := range md {
+		if len(values) == 1 {
+			exported[key] = values[0]
+		} else {
+			exported[key] = values
+		}
+	}
+	return exported
+}
@@ -0,0 +1,208 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	grpcgo "google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/dynamicpb"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (g *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &g.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate grpc options
+	validate := validator.New()
+	if err := validate.Struct(g.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (g *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &g.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate grpc options
+	validate := validator.New()
+	if err := validate.Struct(g.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (g *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get grpc connection, the connection is established before returned
+	conn, err := g.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	// test method discovery
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.TODO(), buildMetadata(g.ResourceOpts.Metadata)), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	if _, _, err := g.loadDescriptors(ctx, conn); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (g *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get grpc connection
+	conn, err := g.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	// list services and methods
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.TODO(), buildMetadata(g.ResourceOpts.Metadata)), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	files, serviceNames, err := g.loadDescriptors(ctx, conn)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"services": exportServices(files, serviceNames)},
+	}, nil
+}
+
+func (g *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get grpc connection
+	conn, err := g.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get grpc connection: " + err.Error())
+	}
+	defer conn.Close()
+
+	// format grpc action
+	if err := mapstructure.Decode(actionOptions, &g.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	timeout := time.Duration(g.ActionOpts.Timeout) * time.Millisecond
+	if timeout <= 0 || timeout > common.DEFAULT_QUERY_AND_EXEC_TIMEOUT {
+		timeout = common.DEFAULT_QUERY_AND_EXEC_TIMEOUT
+	}
+	ctx, cancel := context.WithTimeout(metadata.NewOutgoingContext(context.TODO(), buildMetadata(g.ResourceOpts.Metadata, g.ActionOpts.Metadata)), timeout)
+	defer cancel()
+
+	// resolve method and build request message
+	files, _, err := g.loadDescriptors(ctx, conn)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	methodDescriptor, err := findMethod(files, g.ActionOpts.Method)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if methodDescriptor.IsStreamingClient() {
+		return common.RuntimeResult{Success: false}, errors.New("client streaming and bidirectional streaming methods are not supported")
+	}
+	requestMessage := dynamicpb.NewMessage(methodDescriptor.Input())
+	if g.ActionOpts.Body != "" {
+		if err := (protojson.UnmarshalOptions{Resolver: dynamicpb.NewTypes(files)}).Unmarshal([]byte(g.ActionOpts.Body), requestMessage); err != nil {
+			return common.RuntimeResult{Success: false}, errors.New("invalid request body: " + err.Error())
+		}
+	}
+	fullMethod := "/" + string(methodDescriptor.Parent().FullName()) + "/" + string(methodDescriptor.Name())
+
+	// unary call
+	if !methodDescriptor.IsStreamingServer() {
+		var header, trailer metadata.MD
+		responseMessage := dynamicpb.NewMessage(methodDescriptor.Output())
+		if err := conn.Invoke(ctx, fullMethod, requestMessage, responseMessage, grpcgo.Header(&header), grpcgo.Trailer(&trailer)); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		row, err := exportMessage(responseMessage, files)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		return common.RuntimeResult{
+			Success: true,
+			Rows:    []map[string]interface{}{row},
+			Extra:   map[string]interface{}{"headers": exportMetadata(header), "trailers": exportMetadata(trailer)},
+		}, nil
+	}
+
+	// server streaming call, collect up to MaxResponses messages
+	maxResponses := g.ActionOpts.MaxResponses
+	if maxResponses <= 0 {
+		maxResponses = DEFAULT_MAX_RESPONSES
+	}
+	if maxResponses > MAX_RESPONSES_LIMIT {
+		maxResponses = MAX_RESPONSES_LIMIT
+	}
+	stream, err := conn.NewStream(ctx, &grpcgo.StreamDesc{ServerStreams: true}, fullMethod)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if err := stream.SendMsg(requestMessage); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	rows := make([]map[string]interface{}, 0)
+	completed := false
+	for len(rows) < maxResponses {
+		responseMessage := dynamicpb.NewMessage(methodDescriptor.Output())
+		if err := stream.RecvMsg(responseMessage); err != nil {
+			if err == io.EOF {
+				completed = true
+				break
+			}
+			return common.RuntimeResult{Success: false}, err
+		}
+		row, err := exportMessage(responseMessage, files)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		rows = append(rows, row)
+	}
+	header, _ := stream.Header()
+	extra := map[string]interface{}{"headers": exportMetadata(header), "completed": completed}
+	if completed {
+		extra["trailers"] = exportMetadata(stream.Trailer())
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   extra,
+	}, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package grpc
+
+const (
+	DESCRIPTOR_SOURCE_REFLECTION     = "reflection"
+	DESCRIPTOR_SOURCE_PROTO          = "proto"
+	DESCRIPTOR_SOURCE_DESCRIPTOR_SET = "descriptorSet"
+)
+
+const (
+	DEFAULT_MAX_RESPONSES   = 100
+	MAX_RESPONSES_LIMIT     = 1000
+	DEFAULT_CONNECT_TIMEOUT = 10 // second
+)
+
+// Resource describe a gRPC server, the methods are discovered by server reflection, or from the uploaded ".proto" files
+// or the base64 encoded FileDescriptorSet (like the output of "protoc --include_imports --descriptor_set_out").
+type Resource struct {
+	Target           string              `validate:"required"` // like "localhost:50051"
+	DescriptorSource string              `validate:"required,oneof=reflection proto descriptorSet"`
+	ProtoFiles       []ProtoFile         `validate:"required_if=DescriptorSource proto,dive"`
+	DescriptorSet    string              `validate:"required_if=DescriptorSource descriptorSet"`
+	Metadata         []map[string]string // the key and value pairs sent with every call
+	SSL              SSLOptions
+}
+
+type ProtoFile struct {
+	Name    string `validate:"required"` // the import path of the file, like "foo/bar.proto"
+	Content string `validate:"required"`
+}
+
+type SSLOptions struct {
+	SSL              bool
+	VerificationMode string `validate:"required_unless=SSL false,omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+// Action describe a unary or server streaming call, the Method is the full method name like "package.Service/Method",
+// and the Body is the JSON format request message. The server streaming call collects up to MaxResponses messages.
+type Action struct {
+	Method       string `validate:"required"`
+	Body         string
+	Metadata     []map[string]string
+	MaxResponses int
+	Timeout      int // ms
+}
@@ -0,0 +1,268 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imap
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	goimap "github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	_ "github.com/emersion/go-message/charset"
+	"github.com/emersion/go-message/mail"
+	"github.com/emersion/go-sasl"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+)
+
+func (i *Connector) getClientWithOptions(resourceOptions map[string]interface{}) (*client.Client, error) {
+	if err := mapstructure.Decode(resourceOptions, &i.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	// connect with implicit TLS, StartTLS or plain text
+	tlsConfig, err := common.BuildTLSConfig(i.ResourceOpts.SSL.VerificationMode, i.ResourceOpts.SSL.CACert, i.ResourceOpts.SSL.ClientCert, i.ResourceOpts.SSL.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ServerName = i.ResourceOpts.Host
+	address := net.JoinHostPort(i.ResourceOpts.Host, strconv.Itoa(i.ResourceOpts.Port))
+	dialer := &net.Dialer{Timeout: DEFAULT_CONNECT_TIMEOUT * time.Second}
+	var imapClient *client.Client
+	if i.ResourceOpts.Security == SECURITY_STARTTLS || i.ResourceOpts.Security == SECURITY_NONE {
+		imapClient, err = client.DialWithDialer(dialer, address)
+	} else {
+		imapClient, err = client.DialWithDialerTLS(dialer, address, tlsConfig)
+	}
+	if err != nil {
+		return nil, err
+	}
+	imapClient.Timeout = common.DEFAULT_QUERY_AND_EXEC_TIMEOUT
+	if i.ResourceOpts.Security == SECURITY_STARTTLS {
+		if err := imapClient.StartTLS(tlsConfig); err != nil {
+			imapClient.Logout()
+			return nil, err
+		}
+	}
+
+	// login
+	if i.ResourceOpts.Authentication == AUTHENTICATION_OAUTH2 {
+		err = imapClient.Authenticate(&xoauth2Client{username: i.ResourceOpts.Username, accessToken: i.ResourceOpts.AccessToken})
+	} else {
+		err = imapClient.Login(i.ResourceOpts.Username, i.ResourceOpts.Password)
+	}
+	if err != nil {
+		imapClient.Logout()
+		return nil, err
+	}
+	return imapClient, nil
+}
+
+// xoauth2Client implements the SASL XOAUTH2 mechanism, which is not in the go-sasl package.
+type xoauth2Client struct {
+	username    string
+	accessToken string
+}
+
+var _ sasl.Client = (*xoauth2Client)(nil)
+
+func (x *xoauth2Client) Start() (string, []byte, error) {
+	return "XOAUTH2", []byte("user=" + x.username + "\x01auth=Bearer " + x.accessToken + "\x01\x01"), nil
+}
+
+// Next responds the error challenge with empty response, then the server fails the authentication with the error.
+func (x *xoauth2Client) Next(challenge []byte) ([]byte, error) {
+	return []byte{}, nil
+}
+
+func listMailboxes(imapClient *client.Client) ([]map[string]interface{}, error) {
+	mailboxChan := make(chan *goimap.MailboxInfo, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- imapClient.List("", "*", mailboxChan)
+	}()
+	mailboxes := make([]map[string]interface{}, 0)
+	for mailbox := range mailboxChan {
+		attributes := mailbox.Attributes
+		if attributes == nil {
+			attributes = []string{}
+		}
+		mailboxes = append(mailboxes, map[string]interface{}{
+			"name":       mailbox.Name,
+			"delimiter":  mailbox.Delimiter,
+			"attributes": attributes,
+		})
+	}
+	if err := <-done; err != nil {
+		return nil, err
+	}
+	return mailboxes, nil
+}
+
+func buildSeqSet(uids []uint32) *goimap.SeqSet {
+	seqSet := new(goimap.SeqSet)
+	seqSet.AddNum(uids...)
+	return seqSet
+}
+
+// parseDate parses the date like "2006-01-02" or RFC 3339 time.
+func parseDate(value string) (time.Time, error) {
+	if date, err := time.Parse("2006-01-02", value); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, errors.New("invalid date: " + value)
+	}
+	return date, nil
+}
+
+// fetchMessages fetches the messages by UID and keeps the order of the given UIDs.
+func fetchMessages(imapClient *client.Client, uids []uint32, items []goimap.FetchItem, export func(message *goimap.Message) (map[string]interface{}, error)) ([]map[string]interface{}, error) {
+	messageChan := make(chan *goimap.Message, 16)
+	done := make(chan error, 1)
+	go func() {
+		done <- imapClient.UidFetch(buildSeqSet(uids), items, messageChan)
+	}()
+	exported := make(map[uint32]map[string]interface{}, len(uids))
+	var errInExport error
+	for message := range messageChan {
+		if errInExport != nil {
+			continue
+		}
+		exported[message.Uid], errInExport = export(message)
+	}
+	if err := <-done; err != nil {
+		return nil, err
+	}
+	if errInExport != nil {
+		return nil, errInExport
+	}
+
+	rows := make([]map[string]interface{}, 0, len(exported))
+	for _, uid := range uids {
+		if row, hit := exported[uid]; hit {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+func exportMessageHeader(message *goimap.Message) map[string]interface{} {
+	flags := message.Flags
+	if flags == nil {
+		flags = []string{}
+	}
+	row := map[string]interface{}{
+		"uid":          message.Uid,
+		"flags":        flags,
+		"seen":         hasFlag(flags, goimap.SeenFlag),
+		"flagged":      hasFlag(flags, goimap.FlaggedFlag),
+		"size":         message.Size,
+		"internalDate": message.InternalDate,
+	}
+	if envelope := message.Envelope; envelope != nil {
+		row["subject"] = envelope.Subject
+		row["date"] = envelope.Date
+		row["messageId"] = envelope.MessageId
+		row["inReplyTo"] = envelope.InReplyTo
+		row["from"] = exportAddresses(envelope.From)
+		row["replyTo"] = exportAddresses(envelope.ReplyTo)
+		row["to"] = exportAddresses(envelope.To)
+		row["cc"] = exportAddresses(envelope.Cc)
+	}
+	return row
+}
+
+func hasFlag(flags []string, flag string) bool {
+	for _, f := range flags {
+		if strings.EqualFold(f, flag) {
+			return true
+		}
+	}
+	return false
+}
+
+func exportAddresses(addresses []*goimap.Address) []map[string]string {
+	exported := make([]map[string]string, 0, len(addresses))
+	for _, address := range addresses {
+		exported = append(exported, map[string]string{"name": address.PersonalName, "address": address.Address()})
+	}
+	return exported
+}
+
+// exportMessageBody parses the MIME message into the text and HTML bodies and the base64 encoded attachments.
+func exportMessageBody(literal goimap.Literal, includeAttachments bool) (map[string]interface{}, error) {
+	exported := map[string]interface{}{"text": "", "html": "", "attachments": []map[string]interface{}{}}
+	if literal == nil {
+		return exported, nil
+	}
+	reader, err := mail.CreateReader(literal)
+	if err != nil {
+		return nil, err
+	}
+	attachments := make([]map[string]interface{}, 0)
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch header := part.Header.(type) {
+		case *mail.InlineHeader:
+			contentType, _, _ := header.ContentType()
+			content, err := io.ReadAll(io.LimitReader(part.Body, MAX_ATTACHMENT_SIZE))
+			if err != nil {
+				return nil, err
+			}
+			switch contentType {
+			case "text/plain":
+				exported["text"] = exported["text"].(string) + string(content)
+			case "text/html":
+				exported["html"] = exported["html"].(string) + string(content)
+			}
+		case *mail.AttachmentHeader:
+			filename, _ := header.Filename()
+			contentType, _, _ := header.ContentType()
+			attachment := map[string]interface{}{"filename": filename, "contentType": contentType}
+			if includeAttachments {
+				content, err := io.ReadAll(io.LimitReader(part.Body, MAX_ATTACHMENT_SIZE+1))
+				if err != nil {
+					return nil, err
+				}
+				if len(content) > MAX_ATTACHMENT_SIZE {
+					return nil, errors.New("attachment " + filename + " exceeds the size limit of " + strconv.Itoa(MAX_ATTACHMENT_SIZE) + " bytes")
+				}
+				attachment["size"] = len(content)
+				attachment["data"] = base64.StdEncoding.EncodeToString(content)
+			}
+			attachments = append(attachments, attachment)
+		}
+	}
+	exported["attachments"] = attachments
+	return exported, nil
+}
+
+func sortUIDsDesc(uids []uint32) {
+	sort.Slice(uids, func(i, j int) bool { return uids[i] > uids[j] })
+}
@@ -0,0 +1,280 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imap
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	goimap "github.com/emersion/go-imap"
+	"github.com/emersion/go-imap/client"
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type CommandExecutor struct {
+	client  *client.Client
+	command Action
+}
+
+func mailboxOrDefault(mailbox string) string {
+	if mailbox == "" {
+		return DEFAULT_MAILBOX
+	}
+	return mailbox
+}
+
+func (c *CommandExecutor) search() (common.RuntimeResult, error) {
+	var searchCommandArgs SearchCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &searchCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate imap search action options
+	validate := validator.New()
+	if err := validate.Struct(searchCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if searchCommandArgs.Limit <= 0 {
+		searchCommandArgs.Limit = DEFAULT_SEARCH_LIMIT
+	}
+	if searchCommandArgs.Limit > MAX_SEARCH_LIMIT {
+		searchCommandArgs.Limit = MAX_SEARCH_LIMIT
+	}
+	if searchCommandArgs.Offset < 0 {
+		searchCommandArgs.Offset = 0
+	}
+
+	// build search criteria
+	criteria := goimap.NewSearchCriteria()
+	for header, value := range map[string]string{"From": searchCommandArgs.From, "To": searchCommandArgs.To, "Cc": searchCommandArgs.Cc, "Subject": searchCommandArgs.Subject} {
+		if value != "" {
+			criteria.Header.Add(header, value)
+		}
+	}
+	if searchCommandArgs.Body != "" {
+		criteria.Body = []string{searchCommandArgs.Body}
+	}
+	if searchCommandArgs.Text != "" {
+		criteria.Text = []string{searchCommandArgs.Text}
+	}
+	if searchCommandArgs.Since != "" {
+		since, err := parseDate(searchCommandArgs.Since)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		criteria.Since = since
+	}
+	if searchCommandArgs.Before != "" {
+		before, err := parseDate(searchCommandArgs.Before)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		criteria.Before = before
+	}
+	for flag, expected := range map[string]*bool{goimap.SeenFlag: searchCommandArgs.Seen, goimap.FlaggedFlag: searchCommandArgs.Flagged} {
+		if expected == nil {
+			continue
+		}
+		if *expected {
+			criteria.WithFlags = append(criteria.WithFlags, flag)
+		} else {
+			criteria.WithoutFlags = append(criteria.WithoutFlags, flag)
+		}
+	}
+	criteria.WithFlags = append(criteria.WithFlags, searchCommandArgs.Flags...)
+
+	// search and fetch the headers of a page, newest first
+	if _, err := c.client.Select(mailboxOrDefault(searchCommandArgs.Mailbox), true); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	uids, err := c.client.UidSearch(criteria)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	sortUIDsDesc(uids)
+	total := len(uids)
+	if searchCommandArgs.Offset >= len(uids) {
+		uids = []uint32{}
+	} else {
+		uids = uids[searchCommandArgs.Offset:]
+	}
+	if len(uids) > searchCommandArgs.Limit {
+		uids = uids[:searchCommandArgs.Limit]
+	}
+	rows := make([]map[string]interface{}, 0)
+	if len(uids) > 0 {
+		items := []goimap.FetchItem{goimap.FetchUid, goimap.FetchEnvelope, goimap.FetchFlags, goimap.FetchRFC822Size, goimap.FetchInternalDate}
+		rows, err = fetchMessages(c.client, uids, items, func(message *goimap.Message) (map[string]interface{}, error) {
+			return exportMessageHeader(message), nil
+		})
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{"total": total, "offset": searchCommandArgs.Offset, "limit": searchCommandArgs.Limit},
+	}, nil
+}
+
+func (c *CommandExecutor) fetch() (common.RuntimeResult, error) {
+	var fetchCommandArgs FetchCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &fetchCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate imap fetch action options
+	validate := validator.New()
+	if err := validate.Struct(fetchCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if len(fetchCommandArgs.UIDs) > MAX_FETCH_MESSAGES {
+		return common.RuntimeResult{Success: false}, fmt.Errorf("at most %d messages can be fetched at once", MAX_FETCH_MESSAGES)
+	}
+
+	// fetch the headers, and the whole message with peek for body and attachments
+	if _, err := c.client.Select(mailboxOrDefault(fetchCommandArgs.Mailbox), true); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	items := []goimap.FetchItem{goimap.FetchUid, goimap.FetchEnvelope, goimap.FetchFlags, goimap.FetchRFC822Size, goimap.FetchInternalDate}
+	section := &goimap.BodySectionName{Peek: true}
+	includeBody := fetchCommandArgs.IncludeBody || fetchCommandArgs.IncludeAttachments
+	if includeBody {
+		items = append(items, section.FetchItem())
+	}
+	rows, err := fetchMessages(c.client, fetchCommandArgs.UIDs, items, func(message *goimap.Message) (map[string]interface{}, error) {
+		row := exportMessageHeader(message)
+		if !includeBody {
+			return row, nil
+		}
+		body, err := exportMessageBody(message.GetBody(section), fetchCommandArgs.IncludeAttachments)
+		if err != nil {
+			return nil, err
+		}
+		for key, value := range body {
+			row[key] = value
+		}
+		return row, nil
+	})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+	}, nil
+}
+
+func (c *CommandExecutor) markAsRead() (common.RuntimeResult, error) {
+	var markAsReadCommandArgs MarkAsReadCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &markAsReadCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate imap markAsRead action options
+	validate := validator.New()
+	if err := validate.Struct(markAsReadCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// add or remove the \Seen flag
+	if _, err := c.client.Select(mailboxOrDefault(markAsReadCommandArgs.Mailbox), false); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	var operation goimap.FlagsOp = goimap.AddFlags
+	if markAsReadCommandArgs.Unread {
+		operation = goimap.RemoveFlags
+	}
+	if err := c.client.UidStore(buildSeqSet(markAsReadCommandArgs.UIDs), goimap.FormatFlagsOp(operation, true), []interface{}{goimap.SeenFlag}, nil); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"uids": markAsReadCommandArgs.UIDs, "seen": !markAsReadCommandArgs.Unread}},
+		Extra:   map[string]interface{}{"message": "mark messages successfully"},
+	}, nil
+}
+
+func (c *CommandExecutor) move() (common.RuntimeResult, error) {
+	var moveCommandArgs MoveCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &moveCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate imap move action options
+	validate := validator.New()
+	if err := validate.Struct(moveCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// move messages, fallback to copy, store and expunge when the server does not support MOVE
+	if _, err := c.client.Select(mailboxOrDefault(moveCommandArgs.Mailbox), false); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if err := c.client.UidMove(buildSeqSet(moveCommandArgs.UIDs), moveCommandArgs.Destination); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"uids": moveCommandArgs.UIDs, "destination": moveCommandArgs.Destination}},
+		Extra:   map[string]interface{}{"message": "move messages successfully"},
+	}, nil
+}
+
+func (c *CommandExecutor) flag() (common.RuntimeResult, error) {
+	var flagCommandArgs FlagCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &flagCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate imap flag action options
+	validate := validator.New()
+	if err := validate.Struct(flagCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// store flags
+	if _, err := c.client.Select(mailboxOrDefault(flagCommandArgs.Mailbox), false); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	var operation goimap.FlagsOp
+	switch flagCommandArgs.Operation {
+	case FLAG_OPERATION_REMOVE:
+		operation = goimap.RemoveFlags
+	case FLAG_OPERATION_SET:
+		operation = goimap.SetFlags
+	default:
+		operation = goimap.AddFlags
+	}
+	flags := make([]interface{}, 0, len(flagCommandArgs.Flags))
+	for _, flag := range flagCommandArgs.Flags {
+		if flag == "" {
+			return common.RuntimeResult{Success: false}, errors.New("empty flag is not allowed")
+		}
+		flags = append(flags, flag)
+	}
+	if err := c.client.UidStore(buildSeqSet(flagCommandArgs.UIDs), goimap.FormatFlagsOp(operation, true), flags, nil); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"uids": flagCommandArgs.UIDs, "flags": flagCommandArgs.Flags}},
+		Extra:   map[string]interface{}{"message": "flag messages successfully"},
+	}, nil
+}
@@ -0,0 +1,125 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imap
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (i *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &i.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate imap options
+	validate := validator.New()
+	if err := validate.Struct(i.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (i *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &i.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate imap options
+	validate := validator.New()
+	if err := validate.Struct(i.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (i *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get imap client, the client is logged in before returned
+	imapClient, err := i.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer imapClient.Logout()
+
+	// test connection
+	if err := imapClient.Noop(); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (i *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get imap client
+	imapClient, err := i.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer imapClient.Logout()
+
+	// list folders
+	mailboxes, err := listMailboxes(imapClient)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"mailboxes": mailboxes},
+	}, nil
+}
+
+func (i *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get imap client
+	imapClient, err := i.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get imap connection: " + err.Error())
+	}
+	defer imapClient.Logout()
+
+	// format imap action
+	if err := mapstructure.Decode(actionOptions, &i.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{client: imapClient, command: i.ActionOpts}
+	switch i.ActionOpts.Commands {
+	case SEARCH_COMMAND:
+		result, err = commandExecutor.search()
+	case FETCH_COMMAND:
+		result, err = commandExecutor.fetch()
+	case MARK_AS_READ_COMMAND:
+		result, err = commandExecutor.markAsRead()
+	case MOVE_COMMAND:
+		result, err = commandExecutor.move()
+	case FLAG_COMMAND:
+		result, err = commandExecutor.flag()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported imap command: "+i.ActionOpts.Commands)
+	}
+
+	return result, err
+}
@@ -0,0 +1,122 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package imap
+
+const (
+	SEARCH_COMMAND       = "search"
+	FETCH_COMMAND        = "fetch"
+	MARK_AS_READ_COMMAND = "markAsRead"
+	MOVE_COMMAND         = "move"
+	FLAG_COMMAND         = "flag"
+)
+
+const (
+	SECURITY_SSL      = "ssl"
+	SECURITY_STARTTLS = "starttls"
+	SECURITY_NONE     = "none"
+)
+
+const (
+	AUTHENTICATION_PASSWORD = "password"
+	AUTHENTICATION_OAUTH2   = "oauth2"
+)
+
+const (
+	FLAG_OPERATION_ADD    = "add"
+	FLAG_OPERATION_REMOVE = "remove"
+	FLAG_OPERATION_SET    = "set"
+)
+
+const (
+	DEFAULT_MAILBOX         = "INBOX"
+	DEFAULT_SEARCH_LIMIT    = 50
+	MAX_SEARCH_LIMIT        = 500
+	MAX_FETCH_MESSAGES      = 50
+	MAX_ATTACHMENT_SIZE     = 16 * 1024 * 1024
+	DEFAULT_CONNECT_TIMEOUT = 10 // second
+)
+
+// Resource describe an IMAP server, the Security is "ssl" (implicit TLS, port 993) by default.
+// The oauth2 authentication uses the AccessToken with SASL XOAUTH2, like Gmail and Outlook.
+type Resource struct {
+	Host           string `validate:"required"`
+	Port           int    `validate:"gt=0"`
+	Security       string `validate:"omitempty,oneof=ssl starttls none"`
+	Authentication string `validate:"omitempty,oneof=password oauth2"`
+	Username       string `validate:"required"`
+	Password       string
+	AccessToken    string `validate:"required_if=Authentication oauth2"`
+	SSL            SSLOptions
+}
+
+type SSLOptions struct {
+	VerificationMode string `validate:"omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+type Action struct {
+	Commands    string                 `validate:"required,oneof=search fetch markAsRead move flag"`
+	CommandArgs map[string]interface{} `validate:"required"`
+}
+
+// SearchCommandArgs describe the search criteria, all the given criteria should match. The Since and Before accept
+// date like "2006-01-02" or RFC 3339 time. The matched messages are returned with headers, newest first.
+type SearchCommandArgs struct {
+	Mailbox string   `json:"mailbox"`
+	From    string   `json:"from"`
+	To      string   `json:"to"`
+	Cc      string   `json:"cc"`
+	Subject string   `json:"subject"`
+	Body    string   `json:"body"`
+	Text    string   `json:"text"`
+	Since   string   `json:"since"`
+	Before  string   `json:"before"`
+	Seen    *bool    `json:"seen"`
+	Flagged *bool    `json:"flagged"`
+	Flags   []string `json:"flags"`
+	Limit   int      `json:"limit"`
+	Offset  int      `json:"offset"`
+}
+
+// FetchCommandArgs describe the messages to fetch by UID, the messages are not marked as read by fetching.
+type FetchCommandArgs struct {
+	Mailbox            string   `json:"mailbox"`
+	UIDs               []uint32 `json:"uids" validate:"required,gt=0"` // at most MAX_FETCH_MESSAGES
+	IncludeBody        bool     `json:"includeBody"`
+	IncludeAttachments bool     `json:"includeAttachments"`
+}
+
+// MarkAsReadCommandArgs describe the messages to mark as read, or as unread when the Unread is true.
+type MarkAsReadCommandArgs struct {
+	Mailbox string   `json:"mailbox"`
+	UIDs    []uint32 `json:"uids" validate:"required,gt=0"`
+	Unread  bool     `json:"unread"`
+}
+
+type MoveCommandArgs struct {
+	Mailbox     string   `json:"mailbox"`
+	UIDs        []uint32 `json:"uids" validate:"required,gt=0"`
+	Destination string   `json:"destination" validate:"required"`
+}
+
+// FlagCommandArgs describe the flag changes, the flags are system flags like "\\Flagged" or keywords like "$Important".
+type FlagCommandArgs struct {
+	Mailbox   string   `json:"mailbox"`
+	UIDs      []uint32 `json:"uids" validate:"required,gt=0"`
+	Flags     []string `json:"flags" validate:"required,gt=0"`
+	Operation string   `json:"operation" validate:"omitempty,oneof=add remove set"`
+}
@@ -0,0 +1,341 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package influxdb
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Column struct {
+	Name         string `json:"name"`
+	DataType     string `json:"dataType"`
+	Group        bool   `json:"group"`
+	DefaultValue string `json:"defaultValue"`
+}
+
+type influxQLResponse struct {
+	Results []struct {
+		StatementID int    `json:"statement_id"`
+		Error       string `json:"error"`
+		Series      []struct {
+			Name    string            `json:"name"`
+			Tags    map[string]string `json:"tags"`
+			Columns []string          `json:"columns"`
+			Values  [][]interface{}   `json:"values"`
+		} `json:"series"`
+	} `json:"results"`
+	Error string `json:"error"`
+}
+
+func (i *Connector) getClientWithOptions(resourceOptions map[string]interface{}) (*resty.Client, error) {
+	if err := mapstructure.Decode(resourceOptions, &i.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	client := resty.New().
+		SetBaseURL(strings.TrimRight(i.ResourceOpts.URL, "/")).
+		SetHeader("Authorization", "Token "+i.ResourceOpts.Token).
+		SetTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	return client, nil
+}
+
+// checkResponse converts the InfluxDB error response like `{"code":"invalid","message":"..."}` into error.
+func checkResponse(resp *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp.IsSuccess() {
+		return nil
+	}
+	errorBody := struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}{}
+	if errInUnmarshal := json.Unmarshal(resp.Body(), &errorBody); errInUnmarshal == nil {
+		if errorBody.Message != "" {
+			return errors.New("influxdb error: " + errorBody.Message)
+		}
+		if errorBody.Error != "" {
+			return errors.New("influxdb error: " + errorBody.Error)
+		}
+	}
+	return errors.New("influxdb error: " + resp.Status() + " " + resp.String())
+}
+
+func runFluxQuery(client *resty.Client, org string, query string) ([]map[string]interface{}, []Column, error) {
+	resp, err := client.R().
+		SetQueryParam("org", org).
+		SetHeader("Accept", "application/csv").
+		SetBody(map[string]interface{}{
+			"query": query,
+			"type":  "flux",
+			"dialect": map[string]interface{}{
+				"header":         true,
+				"delimiter":      ",",
+				"annotations":    strings.Split(ANNOTATION_DIALECT, ","),
+				"commentPrefix":  "#",
+				"dateTimeFormat": "RFC3339",
+			},
+		}).
+		Post(QUERY_API_PATH)
+	if err := checkResponse(resp, err); err != nil {
+		return nil, nil, err
+	}
+	return parseAnnotatedCSV(strings.NewReader(resp.String()))
+}
+
+// parseAnnotatedCSV parses the annotated CSV of flux query into rows with typed values, see
+// https://docs.influxdata.com/influxdb/v2/reference/syntax/annotated-csv/ for the format.
+// Each table of the result starts with the annotation rows and a header row, the columns of the first table are returned.
+// An error that happens while the response is streamed is sent as a table with the "error" and "reference" columns,
+// it is returned as error instead of rows.
+func parseAnnotatedCSV(reader io.Reader) ([]map[string]interface{}, []Column, error) {
+	csvReader := csv.NewReader(reader)
+	csvReader.FieldsPerRecord = -1
+	csvReader.ReuseRecord = false
+
+	rows := make([]map[string]interface{}, 0)
+	var firstTableColumns []Column
+	var columns []Column
+	var annotations map[string][]string
+	expectHeader := false
+	for {
+		record, err := csvReader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+		if len(record) == 0 {
+			continue
+		}
+
+		// annotation rows start a new table
+		if strings.HasPrefix(record[0], "#") {
+			if !expectHeader {
+				annotations = make(map[string][]string)
+				expectHeader = true
+			}
+			annotations[record[0]] = record
+			continue
+		}
+
+		// header row
+		if expectHeader || columns == nil {
+			columns = make([]Column, len(record))
+			for index, name := range record {
+				columns[index] = Column{
+					Name:         name,
+					DataType:     annotationAt(annotations, ANNOTATION_DATATYPE, index),
+					Group:        annotationAt(annotations, ANNOTATION_GROUP, index) == "true",
+					DefaultValue: annotationAt(annotations, ANNOTATION_DEFAULT, index),
+				}
+			}
+			if firstTableColumns == nil {
+				firstTableColumns = exportedColumns(columns)
+			}
+			expectHeader = false
+			continue
+		}
+
+		// error table, see https://docs.influxdata.com/influxdb/v2/reference/syntax/annotated-csv/#errors
+		if isErrorTable(columns) {
+			return nil, nil, errors.New("influxdb error: " + fluxErrorMessage(columns, record))
+		}
+
+		// data row, the first column is reserved for annotations and is always empty
+		row := make(map[string]interface{}, len(columns))
+		for index, column := range columns {
+			if column.Name == "" || index >= len(record) {
+				continue
+			}
+			value := record[index]
+			if value == "" {
+				value = column.DefaultValue
+			}
+			row[column.Name] = convertAnnotatedValue(column.DataType, value)
+		}
+		rows = append(rows, row)
+	}
+	if firstTableColumns == nil {
+		firstTableColumns = []Column{}
+	}
+	return rows, firstTableColumns, nil
+}
+
+// isErrorTable reports whether the header of the table is the one of flux error table, like `,error,reference`.
+func isErrorTable(columns []Column) bool {
+	names := make([]string, 0, len(columns))
+	for _, column := range columns {
+		if column.Name != "" {
+			names = append(names, column.Name)
+		}
+	}
+	return len(names) == 2 && names[0] == FLUX_ERROR_COLUMN_ERROR && names[1] == FLUX_ERROR_COLUMN_REFERENCE
+}
+
+func fluxErrorMessage(columns []Column, record []string) string {
+	message := ""
+	reference := ""
+	for index, column := range columns {
+		if index >= len(record) {
+			break
+		}
+		switch column.Name {
+		case FLUX_ERROR_COLUMN_ERROR:
+			message = record[index]
+		case FLUX_ERROR_COLUMN_REFERENCE:
+			reference = record[index]
+		}
+	}
+	if reference != "" {
+		return message + " (reference: " + reference + ")"
+	}
+	return message
+}
+
+func annotationAt(annotations map[string][]string, annotation string, index int) string {
+	values, hit := annotations[annotation]
+	if !hit || index >= len(values) {
+		return ""
+	}
+	return values[index]
+}
+
+func exportedColumns(columns []Column) []Column {
+	exported := make([]Column, 0, len(columns))
+	for _, column := range columns {
+		if column.Name != "" {
+			exported = append(exported, column)
+		}
+	}
+	return exported
+}
+
+// convertAnnotatedValue converts the value by the "#datatype" annotation, the dateTime and duration values are kept as string.
+func convertAnnotatedValue(dataType string, value string) interface{} {
+	if value == "" {
+		return nil
+	}
+	var converted interface{}
+	var err error
+	switch dataType {
+	case "long":
+		converted, err = strconv.ParseInt(value, 10, 64)
+	case "unsignedLong":
+		converted, err = strconv.ParseUint(value, 10, 64)
+	case "double":
+		converted, err = strconv.ParseFloat(value, 64)
+	case "boolean":
+		converted, err = strconv.ParseBool(value)
+	default:
+		return value
+	}
+	if err != nil {
+		return value
+	}
+	return converted
+}
+
+func runInfluxQLQuery(client *resty.Client, database string, retentionPolicy string, query string) ([]map[string]interface{}, error) {
+	params := map[string]string{"db": database, "q": query}
+	if retentionPolicy != "" {
+		params["rp"] = retentionPolicy
+	}
+	resp, err := client.R().
+		SetHeader("Accept", "application/json").
+		SetQueryParams(params).
+		Get(INFLUXQL_API_PATH)
+	if err := checkResponse(resp, err); err != nil {
+		return nil, err
+	}
+	var influxQLResp influxQLResponse
+	if err := json.Unmarshal(resp.Body(), &influxQLResp); err != nil {
+		return nil, err
+	}
+	if influxQLResp.Error != "" {
+		return nil, errors.New("influxdb error: " + influxQLResp.Error)
+	}
+
+	// flatten the series into rows, with the measurement name and tags as columns
+	rows := make([]map[string]interface{}, 0)
+	for _, result := range influxQLResp.Results {
+		if result.Error != "" {
+			return nil, errors.New("influxdb error: " + result.Error)
+		}
+		for _, series := range result.Series {
+			for _, values := range series.Values {
+				row := make(map[string]interface{}, len(series.Tags)+len(series.Columns)+1)
+				row["name"] = series.Name
+				for tagKey, tagValue := range series.Tags {
+					row[tagKey] = tagValue
+				}
+				for index, columnName := range series.Columns {
+					if index < len(values) {
+						row[columnName] = values[index]
+					}
+				}
+				rows = append(rows, row)
+			}
+		}
+	}
+	return rows, nil
+}
+
+func listBuckets(client *resty.Client, org string, limit int) ([]map[string]interface{}, error) {
+	resp, err := client.R().
+		SetQueryParams(map[string]string{"org": org, "limit": strconv.Itoa(limit)}).
+		Get(BUCKETS_API_PATH)
+	if err := checkResponse(resp, err); err != nil {
+		return nil, err
+	}
+	bucketsResp := struct {
+		Buckets []map[string]interface{} `json:"buckets"`
+	}{}
+	if err := json.Unmarshal(resp.Body(), &bucketsResp); err != nil {
+		return nil, err
+	}
+	return bucketsResp.Buckets, nil
+}
+
+func listMeasurements(client *resty.Client, org string, bucket string) ([]string, error) {
+	query := "import \"influxdata/influxdb/schema\"\nschema.measurements(bucket: " + quoteFluxString(bucket) + ")"
+	rows, _, err := runFluxQuery(client, org, query)
+	if err != nil {
+		return nil, err
+	}
+	measurements := make([]string, 0, len(rows))
+	for _, row := range rows {
+		if measurement, ok := row["_value"].(string); ok {
+			measurements = append(measurements, measurement)
+		}
+	}
+	return measurements, nil
+}
+
+func quoteFluxString(str string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"", "${", "\\${").Replace(str) + "\""
+}
@@ -0,0 +1,121 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package influxdb
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/go-resty/resty/v2"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+)
+
+type CommandExecutor struct {
+	client  *resty.Client
+	org     string
+	command Action
+}
+
+func (c *CommandExecutor) flux() (common.RuntimeResult, error) {
+	var fluxCommandArgs FluxCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &fluxCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate influxdb flux action options
+	validate := validator.New()
+	if err := validate.Struct(fluxCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	rows, columns, err := runFluxQuery(c.client, c.org, fluxCommandArgs.Query)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{"columns": columns},
+	}, nil
+}
+
+func (c *CommandExecutor) influxQL() (common.RuntimeResult, error) {
+	var influxQLCommandArgs InfluxQLCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &influxQLCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate influxdb influxql action options
+	validate := validator.New()
+	if err := validate.Struct(influxQLCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	rows, err := runInfluxQLQuery(c.client, influxQLCommandArgs.Database, influxQLCommandArgs.RetentionPolicy, influxQLCommandArgs.Query)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{},
+	}, nil
+}
+
+func (c *CommandExecutor) write() (common.RuntimeResult, error) {
+	var writeCommandArgs WriteCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &writeCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate influxdb write action options
+	validate := validator.New()
+	if err := validate.Struct(writeCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	precision := writeCommandArgs.Precision
+	if precision == "" {
+		precision = DEFAULT_PRECISION
+	}
+
+	resp, err := c.client.R().
+		SetQueryParams(map[string]string{
+			"org":       c.org,
+			"bucket":    writeCommandArgs.Bucket,
+			"precision": precision,
+		}).
+		SetHeader("Content-Type", "text/plain; charset=utf-8").
+		SetBody(writeCommandArgs.Data).
+		Post(WRITE_API_PATH)
+	if err := checkResponse(resp, err); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// count the points, the empty lines and comments are ignored by line protocol
+	points := 0
+	for _, line := range strings.Split(writeCommandArgs.Data, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "#") {
+			points++
+		}
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": fmt.Sprintf("Wrote %d points to bucket %s.", points, writeCommandArgs.Bucket)},
+	}, nil
+}
@@ -0,0 +1,134 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package influxdb
+
+import (
+	"errors"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (i *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &i.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate influxdb options
+	validate := validator.New()
+	if err := validate.Struct(i.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (i *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &i.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate influxdb options
+	validate := validator.New()
+	if err := validate.Struct(i.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (i *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get influxdb client
+	client, err := i.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+
+	// test influxdb connection, the bucket listing checks both the token and the org
+	if _, err := listBuckets(client, i.ResourceOpts.Org, 1); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (i *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get influxdb client
+	client, err := i.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	// list buckets, and the measurements of the user buckets
+	buckets, err := listBuckets(client, i.ResourceOpts.Org, MAX_BUCKETS_IN_META)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	bucketsInfo := make(map[string]interface{}, len(buckets))
+	for _, bucket := range buckets {
+		bucketName, _ := bucket["name"].(string)
+		bucketType, _ := bucket["type"].(string)
+		measurements := make([]string, 0)
+		if bucketType == BUCKET_TYPE_USER {
+			measurements, err = listMeasurements(client, i.ResourceOpts.Org, bucketName)
+			if err != nil {
+				return common.MetaInfoResult{Success: false}, err
+			}
+		}
+		bucketsInfo[bucketName] = map[string]interface{}{
+			"id":           bucket["id"],
+			"type":         bucketType,
+			"measurements": measurements,
+		}
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"buckets": bucketsInfo},
+	}, nil
+}
+
+func (i *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get influxdb client
+	client, err := i.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get influxdb client: " + err.Error())
+	}
+
+	// format influxdb action
+	if err := mapstructure.Decode(actionOptions, &i.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{client: client, org: i.ResourceOpts.Org, command: i.ActionOpts}
+	switch i.ActionOpts.Commands {
+	case FLUX_COMMAND:
+		result, err = commandExecutor.flux()
+	case INFLUXQL_COMMAND:
+		result, err = commandExecutor.influxQL()
+	case WRITE_COMMAND:
+		result, err = commandExecutor.write()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported influxdb command: "+i.ActionOpts.Commands)
+	}
+
+	return result, err
+}
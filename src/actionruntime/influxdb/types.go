@@ -0,0 +1,70 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package influxdb
+
+const (
+	FLUX_COMMAND     = "flux"
+	INFLUXQL_COMMAND = "influxql"
+	WRITE_COMMAND    = "write"
+)
+
+const (
+	QUERY_API_PATH    = "/api/v2/query"
+	WRITE_API_PATH    = "/api/v2/write"
+	BUCKETS_API_PATH  = "/api/v2/buckets"
+	INFLUXQL_API_PATH = "/query" // the v1 compatibility API
+)
+
+const (
+	BUCKET_TYPE_USER    = "user"
+	MAX_BUCKETS_IN_META = 100
+	DEFAULT_PRECISION   = "ns"
+	ANNOTATION_DATATYPE = "#datatype"
+	ANNOTATION_GROUP    = "#group"
+	ANNOTATION_DEFAULT  = "#default"
+	ANNOTATION_DIALECT  = "datatype,group,default"
+
+	FLUX_ERROR_COLUMN_ERROR     = "error"
+	FLUX_ERROR_COLUMN_REFERENCE = "reference"
+)
+
+type Resource struct {
+	URL   string `validate:"required"` // like "http://localhost:8086"
+	Org   string `validate:"required"`
+	Token string `validate:"required"`
+}
+
+type Action struct {
+	Commands    string                 `validate:"required,oneof=flux influxql write"`
+	CommandArgs map[string]interface{} `validate:"required"`
+}
+
+type FluxCommandArgs struct {
+	Query string `json:"query" validate:"required"`
+}
+
+// InfluxQLCommandArgs describe a InfluxQL query through the v1 compatibility API, the Database and RetentionPolicy
+// are mapped to a bucket by the DBRP mapping of InfluxDB.
+type InfluxQLCommandArgs struct {
+	Query           string `json:"query" validate:"required"`
+	Database        string `json:"database" validate:"required"`
+	RetentionPolicy string `json:"retentionPolicy"`
+}
+
+type WriteCommandArgs struct {
+	Bucket    string `json:"bucket" validate:"required"`
+	Precision string `json:"precision" validate:"omitempty,oneof=ns us ms s"`
+	Data      string `json:"data" validate:"required"` // line protocol, one point per line
+}
@@ -0,0 +1,170 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+	"github.com/segmentio/kafka-go"
+	"github.com/segmentio/kafka-go/sasl"
+	"github.com/segmentio/kafka-go/sasl/plain"
+	"github.com/segmentio/kafka-go/sasl/scram"
+)
+
+// Connection holds everything needed to talk with the kafka cluster,
+// the transport is used by admin client and producer, the dialer is used by consumer.
+type Connection struct {
+	Brokers   []string
+	Transport *kafka.Transport
+	Dialer    *kafka.Dialer
+}
+
+func (c *Connection) Client() *kafka.Client {
+	return &kafka.Client{
+		Addr:      kafka.TCP(c.Brokers...),
+		Transport: c.Transport,
+	}
+}
+
+func (c *Connection) Close() {
+	c.Transport.CloseIdleConnections()
+}
+
+func (k *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*Connection, error) {
+	if err := mapstructure.Decode(resourceOptions, &k.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	// split brokers
+	brokers := make([]string, 0)
+	for _, broker := range strings.Split(k.ResourceOpts.Brokers, ",") {
+		broker = strings.TrimSpace(broker)
+		if broker != "" {
+			brokers = append(brokers, broker)
+		}
+	}
+	if len(brokers) == 0 {
+		return nil, errors.New("no kafka broker address")
+	}
+
+	// build sasl mechanism
+	var mechanism sasl.Mechanism
+	if k.ResourceOpts.SASL.SASL {
+		var err error
+		mechanism, err = buildSASLMechanism(k.ResourceOpts.SASL)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// build tls config
+	var tlsConfig *tls.Config
+	if k.ResourceOpts.SSL.SSL {
+		var err error
+		tlsConfig, err = common.BuildTLSConfig(k.ResourceOpts.SSL.VerificationMode, k.ResourceOpts.SSL.CACert, k.ResourceOpts.SSL.ClientCert, k.ResourceOpts.SSL.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &Connection{
+		Brokers: brokers,
+		Transport: &kafka.Transport{
+			DialTimeout: DEFAULT_DIAL_TIMEOUT * time.Second,
+			ClientID:    k.ResourceOpts.ClientID,
+			TLS:         tlsConfig,
+			SASL:        mechanism,
+		},
+		Dialer: &kafka.Dialer{
+			Timeout:       DEFAULT_DIAL_TIMEOUT * time.Second,
+			DualStack:     true,
+			ClientID:      k.ResourceOpts.ClientID,
+			TLS:           tlsConfig,
+			SASLMechanism: mechanism,
+		},
+	}, nil
+}
+
+func buildSASLMechanism(saslOptions SASLOptions) (sasl.Mechanism, error) {
+	switch saslOptions.Mechanism {
+	case SASL_MECHANISM_PLAIN:
+		return plain.Mechanism{Username: saslOptions.Username, Password: saslOptions.Password}, nil
+	case SASL_MECHANISM_SCRAM_SHA_256:
+		return scram.Mechanism(scram.SHA256, saslOptions.Username, saslOptions.Password)
+	case SASL_MECHANISM_SCRAM_SHA_512:
+		return scram.Mechanism(scram.SHA512, saslOptions.Username, saslOptions.Password)
+	default:
+		return nil, errors.New("unsupported sasl mechanism: " + saslOptions.Mechanism)
+	}
+}
+
+// listTopics return all non-internal topics with partitions and their first and last offsets, like:
+// {"topic": {"partitions": [{"partition": 0, "leader": "host:9092", "firstOffset": 0, "lastOffset": 42}]}}
+func listTopics(ctx context.Context, client *kafka.Client) (map[string]interface{}, error) {
+	metadata, err := client.Metadata(ctx, &kafka.MetadataRequest{})
+	if err != nil {
+		return nil, err
+	}
+
+	// collect partition offsets request
+	offsetRequests := make(map[string][]kafka.OffsetRequest)
+	for _, topic := range metadata.Topics {
+		if topic.Internal || topic.Error != nil {
+			continue
+		}
+		for _, partition := range topic.Partitions {
+			offsetRequests[topic.Name] = append(offsetRequests[topic.Name], kafka.FirstOffsetOf(partition.ID), kafka.LastOffsetOf(partition.ID))
+		}
+	}
+	offsets := make(map[string][]kafka.PartitionOffsets)
+	if len(offsetRequests) > 0 {
+		offsetsResponse, err := client.ListOffsets(ctx, &kafka.ListOffsetsRequest{Topics: offsetRequests})
+		if err != nil {
+			return nil, err
+		}
+		offsets = offsetsResponse.Topics
+	}
+
+	// assemble
+	topics := make(map[string]interface{})
+	for _, topic := range metadata.Topics {
+		if topic.Internal || topic.Error != nil {
+			continue
+		}
+		partitionOffsets := make(map[int]kafka.PartitionOffsets)
+		for _, partitionOffset := range offsets[topic.Name] {
+			partitionOffsets[partitionOffset.Partition] = partitionOffset
+		}
+		partitions := make([]map[string]interface{}, 0, len(topic.Partitions))
+		for _, partition := range topic.Partitions {
+			partitionOffset := partitionOffsets[partition.ID]
+			partitions = append(partitions, map[string]interface{}{
+				"partition":   partition.ID,
+				"leader":      partition.Leader.ID,
+				"replicas":    len(partition.Replicas),
+				"firstOffset": partitionOffset.FirstOffset,
+				"lastOffset":  partitionOffset.LastOffset,
+			})
+		}
+		topics[topic.Name] = map[string]interface{}{"partitions": partitions}
+	}
+	return topics, nil
+}
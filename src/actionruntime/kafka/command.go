@@ -0,0 +1,342 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/segmentio/kafka-go"
+)
+
+type CommandExecutor struct {
+	conn    *Connection
+	command Action
+}
+
+func (c *CommandExecutor) produce() (common.RuntimeResult, error) {
+	var produceCommandArgs ProduceCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &produceCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate kafka produce action options
+	validate := validator.New()
+	if err := validate.Struct(produceCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// build message
+	message := kafka.Message{
+		Value: []byte(produceCommandArgs.Value),
+	}
+	if produceCommandArgs.Key != "" {
+		message.Key = []byte(produceCommandArgs.Key)
+	}
+	for _, header := range produceCommandArgs.Headers {
+		if header["key"] != "" {
+			message.Headers = append(message.Headers, kafka.Header{Key: header["key"], Value: []byte(header["value"])})
+		}
+	}
+
+	// the writer ignores message partition, so pin it by balancer
+	var balancer kafka.Balancer = &kafka.Hash{}
+	if produceCommandArgs.Partition != nil {
+		partition := *produceCommandArgs.Partition
+		balancer = kafka.BalancerFunc(func(msg kafka.Message, partitions ...int) int {
+			return partition
+		})
+	}
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(c.conn.Brokers...),
+		Topic:        produceCommandArgs.Topic,
+		Balancer:     balancer,
+		Transport:    c.conn.Transport,
+		RequiredAcks: kafka.RequireAll,
+	}
+	defer writer.Close()
+
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	if err := writer.WriteMessages(ctx, message); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": fmt.Sprintf("Produced 1 message to topic %s.", produceCommandArgs.Topic)},
+	}, nil
+}
+
+func (c *CommandExecutor) consume() (common.RuntimeResult, error) {
+	var consumeCommandArgs ConsumeCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &consumeCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate kafka consume action options
+	validate := validator.New()
+	if err := validate.Struct(consumeCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if consumeCommandArgs.CommitOffsets && consumeCommandArgs.GroupID == "" {
+		return common.RuntimeResult{Success: false}, errors.New("commit offsets requires group id")
+	}
+	limit := consumeCommandArgs.Limit
+	if limit <= 0 {
+		limit = DEFAULT_CONSUME_LIMIT
+	}
+	if limit > MAX_CONSUME_LIMIT {
+		limit = MAX_CONSUME_LIMIT
+	}
+	timeout := time.Duration(consumeCommandArgs.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = DEFAULT_CONSUME_TIMEOUT * time.Millisecond
+	}
+	if timeout > common.DEFAULT_QUERY_AND_EXEC_TIMEOUT {
+		timeout = common.DEFAULT_QUERY_AND_EXEC_TIMEOUT
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), timeout)
+	defer cancel()
+
+	// init reader, the reader always attach to single partition, so the offset can be set
+	reader := kafka.NewReader(kafka.ReaderConfig{
+		Brokers:   c.conn.Brokers,
+		Topic:     consumeCommandArgs.Topic,
+		Partition: consumeCommandArgs.Partition,
+		Dialer:    c.conn.Dialer,
+		MinBytes:  1,
+		MaxBytes:  10e6,
+		MaxWait:   500 * time.Millisecond,
+	})
+	defer reader.Close()
+
+	// seek
+	startFrom := consumeCommandArgs.StartFrom
+	switch {
+	case startFrom == START_FROM_TIMESTAMP:
+		startAt, err := time.Parse(time.RFC3339, consumeCommandArgs.Timestamp)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, errors.New("invalid timestamp, it should be RFC3339 format: " + err.Error())
+		}
+		if err := reader.SetOffsetAt(ctx, startAt); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	case startFrom == START_FROM_OFFSET:
+		if err := reader.SetOffset(consumeCommandArgs.Offset); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	case startFrom == START_FROM_LATEST:
+		if err := reader.SetOffset(kafka.LastOffset); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	case startFrom == "" && consumeCommandArgs.GroupID != "":
+		committedOffset, err := c.fetchCommittedOffset(ctx, consumeCommandArgs.GroupID, consumeCommandArgs.Topic, consumeCommandArgs.Partition)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		if err := reader.SetOffset(committedOffset); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	default:
+		if err := reader.SetOffset(kafka.FirstOffset); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
+	// read messages until limit or timeout
+	rows := make([]map[string]interface{}, 0, limit)
+	nextOffset := int64(-1)
+	for len(rows) < limit {
+		message, err := reader.FetchMessage(ctx)
+		if errors.Is(err, context.DeadlineExceeded) {
+			break
+		}
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		rows = append(rows, exportMessage(message))
+		nextOffset = message.Offset + 1
+	}
+
+	// commit the offset of next message for the group
+	if consumeCommandArgs.CommitOffsets && nextOffset >= 0 {
+		commitCtx, commitCancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+		defer commitCancel()
+		if err := c.commitOffset(commitCtx, consumeCommandArgs.GroupID, consumeCommandArgs.Topic, consumeCommandArgs.Partition, nextOffset); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{"nextOffset": nextOffset},
+	}, nil
+}
+
+func (c *CommandExecutor) listTopics() (common.RuntimeResult, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	topics, err := listTopics(ctx, c.conn.Client())
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	rows := make([]map[string]interface{}, 0, len(topics))
+	for topicName, topic := range topics {
+		rows = append(rows, map[string]interface{}{"topic": topicName, "partitions": topic.(map[string]interface{})["partitions"]})
+	}
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{},
+	}, nil
+}
+
+func (c *CommandExecutor) createTopic() (common.RuntimeResult, error) {
+	var createTopicCommandArgs CreateTopicCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &createTopicCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate kafka create topic action options
+	validate := validator.New()
+	if err := validate.Struct(createTopicCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if createTopicCommandArgs.NumPartitions <= 0 {
+		createTopicCommandArgs.NumPartitions = 1
+	}
+	if createTopicCommandArgs.ReplicationFactor <= 0 {
+		createTopicCommandArgs.ReplicationFactor = 1
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	resp, err := c.conn.Client().CreateTopics(ctx, &kafka.CreateTopicsRequest{
+		Topics: []kafka.TopicConfig{
+			{
+				Topic:             createTopicCommandArgs.Topic,
+				NumPartitions:     createTopicCommandArgs.NumPartitions,
+				ReplicationFactor: createTopicCommandArgs.ReplicationFactor,
+			},
+		},
+	})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if errInCreate := resp.Errors[createTopicCommandArgs.Topic]; errInCreate != nil {
+		return common.RuntimeResult{Success: false}, errInCreate
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": fmt.Sprintf("Topic %s created.", createTopicCommandArgs.Topic)},
+	}, nil
+}
+
+func (c *CommandExecutor) deleteTopic() (common.RuntimeResult, error) {
+	var deleteTopicCommandArgs DeleteTopicCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &deleteTopicCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate kafka delete topic action options
+	validate := validator.New()
+	if err := validate.Struct(deleteTopicCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	resp, err := c.conn.Client().DeleteTopics(ctx, &kafka.DeleteTopicsRequest{Topics: []string{deleteTopicCommandArgs.Topic}})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if errInDelete := resp.Errors[deleteTopicCommandArgs.Topic]; errInDelete != nil {
+		return common.RuntimeResult{Success: false}, errInDelete
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": fmt.Sprintf("Topic %s deleted.", deleteTopicCommandArgs.Topic)},
+	}, nil
+}
+
+// fetchCommittedOffset return the committed offset of the group, or the first offset when the group have not committed yet.
+func (c *CommandExecutor) fetchCommittedOffset(ctx context.Context, groupID string, topic string, partition int) (int64, error) {
+	resp, err := c.conn.Client().OffsetFetch(ctx, &kafka.OffsetFetchRequest{
+		GroupID: groupID,
+		Topics:  map[string][]int{topic: {partition}},
+	})
+	if err != nil {
+		return 0, err
+	}
+	if resp.Error != nil {
+		return 0, resp.Error
+	}
+	for _, partitionOffset := range resp.Topics[topic] {
+		if partitionOffset.Partition != partition {
+			continue
+		}
+		if partitionOffset.Error != nil {
+			return 0, partitionOffset.Error
+		}
+		if partitionOffset.CommittedOffset >= 0 {
+			return partitionOffset.CommittedOffset, nil
+		}
+	}
+	return kafka.FirstOffset, nil
+}
+
+// commitOffset commit offset as a standalone consumer (no generation and member), so it does not join the group.
+func (c *CommandExecutor) commitOffset(ctx context.Context, groupID string, topic string, partition int, offset int64) error {
+	resp, err := c.conn.Client().OffsetCommit(ctx, &kafka.OffsetCommitRequest{
+		GroupID:      groupID,
+		GenerationID: -1,
+		Topics:       map[string][]kafka.OffsetCommit{topic: {{Partition: partition, Offset: offset}}},
+	})
+	if err != nil {
+		return err
+	}
+	for _, partitionResult := range resp.Topics[topic] {
+		if partitionResult.Error != nil {
+			return partitionResult.Error
+		}
+	}
+	return nil
+}
+
+func exportMessage(message kafka.Message) map[string]interface{} {
+	headers := make(map[string]interface{}, len(message.Headers))
+	for _, header := range message.Headers {
+		headers[header.Key] = string(header.Value)
+	}
+	return map[string]interface{}{
+		"topic":     message.Topic,
+		"partition": message.Partition,
+		"offset":    message.Offset,
+		"key":       string(message.Key),
+		"value":     string(message.Value),
+		"headers":   headers,
+		"timestamp": message.Time.UTC().Format(time.RFC3339Nano),
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/segmentio/kafka-go"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (k *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &k.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate kafka options
+	validate := validator.New()
+	if err := validate.Struct(k.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (k *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &k.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate kafka options
+	validate := validator.New()
+	if err := validate.Struct(k.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (k *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get kafka connection
+	conn, err := k.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	// test kafka connection by fetching cluster metadata
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	if _, err := conn.Client().Metadata(ctx, &kafka.MetadataRequest{}); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (k *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get kafka connection
+	conn, err := k.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	// get topics with partition offsets
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	topics, err := listTopics(ctx, conn.Client())
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"topics": topics},
+	}, nil
+}
+
+func (k *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get kafka connection
+	conn, err := k.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get kafka connection: " + err.Error())
+	}
+	defer conn.Close()
+
+	// format kafka action
+	if err := mapstructure.Decode(actionOptions, &k.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{conn: conn, command: k.ActionOpts}
+	switch k.ActionOpts.Commands {
+	case PRODUCE_COMMAND:
+		result, err = commandExecutor.produce()
+	case CONSUME_COMMAND:
+		result, err = commandExecutor.consume()
+	case LIST_TOPICS_COMMAND:
+		result, err = commandExecutor.listTopics()
+	case CREATE_TOPIC_COMMAND:
+		result, err = commandExecutor.createTopic()
+	case DELETE_TOPIC_COMMAND:
+		result, err = commandExecutor.deleteTopic()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported kafka command: "+k.ActionOpts.Commands)
+	}
+
+	return result, err
+}
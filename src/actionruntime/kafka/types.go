@@ -0,0 +1,103 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package kafka
+
+const (
+	PRODUCE_COMMAND      = "produce"
+	CONSUME_COMMAND      = "consume"
+	LIST_TOPICS_COMMAND  = "listTopics"
+	CREATE_TOPIC_COMMAND = "createTopic"
+	DELETE_TOPIC_COMMAND = "deleteTopic"
+)
+
+const (
+	SASL_MECHANISM_PLAIN         = "plain"
+	SASL_MECHANISM_SCRAM_SHA_256 = "scram-sha-256"
+	SASL_MECHANISM_SCRAM_SHA_512 = "scram-sha-512"
+)
+
+const (
+	START_FROM_EARLIEST  = "earliest"
+	START_FROM_LATEST    = "latest"
+	START_FROM_OFFSET    = "offset"
+	START_FROM_TIMESTAMP = "timestamp"
+)
+
+const (
+	DEFAULT_CONSUME_LIMIT   = 10
+	MAX_CONSUME_LIMIT       = 1000
+	DEFAULT_CONSUME_TIMEOUT = 5000 // ms
+	DEFAULT_DIAL_TIMEOUT    = 10   // second
+)
+
+type Resource struct {
+	Brokers  string `validate:"required"` // comma separated broker address list, like "host1:9092,host2:9092"
+	ClientID string
+	SASL     SASLOptions
+	SSL      SSLOptions
+}
+
+type SASLOptions struct {
+	SASL      bool
+	Mechanism string `validate:"required_unless=SASL false,omitempty,oneof=plain scram-sha-256 scram-sha-512"`
+	Username  string `validate:"required_unless=SASL false"`
+	Password  string
+}
+
+type SSLOptions struct {
+	SSL              bool
+	VerificationMode string `validate:"required_unless=SSL false,omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+type Action struct {
+	Commands    string                 `validate:"required,oneof=produce consume listTopics createTopic deleteTopic"`
+	CommandArgs map[string]interface{} `validate:"required"`
+}
+
+type ProduceCommandArgs struct {
+	Topic     string              `json:"topic" validate:"required"`
+	Key       string              `json:"key"`
+	Value     string              `json:"value"`
+	Headers   []map[string]string `json:"headers"`
+	Partition *int                `json:"partition"` // nil for balanced by key
+}
+
+// ConsumeCommandArgs describe a bounded consume on a single partition, it returns when Limit messages were read or Timeout reached.
+// With GroupID, the consume start from the committed offset of the group by default, and commits the offset back when CommitOffsets is set.
+// Without GroupID, the consume is ad hoc and start from the earliest offset by default.
+type ConsumeCommandArgs struct {
+	Topic         string `json:"topic" validate:"required"`
+	GroupID       string `json:"groupID"`
+	Partition     int    `json:"partition"`
+	StartFrom     string `json:"startFrom" validate:"omitempty,oneof=earliest latest offset timestamp"`
+	Offset        int64  `json:"offset"`
+	Timestamp     string `json:"timestamp" validate:"required_if=StartFrom timestamp"` // RFC3339 format
+	Limit         int    `json:"limit"`
+	Timeout       int    `json:"timeout"` // ms
+	CommitOffsets bool   `json:"commitOffsets"`
+}
+
+type CreateTopicCommandArgs struct {
+	Topic             string `json:"topic" validate:"required"`
+	NumPartitions     int    `json:"numPartitions"`
+	ReplicationFactor int    `json:"replicationFactor"`
+}
+
+type DeleteTopicCommandArgs struct {
+	Topic string `json:"topic" validate:"required"`
+}
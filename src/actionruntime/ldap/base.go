@@ -0,0 +1,176 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+)
+
+func (l *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*goldap.Conn, error) {
+	if err := mapstructure.Decode(resourceOptions, &l.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	// build tls config for ldaps and StartTLS
+	serverURL, err := url.Parse(l.ResourceOpts.URL)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig, err := common.BuildTLSConfig(l.ResourceOpts.SSL.VerificationMode, l.ResourceOpts.SSL.CACert, l.ResourceOpts.SSL.ClientCert, l.ResourceOpts.SSL.ClientKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig.ServerName = serverURL.Hostname()
+
+	// connect and bind
+	conn, err := goldap.DialURL(l.ResourceOpts.URL,
+		goldap.DialWithDialer(&net.Dialer{Timeout: DEFAULT_CONNECT_TIMEOUT * time.Second}),
+		goldap.DialWithTLSConfig(tlsConfig))
+	if err != nil {
+		return nil, err
+	}
+	conn.SetTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	if l.ResourceOpts.StartTLS {
+		if err := conn.StartTLS(tlsConfig); err != nil {
+			conn.Close()
+			return nil, err
+		}
+	}
+	if l.ResourceOpts.BindDN != "" {
+		err = conn.Bind(l.ResourceOpts.BindDN, l.ResourceOpts.BindPassword)
+	} else {
+		err = conn.UnauthenticatedBind("")
+	}
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+func parseScope(scope string) int {
+	switch scope {
+	case SCOPE_BASE:
+		return goldap.ScopeBaseObject
+	case SCOPE_ONE:
+		return goldap.ScopeSingleLevel
+	default:
+		return goldap.ScopeWholeSubtree
+	}
+}
+
+// exportEntry maps an entry into a row, the single value attribute is exported as string, and the multiple value
+// attribute is exported as string list. The binary values (like "objectGUID" or "jpegPhoto") are base64 encoded.
+func exportEntry(entry *goldap.Entry) map[string]interface{} {
+	row := make(map[string]interface{}, len(entry.Attributes)+1)
+	row["dn"] = entry.DN
+	for _, attribute := range entry.Attributes {
+		values := make([]string, 0, len(attribute.ByteValues))
+		for _, byteValue := range attribute.ByteValues {
+			if utf8.Valid(byteValue) {
+				values = append(values, string(byteValue))
+			} else {
+				values = append(values, base64.StdEncoding.EncodeToString(byteValue))
+			}
+		}
+		if len(values) == 1 {
+			row[attribute.Name] = values[0]
+		} else {
+			row[attribute.Name] = values
+		}
+	}
+	return row
+}
+
+// normalizeAttributeValues converts the attribute values from a string or a list into string list, the attribute names are sorted.
+func normalizeAttributeValues(attributes map[string]interface{}) ([]string, map[string][]string, error) {
+	names := make([]string, 0, len(attributes))
+	normalized := make(map[string][]string, len(attributes))
+	for name, value := range attributes {
+		var values []string
+		switch v := value.(type) {
+		case nil:
+			values = []string{}
+		case string:
+			values = []string{v}
+		case []string:
+			values = v
+		case []interface{}:
+			values = make([]string, 0, len(v))
+			for _, item := range v {
+				formatted, ok := formatAttributeValue(item)
+				if !ok {
+					return nil, nil, errors.New("unsupported value type of attribute " + name)
+				}
+				values = append(values, formatted)
+			}
+		default:
+			formatted, ok := formatAttributeValue(v)
+			if !ok {
+				return nil, nil, errors.New("unsupported value type of attribute " + name)
+			}
+			values = []string{formatted}
+		}
+		names = append(names, name)
+		normalized[name] = values
+	}
+	sort.Strings(names)
+	return names, normalized, nil
+}
+
+// formatAttributeValue formats a scalar value in the LDAP string representation, the JSON number is formatted without
+// exponent (1000000 instead of "1e+06") and the boolean is "TRUE" or "FALSE" as the Boolean syntax requires.
+func formatAttributeValue(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case string:
+		return v, true
+	case bool:
+		if v {
+			return "TRUE", true
+		}
+		return "FALSE", true
+	case int:
+		return strconv.Itoa(v), true
+	case int64:
+		return strconv.FormatInt(v, 10), true
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), true
+	default:
+		return "", false
+	}
+}
+
+// encodeActiveDirectoryPassword encodes the password into the "unicodePwd" format, a quoted UTF-16LE string.
+func encodeActiveDirectoryPassword(password string) string {
+	encoded := utf16.Encode([]rune("\"" + password + "\""))
+	buf := make([]byte, len(encoded)*2)
+	for i, r := range encoded {
+		binary.LittleEndian.PutUint16(buf[i*2:], r)
+	}
+	return string(buf)
+}
@@ -0,0 +1,53 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeAttributeValues(t *testing.T) {
+	names, normalized, err := normalizeAttributeValues(map[string]interface{}{
+		"uidNumber":   float64(1000000),
+		"gidNumber":   []interface{}{float64(2000000), "3000000"},
+		"shadowMin":   float64(0.5),
+		"pwdLockout":  true,
+		"isRetired":   []interface{}{false},
+		"cn":          "Alice",
+		"objectClass": []interface{}{"top", "person"},
+		"emptyAttr":   nil,
+		"shadowMax":   int64(42),
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"cn", "emptyAttr", "gidNumber", "isRetired", "objectClass", "pwdLockout", "shadowMax", "shadowMin", "uidNumber"}, names)
+	assert.Equal(t, []string{"1000000"}, normalized["uidNumber"])
+	assert.Equal(t, []string{"2000000", "3000000"}, normalized["gidNumber"])
+	assert.Equal(t, []string{"0.5"}, normalized["shadowMin"])
+	assert.Equal(t, []string{"TRUE"}, normalized["pwdLockout"])
+	assert.Equal(t, []string{"FALSE"}, normalized["isRetired"])
+	assert.Equal(t, []string{"Alice"}, normalized["cn"])
+	assert.Equal(t, []string{"top", "person"}, normalized["objectClass"])
+	assert.Equal(t, []string{}, normalized["emptyAttr"])
+	assert.Equal(t, []string{"42"}, normalized["shadowMax"])
+}
+
+func TestNormalizeAttributeValuesRejectsNestedObject(t *testing.T) {
+	_, _, err := normalizeAttributeValues(map[string]interface{}{
+		"description": []interface{}{map[string]interface{}{"text": "nested"}},
+	})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,227 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type CommandExecutor struct {
+	conn    *goldap.Conn
+	command Action
+	baseDN  string
+}
+
+func (c *CommandExecutor) search() (common.RuntimeResult, error) {
+	var searchCommandArgs SearchCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &searchCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate ldap search action options
+	validate := validator.New()
+	if err := validate.Struct(searchCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if searchCommandArgs.BaseDN == "" {
+		searchCommandArgs.BaseDN = c.baseDN
+	}
+	if searchCommandArgs.Filter == "" {
+		searchCommandArgs.Filter = DEFAULT_FILTER
+	}
+	if searchCommandArgs.PageSize <= 0 {
+		searchCommandArgs.PageSize = DEFAULT_PAGE_SIZE
+	}
+	if searchCommandArgs.PageSize > MAX_PAGE_SIZE {
+		searchCommandArgs.PageSize = MAX_PAGE_SIZE
+	}
+	if searchCommandArgs.SizeLimit <= 0 || searchCommandArgs.SizeLimit > MAX_SIZE_LIMIT {
+		searchCommandArgs.SizeLimit = MAX_SIZE_LIMIT
+	}
+
+	// fetch all pages on the same connection, the paging cookie is only valid on the connection which started the search
+	searchRequest := goldap.NewSearchRequest(
+		searchCommandArgs.BaseDN,
+		parseScope(searchCommandArgs.Scope),
+		goldap.NeverDerefAliases,
+		searchCommandArgs.SizeLimit,
+		int(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT.Seconds()),
+		false,
+		searchCommandArgs.Filter,
+		searchCommandArgs.Attributes,
+		nil,
+	)
+	result, err := c.conn.SearchWithPaging(searchRequest, uint32(searchCommandArgs.PageSize))
+	sizeLimitExceeded := goldap.IsErrorWithCode(err, goldap.LDAPResultSizeLimitExceeded)
+	if err != nil && !sizeLimitExceeded {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// some servers apply the size limit to each page, so cap the entries again
+	entries := result.Entries
+	if len(entries) > searchCommandArgs.SizeLimit {
+		entries = entries[:searchCommandArgs.SizeLimit]
+		sizeLimitExceeded = true
+	}
+	rows := make([]map[string]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		rows = append(rows, exportEntry(entry))
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra: map[string]interface{}{
+			"sizeLimitExceeded": sizeLimitExceeded,
+		},
+	}, nil
+}
+
+func (c *CommandExecutor) addEntry() (common.RuntimeResult, error) {
+	var addCommandArgs AddCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &addCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate ldap add action options
+	validate := validator.New()
+	if err := validate.Struct(addCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	names, attributes, err := normalizeAttributeValues(addCommandArgs.Attributes)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	addRequest := goldap.NewAddRequest(addCommandArgs.DN, nil)
+	for _, name := range names {
+		addRequest.Attribute(name, attributes[name])
+	}
+	if err := c.conn.Add(addRequest); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": "Added entry " + addCommandArgs.DN + "."},
+	}, nil
+}
+
+func (c *CommandExecutor) modifyEntry() (common.RuntimeResult, error) {
+	var modifyCommandArgs ModifyCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &modifyCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate ldap modify action options
+	validate := validator.New()
+	if err := validate.Struct(modifyCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if len(modifyCommandArgs.Add) == 0 && len(modifyCommandArgs.Replace) == 0 && len(modifyCommandArgs.Delete) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("no attribute changes")
+	}
+
+	modifyRequest := goldap.NewModifyRequest(modifyCommandArgs.DN, nil)
+	changes := []struct {
+		attributes map[string]interface{}
+		apply      func(string, []string)
+	}{
+		{modifyCommandArgs.Add, modifyRequest.Add},
+		{modifyCommandArgs.Replace, modifyRequest.Replace},
+		{modifyCommandArgs.Delete, modifyRequest.Delete},
+	}
+	for _, change := range changes {
+		names, attributes, err := normalizeAttributeValues(change.attributes)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		for _, name := range names {
+			change.apply(name, attributes[name])
+		}
+	}
+	if err := c.conn.Modify(modifyRequest); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": "Modified entry " + modifyCommandArgs.DN + "."},
+	}, nil
+}
+
+func (c *CommandExecutor) deleteEntry() (common.RuntimeResult, error) {
+	var deleteCommandArgs DeleteCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &deleteCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate ldap delete action options
+	validate := validator.New()
+	if err := validate.Struct(deleteCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	if err := c.conn.Del(goldap.NewDelRequest(deleteCommandArgs.DN, nil)); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": "Deleted entry " + deleteCommandArgs.DN + "."},
+	}, nil
+}
+
+func (c *CommandExecutor) resetPassword() (common.RuntimeResult, error) {
+	var resetPasswordCommandArgs ResetPasswordCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &resetPasswordCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate ldap resetPassword action options
+	validate := validator.New()
+	if err := validate.Struct(resetPasswordCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	if resetPasswordCommandArgs.Mode == PASSWORD_MODE_ACTIVE_DIRECTORY {
+		// the Active Directory resets password by replacing "unicodePwd", or changes it by deleting the old one and adding the new one
+		modifyRequest := goldap.NewModifyRequest(resetPasswordCommandArgs.DN, nil)
+		if resetPasswordCommandArgs.OldPassword != "" {
+			modifyRequest.Delete("unicodePwd", []string{encodeActiveDirectoryPassword(resetPasswordCommandArgs.OldPassword)})
+			modifyRequest.Add("unicodePwd", []string{encodeActiveDirectoryPassword(resetPasswordCommandArgs.NewPassword)})
+		} else {
+			modifyRequest.Replace("unicodePwd", []string{encodeActiveDirectoryPassword(resetPasswordCommandArgs.NewPassword)})
+		}
+		if err := c.conn.Modify(modifyRequest); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	} else {
+		passwordModifyRequest := goldap.NewPasswordModifyRequest(resetPasswordCommandArgs.DN, resetPasswordCommandArgs.OldPassword, resetPasswordCommandArgs.NewPassword)
+		if _, err := c.conn.PasswordModify(passwordModifyRequest); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": "Reset password of " + resetPasswordCommandArgs.DN + "."},
+	}, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	goldap "github.com/go-ldap/ldap/v3"
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (l *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &l.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate ldap options
+	validate := validator.New()
+	if err := validate.Struct(l.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (l *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &l.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate ldap options
+	validate := validator.New()
+	if err := validate.Struct(l.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (l *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get ldap connection, the connection is bound with the bind DN
+	conn, err := l.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (l *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get ldap connection
+	conn, err := l.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	// read the root DSE
+	searchRequest := goldap.NewSearchRequest("", goldap.ScopeBaseObject, goldap.NeverDerefAliases, 1, 0, false, DEFAULT_FILTER,
+		[]string{"namingContexts", "defaultNamingContext", "supportedLDAPVersion", "vendorName", "vendorVersion"}, nil)
+	result, err := conn.Search(searchRequest)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	rootDSE := map[string]interface{}{}
+	if len(result.Entries) > 0 {
+		rootDSE = exportEntry(result.Entries[0])
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"baseDN": l.ResourceOpts.BaseDN, "rootDSE": rootDSE},
+	}, nil
+}
+
+func (l *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get ldap connection
+	conn, err := l.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get ldap connection: " + err.Error())
+	}
+	defer conn.Close()
+
+	// format ldap action
+	if err := mapstructure.Decode(actionOptions, &l.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{conn: conn, command: l.ActionOpts, baseDN: l.ResourceOpts.BaseDN}
+	switch l.ActionOpts.Commands {
+	case SEARCH_COMMAND:
+		result, err = commandExecutor.search()
+	case ADD_COMMAND:
+		result, err = commandExecutor.addEntry()
+	case MODIFY_COMMAND:
+		result, err = commandExecutor.modifyEntry()
+	case DELETE_COMMAND:
+		result, err = commandExecutor.deleteEntry()
+	case RESET_PASSWORD_COMMAND:
+		result, err = commandExecutor.resetPassword()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported ldap command: "+l.ActionOpts.Commands)
+	}
+
+	return result, err
+}
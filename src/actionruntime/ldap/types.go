@@ -0,0 +1,102 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package ldap
+
+const (
+	SEARCH_COMMAND         = "search"
+	ADD_COMMAND            = "add"
+	MODIFY_COMMAND         = "modify"
+	DELETE_COMMAND         = "delete"
+	RESET_PASSWORD_COMMAND = "resetPassword"
+)
+
+const (
+	SCOPE_BASE = "base"
+	SCOPE_ONE  = "one"
+	SCOPE_SUB  = "sub"
+)
+
+const (
+	PASSWORD_MODE_PASSWORD_MODIFY  = "passwordModify" // RFC 3062 password modify extended operation
+	PASSWORD_MODE_ACTIVE_DIRECTORY = "activeDirectory"
+)
+
+const (
+	DEFAULT_FILTER          = "(objectClass=*)"
+	DEFAULT_PAGE_SIZE       = 100
+	MAX_PAGE_SIZE           = 1000
+	MAX_SIZE_LIMIT          = 10000
+	DEFAULT_CONNECT_TIMEOUT = 10 // second
+)
+
+// Resource describe a LDAP server, the URL scheme is "ldap" or "ldaps", and StartTLS upgrades the "ldap" connection.
+type Resource struct {
+	URL          string `validate:"required"`
+	BindDN       string
+	BindPassword string
+	BaseDN       string
+	StartTLS     bool
+	SSL          SSLOptions
+}
+
+type SSLOptions struct {
+	VerificationMode string `validate:"omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+type Action struct {
+	Commands    string                 `validate:"required,oneof=search add modify delete resetPassword"`
+	CommandArgs map[string]interface{} `validate:"required"`
+}
+
+// SearchCommandArgs describe a search, all pages of PageSize entries are fetched until SizeLimit entries,
+// the SizeLimit is capped by MAX_SIZE_LIMIT.
+type SearchCommandArgs struct {
+	BaseDN     string   `json:"baseDN"`
+	Filter     string   `json:"filter"`
+	Scope      string   `json:"scope" validate:"omitempty,oneof=base one sub"`
+	Attributes []string `json:"attributes"`
+	SizeLimit  int      `json:"sizeLimit"`
+	PageSize   int      `json:"pageSize"`
+}
+
+// the attribute values accept a string or a string list, like {"cn": "foo", "objectClass": ["top", "person"]}
+
+type AddCommandArgs struct {
+	DN         string                 `json:"dn" validate:"required"`
+	Attributes map[string]interface{} `json:"attributes" validate:"required,gt=0"`
+}
+
+// ModifyCommandArgs describe the attribute changes of an entry, the attribute with empty values in Delete removes the whole attribute.
+type ModifyCommandArgs struct {
+	DN      string                 `json:"dn" validate:"required"`
+	Add     map[string]interface{} `json:"add"`
+	Replace map[string]interface{} `json:"replace"`
+	Delete  map[string]interface{} `json:"delete"`
+}
+
+type DeleteCommandArgs struct {
+	DN string `json:"dn" validate:"required"`
+}
+
+// ResetPasswordCommandArgs describe a password reset, the Active Directory requires the connection is encrypted.
+type ResetPasswordCommandArgs struct {
+	DN          string `json:"dn" validate:"required"`
+	NewPassword string `json:"newPassword" validate:"required"`
+	OldPassword string `json:"oldPassword"`
+	Mode        string `json:"mode" validate:"omitempty,oneof=passwordModify activeDirectory"`
+}
@@ -0,0 +1,224 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mongodb
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/mitchellh/mapstructure"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/gridfs"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func (q *QueryRunner) gridFSBucket(name string) (*gridfs.Bucket, error) {
+	if name == "" {
+		name = DEFAULT_GRIDFS_BUCKET
+	}
+	bucket, err := gridfs.NewBucket(q.client.Database(q.db), options.GridFSBucket().SetName(name))
+	if err != nil {
+		return nil, err
+	}
+	deadline := time.Now().Add(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	if err := bucket.SetReadDeadline(deadline); err != nil {
+		return nil, err
+	}
+	if err := bucket.SetWriteDeadline(deadline); err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
+
+// parseGridFSFileID accepts either a hex ObjectID or an extended JSON value,
+// anything else is used as a plain string id.
+func parseGridFSFileID(rawID string) interface{} {
+	if objectID, err := primitive.ObjectIDFromHex(rawID); err == nil {
+		return objectID
+	}
+	var wrapper bson.D
+	if err := bson.UnmarshalExtJSON([]byte(`{"id":`+rawID+`}`), true, &wrapper); err == nil && len(wrapper) == 1 {
+		return wrapper[0].Value
+	}
+	return rawID
+}
+
+func exportGridFSFile(file *gridfs.File) map[string]interface{} {
+	var metadata bson.M
+	if len(file.Metadata) > 0 {
+		_ = bson.Unmarshal(file.Metadata, &metadata)
+	}
+	return map[string]interface{}{
+		"id":         file.ID,
+		"filename":   file.Name,
+		"length":     file.Length,
+		"chunkSize":  file.ChunkSize,
+		"uploadDate": file.UploadDate,
+		"metadata":   metadata,
+	}
+}
+
+func (q *QueryRunner) gridFSList() (common.RuntimeResult, error) {
+	var lOptions GridFSListContent
+	if err := mapstructure.Decode(q.query.TypeContent, &lOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucket, err := q.gridFSBucket(lOptions.Bucket)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	filter := bson.D{}
+	if lOptions.Query != "" && lOptions.Query != "{}" {
+		if err := bson.UnmarshalExtJSON([]byte(lOptions.Query), true, &filter); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+	opts := options.GridFSFind().SetLimit(DEFAULT_GRIDFS_LIST_LIMIT).SetSort(bson.D{{Key: "uploadDate", Value: -1}})
+	if lOptions.SortBy != "" && lOptions.SortBy != "{}" {
+		var sortBy bson.D
+		if err := bson.UnmarshalExtJSON([]byte(lOptions.SortBy), true, &sortBy); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		opts = opts.SetSort(sortBy)
+	}
+	if lOptions.Limit != "" {
+		limit, err := strconv.ParseInt(lOptions.Limit, 10, 32)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		opts = opts.SetLimit(int32(limit))
+	}
+	if lOptions.Skip != "" {
+		skip, err := strconv.ParseInt(lOptions.Skip, 10, 32)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		opts = opts.SetSkip(int32(skip))
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	cursor, err := bucket.FindContext(ctx, filter, opts)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	var files []gridfs.File
+	if err := cursor.All(ctx, &files); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	results := make([]map[string]interface{}, 0, len(files))
+	for i := range files {
+		results = append(results, exportGridFSFile(&files[i]))
+	}
+	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{{"result": results}}}, nil
+}
+
+func (q *QueryRunner) gridFSRead() (common.RuntimeResult, error) {
+	var rOptions GridFSReadContent
+	if err := mapstructure.Decode(q.query.TypeContent, &rOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucket, err := q.gridFSBucket(rOptions.Bucket)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var stream *gridfs.DownloadStream
+	switch {
+	case rOptions.FileID != "":
+		stream, err = bucket.OpenDownloadStream(parseGridFSFileID(rOptions.FileID))
+	case rOptions.Filename != "":
+		opts := options.GridFSName()
+		if rOptions.Revision != "" {
+			revision, err := strconv.ParseInt(rOptions.Revision, 10, 32)
+			if err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+			opts = opts.SetRevision(int32(revision))
+		}
+		stream, err = bucket.OpenDownloadStreamByName(rOptions.Filename, opts)
+	default:
+		return common.RuntimeResult{Success: false}, errors.New("gridFSRead requires a fileID or filename")
+	}
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	defer stream.Close()
+
+	file := stream.GetFile()
+	if file.Length > MAX_GRIDFS_READ_SIZE {
+		return common.RuntimeResult{Success: false}, fmt.Errorf("file size %d exceeds the %d bytes read limit", file.Length, MAX_GRIDFS_READ_SIZE)
+	}
+	var buffer bytes.Buffer
+	if _, err := io.Copy(&buffer, stream); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	result := exportGridFSFile(file)
+	result["data"] = base64.StdEncoding.EncodeToString(buffer.Bytes())
+	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{{"result": result}}}, nil
+}
+
+func (q *QueryRunner) gridFSUpload() (common.RuntimeResult, error) {
+	var uOptions GridFSUploadContent
+	if err := mapstructure.Decode(q.query.TypeContent, &uOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if uOptions.Filename == "" {
+		return common.RuntimeResult{Success: false}, errors.New("gridFSUpload requires a filename")
+	}
+	data, err := base64.StdEncoding.DecodeString(uOptions.Data)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("gridFSUpload data must be base64 encoded: " + err.Error())
+	}
+	bucket, err := q.gridFSBucket(uOptions.Bucket)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	metadata := bson.D{}
+	if uOptions.Metadata != "" && uOptions.Metadata != "{}" {
+		if err := bson.UnmarshalExtJSON([]byte(uOptions.Metadata), true, &metadata); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+	if uOptions.ContentType != "" {
+		metadata = append(metadata, bson.E{Key: "contentType", Value: uOptions.ContentType})
+	}
+	opts := options.GridFSUpload()
+	if len(metadata) > 0 {
+		opts = opts.SetMetadata(metadata)
+	}
+
+	fileID, err := bucket.UploadFromStream(uOptions.Filename, bytes.NewReader(data), opts)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{{"result": map[string]interface{}{
+		"id":       fileID,
+		"filename": uOptions.Filename,
+		"length":   len(data),
+	}}}}, nil
+}
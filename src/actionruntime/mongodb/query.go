@@ -17,8 +17,10 @@ package mongodb
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strconv"
+	"time"
 
 	"github.com/illacloud/builder-backend/src/actionruntime/common"
 
@@ -26,6 +28,8 @@ import (
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 type QueryRunner struct {
@@ -608,3 +612,288 @@ func (q *QueryRunner) command() (common.RuntimeResult, error) {
 
 	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{{"result": results}}}, nil
 }
+
+func (q *QueryRunner) transaction() (common.RuntimeResult, error) {
+	var tOptions TransactionContent
+	if err := mapstructure.Decode(q.query.TypeContent, &tOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	var operations []bson.D
+	if tOptions.Operations != "" && tOptions.Operations != "[]" {
+		if err := bson.UnmarshalExtJSON([]byte(tOptions.Operations), true, &operations); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+	if len(operations) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("transaction requires at least one operation")
+	}
+
+	// build `TransactionOptions`
+	if tOptions.Options == "" {
+		tOptions.Options = "{}"
+	}
+	var rawTransactionOptions map[string]interface{}
+	if err := json.Unmarshal([]byte(tOptions.Options), &rawTransactionOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	var parsedTransactionOptions TransactionOptions
+	if err := mapstructure.Decode(rawTransactionOptions, &parsedTransactionOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	opts := options.Transaction()
+	if parsedTransactionOptions.ReadConcern != "" {
+		opts = opts.SetReadConcern(readconcern.New(readconcern.Level(parsedTransactionOptions.ReadConcern)))
+	}
+	switch w := parsedTransactionOptions.WriteConcern.(type) {
+	case nil:
+	case string:
+		if w == "majority" {
+			opts = opts.SetWriteConcern(writeconcern.New(writeconcern.WMajority()))
+		} else {
+			opts = opts.SetWriteConcern(writeconcern.New(writeconcern.WTagSet(w)))
+		}
+	case float64:
+		opts = opts.SetWriteConcern(writeconcern.New(writeconcern.W(int(w))))
+	default:
+		return common.RuntimeResult{Success: false}, fmt.Errorf("unsupported transaction write concern: %v", w)
+	}
+	if parsedTransactionOptions.MaxCommitTimeMS > 0 {
+		maxCommitTime := time.Duration(parsedTransactionOptions.MaxCommitTimeMS) * time.Millisecond
+		opts = opts.SetMaxCommitTime(&maxCommitTime)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+
+	// transactions are only available on replica sets and sharded clusters
+	if err := q.checkTransactionSupport(ctx); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	session, err := q.client.StartSession()
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	defer session.EndSession(ctx)
+
+	results, err := session.WithTransaction(ctx, func(sessCtx mongo.SessionContext) (interface{}, error) {
+		rows := make([]map[string]interface{}, 0, len(operations))
+		for index, operation := range operations {
+			row, err := q.runTransactionOperation(sessCtx, operation)
+			if err != nil {
+				return nil, fmt.Errorf("transaction operation %d failed: %s", index, err.Error())
+			}
+			row["index"] = index
+			rows = append(rows, row)
+		}
+		return rows, nil
+	}, opts)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	return common.RuntimeResult{Success: true, Rows: []map[string]interface{}{{"result": results}}}, nil
+}
+
+func (q *QueryRunner) checkTransactionSupport(ctx context.Context) error {
+	var hello bson.M
+	if err := q.client.Database("admin").RunCommand(ctx, bson.D{{Key: "hello", Value: 1}}).Decode(&hello); err != nil {
+		// servers older than 4.4.2 only know the legacy handshake command
+		if err := q.client.Database("admin").RunCommand(ctx, bson.D{{Key: "isMaster", Value: 1}}).Decode(&hello); err != nil {
+			return err
+		}
+	}
+	if setName, ok := hello["setName"].(string); ok && setName != "" {
+		return nil
+	}
+	if msg, ok := hello["msg"].(string); ok && msg == "isdbgrid" {
+		return nil
+	}
+	return errors.New("transactions require a replica set or sharded cluster deployment")
+}
+
+func (q *QueryRunner) runTransactionOperation(ctx mongo.SessionContext, operation bson.D) (map[string]interface{}, error) {
+	if len(operation) != 1 {
+		return nil, errors.New("each operation must contain exactly one operation type")
+	}
+	operationType := operation[0].Key
+	content, ok := operation[0].Value.(bson.D)
+	if !ok {
+		return nil, fmt.Errorf("%s operation must be a document", operationType)
+	}
+	contentMap := content.Map()
+
+	collection := q.query.Collection
+	if name, ok := contentMap["collection"].(string); ok && name != "" {
+		collection = name
+	}
+	if collection == "" {
+		return nil, fmt.Errorf("%s operation is missing a collection", operationType)
+	}
+	coll := q.client.Database(q.db).Collection(collection)
+
+	var result interface{}
+	var err error
+	switch operationType {
+	case "insertOne":
+		document, ok := contentMap["document"].(bson.D)
+		if !ok {
+			return nil, errors.New("insertOne requires a document")
+		}
+		result, err = coll.InsertOne(ctx, document)
+	case "insertMany":
+		documents, ok := contentMap["documents"].(bson.A)
+		if !ok {
+			return nil, errors.New("insertMany requires a documents array")
+		}
+		result, err = coll.InsertMany(ctx, documents)
+	case "updateOne", "updateMany":
+		filter, update := transactionDocument(contentMap, "filter"), contentMap["update"]
+		if update == nil {
+			return nil, fmt.Errorf("%s requires an update", operationType)
+		}
+		opts := options.Update()
+		if upsert, ok := contentMap["upsert"].(bool); ok {
+			opts = opts.SetUpsert(upsert)
+		}
+		if operationType == "updateOne" {
+			result, err = coll.UpdateOne(ctx, filter, update, opts)
+		} else {
+			result, err = coll.UpdateMany(ctx, filter, update, opts)
+		}
+	case "replaceOne":
+		replacement, ok := contentMap["replacement"].(bson.D)
+		if !ok {
+			return nil, errors.New("replaceOne requires a replacement")
+		}
+		opts := options.Replace()
+		if upsert, ok := contentMap["upsert"].(bool); ok {
+			opts = opts.SetUpsert(upsert)
+		}
+		result, err = coll.ReplaceOne(ctx, transactionDocument(contentMap, "filter"), replacement, opts)
+	case "deleteOne":
+		result, err = coll.DeleteOne(ctx, transactionDocument(contentMap, "filter"))
+	case "deleteMany":
+		result, err = coll.DeleteMany(ctx, transactionDocument(contentMap, "filter"))
+	case "findOne":
+		var document bson.M
+		err = coll.FindOne(ctx, transactionDocument(contentMap, "filter")).Decode(&document)
+		if err == mongo.ErrNoDocuments {
+			err = nil
+		}
+		result = document
+	default:
+		return nil, errors.New("unsupported transaction operation: " + operationType)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{"operation": operationType, "collection": collection, "result": result}, nil
+}
+
+func transactionDocument(content map[string]interface{}, key string) bson.D {
+	if document, ok := content[key].(bson.D); ok {
+		return document
+	}
+	return bson.D{}
+}
+
+func (q *QueryRunner) watch() (common.RuntimeResult, error) {
+	var wOptions WatchContent
+	if err := mapstructure.Decode(q.query.TypeContent, &wOptions); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	pipeline := []bson.D{}
+	if wOptions.Pipeline != "" && wOptions.Pipeline != "[]" {
+		if err := bson.UnmarshalExtJSON([]byte(wOptions.Pipeline), true, &pipeline); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
+	// the sampling window must end before the action execution timeout
+	duration := int64(DEFAULT_WATCH_DURATION)
+	if wOptions.Duration != "" {
+		parsedDuration, err := strconv.ParseInt(wOptions.Duration, 10, 64)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		duration = parsedDuration
+	}
+	if duration <= 0 || duration > MAX_WATCH_DURATION {
+		return common.RuntimeResult{Success: false}, fmt.Errorf("watch duration must be between 1 and %d seconds", MAX_WATCH_DURATION)
+	}
+	maxEvents := DEFAULT_WATCH_MAX_EVENTS
+	if wOptions.MaxEvents != "" {
+		parsedMaxEvents, err := strconv.Atoi(wOptions.MaxEvents)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		maxEvents = parsedMaxEvents
+	}
+	if maxEvents <= 0 || maxEvents > MAX_WATCH_MAX_EVENTS {
+		return common.RuntimeResult{Success: false}, fmt.Errorf("watch maxEvents must be between 1 and %d", MAX_WATCH_MAX_EVENTS)
+	}
+
+	opts := options.ChangeStream()
+	if wOptions.FullDocument != "" && wOptions.FullDocument != WATCH_FULL_DOCUMENT_DEFAULT {
+		opts = opts.SetFullDocument(options.FullDocument(wOptions.FullDocument))
+	}
+	if wOptions.StartAfter != "" && wOptions.StartAfter != "{}" {
+		var startAfter bson.D
+		if err := bson.UnmarshalExtJSON([]byte(wOptions.StartAfter), true, &startAfter); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		opts = opts.SetStartAfter(startAfter)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Duration(duration)*time.Second)
+	defer cancel()
+
+	var stream *mongo.ChangeStream
+	var err error
+	switch wOptions.Scope {
+	case WATCH_SCOPE_DATABASE:
+		stream, err = q.client.Database(q.db).Watch(ctx, pipeline, opts)
+	case "", WATCH_SCOPE_COLLECTION:
+		if q.query.Collection == "" {
+			return common.RuntimeResult{Success: false}, errors.New("watch on a collection requires a collection name")
+		}
+		stream, err = q.client.Database(q.db).Collection(q.query.Collection).Watch(ctx, pipeline, opts)
+	default:
+		return common.RuntimeResult{Success: false}, errors.New("unsupported watch scope: " + wOptions.Scope)
+	}
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	defer stream.Close(context.Background())
+
+	events := make([]bson.M, 0)
+	for len(events) < maxEvents && stream.Next(ctx) {
+		var event bson.M
+		if err := stream.Decode(&event); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		events = append(events, event)
+	}
+	// reaching the end of the sampling window is the normal way to stop
+	windowClosed := ctx.Err() != nil
+	if err := stream.Err(); err != nil && !windowClosed {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var resumeToken interface{}
+	if token := stream.ResumeToken(); token != nil {
+		var decodedToken bson.M
+		if err := bson.Unmarshal(token, &decodedToken); err == nil {
+			resumeToken = decodedToken
+		}
+	}
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"result": events}},
+		Extra: map[string]interface{}{
+			"count":        len(events),
+			"windowClosed": windowClosed,
+			"resumeToken":  resumeToken,
+		},
+	}, nil
+}
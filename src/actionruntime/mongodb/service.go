@@ -131,6 +131,13 @@ func (m *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		db = "test"
 	}
 
+	// bind the context values into the parsed documents in safe mode
+	if m.Action.IsSafeMode() {
+		if err := m.Action.EscapeTypeContent(rawActionOptions); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
 	var result common.RuntimeResult
 	queryRunner := QueryRunner{client: client, query: m.Action, db: db}
 	switch m.Action.ActionType {
@@ -164,6 +171,16 @@ func (m *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		result, err = queryRunner.updateOne()
 	case "command":
 		result, err = queryRunner.command()
+	case "transaction":
+		result, err = queryRunner.transaction()
+	case "watch":
+		result, err = queryRunner.watch()
+	case "gridFSList":
+		result, err = queryRunner.gridFSList()
+	case "gridFSRead":
+		result, err = queryRunner.gridFSRead()
+	case "gridFSUpload":
+		result, err = queryRunner.gridFSUpload()
 	}
 
 	return result, err
@@ -14,7 +14,15 @@
 
 package mongodb
 
-import "go.mongodb.org/mongo-driver/mongo/options"
+import (
+	"errors"
+	"strings"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	parser_document "github.com/illacloud/builder-backend/src/utils/parser/document"
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
 
 const (
 	STANDARD_FORMAT    = "standard"
@@ -23,8 +31,42 @@ const (
 	URI_OPTIONS        = "uri"
 )
 
+const (
+	DEFAULT_GRIDFS_BUCKET       = "fs"
+	DEFAULT_GRIDFS_LIST_LIMIT   = 100
+	MAX_GRIDFS_READ_SIZE        = 16 << 20
+	DEFAULT_WATCH_DURATION      = 10
+	MAX_WATCH_DURATION          = 25
+	DEFAULT_WATCH_MAX_EVENTS    = 100
+	MAX_WATCH_MAX_EVENTS        = 1000
+	WATCH_SCOPE_COLLECTION      = "collection"
+	WATCH_SCOPE_DATABASE        = "database"
+	WATCH_FULL_DOCUMENT_DEFAULT = "default"
+)
+
+const (
+	FIELD_TYPE_CONTENT = "typeContent"
+)
+
 var (
 	CONNECTION_FORMAT = map[string]string{STANDARD_FORMAT: "mongodb", DNSSEEDLIST_FORMAT: "mongodb+srv"}
+
+	// DOCUMENT_FIELDS lists the type content fields holding an extended JSON document, they are rendered by the
+	// document escaper in safe mode.
+	DOCUMENT_FIELDS = map[string]bool{
+		"aggregation": true,
+		"operations":  true,
+		"options":     true,
+		"query":       true,
+		"filter":      true,
+		"update":      true,
+		"projection":  true,
+		"sortby":      true,
+		"document":    true,
+		"pipeline":    true,
+		"startafter":  true,
+		"metadata":    true,
+	}
 )
 
 type Options struct {
@@ -56,6 +98,36 @@ type Query struct {
 	ActionType  string `validate:"required"`
 	Collection  string
 	TypeContent map[string]interface{} `validate:"required"`
+	Mode        string                 `validate:"omitempty,oneof=document document-safe"`
+}
+
+func (q *Query) IsSafeMode() bool {
+	return q.Mode == common.MODE_DOCUMENT_SAFE
+}
+
+// EscapeTypeContent renders the document fields from the raw template with the document escaper, so the context
+// values are bound as typed leaves and can not inject query operators.
+func (q *Query) EscapeTypeContent(rawTemplate map[string]interface{}) error {
+	rawTypeContent, assertPass := rawTemplate[FIELD_TYPE_CONTENT].(map[string]interface{})
+	if !assertPass {
+		return errors.New("missing typeContent field for EscapeTypeContent() in query")
+	}
+	context, err := parser_document.ExportContextFromRawTemplate(rawTemplate)
+	if err != nil {
+		return err
+	}
+	documentEscaper := parser_document.NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	for field, rawValue := range rawTypeContent {
+		if !DOCUMENT_FIELDS[strings.ToLower(field)] {
+			continue
+		}
+		escapedValue, err := documentEscaper.EscapeDocumentValue(rawValue, context)
+		if err != nil {
+			return err
+		}
+		q.TypeContent[field] = escapedValue
+	}
+	return nil
 }
 
 type AggregateContent struct {
@@ -134,6 +206,43 @@ type CommandContent struct {
 	Document string
 }
 
+type TransactionContent struct {
+	Operations string
+	Options    string
+}
+
+type WatchContent struct {
+	Pipeline     string
+	Scope        string
+	FullDocument string
+	Duration     string
+	MaxEvents    string
+	StartAfter   string
+}
+
+type GridFSListContent struct {
+	Bucket string
+	Query  string
+	SortBy string
+	Limit  string
+	Skip   string
+}
+
+type GridFSReadContent struct {
+	Bucket   string
+	FileID   string
+	Filename string
+	Revision string
+}
+
+type GridFSUploadContent struct {
+	Bucket      string
+	Filename    string
+	Data        string
+	ContentType string
+	Metadata    string
+}
+
 type AggregateOptions struct {
 	Collation *options.Collation
 	Hint      interface{}
@@ -167,3 +276,9 @@ type UpdateOneOptions struct {
 	ArrayFilters []interface{}
 	Upsert       bool
 }
+
+type TransactionOptions struct {
+	ReadConcern     string
+	WriteConcern    interface{}
+	MaxCommitTimeMS int64
+}
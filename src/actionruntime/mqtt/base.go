@@ -0,0 +1,85 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+)
+
+func (m *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (pahomqtt.Client, error) {
+	if err := mapstructure.Decode(resourceOptions, &m.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	// concurrent runs of the same resource need distinct client ids, only the fixed client id keeps the session
+	clientID := m.ResourceOpts.ClientID
+	if !m.ResourceOpts.FixedClientID {
+		if clientID == "" {
+			clientID = DEFAULT_CLIENT_ID_PREFIX
+		}
+		clientID += "-" + strings.ReplaceAll(uuid.New().String(), "-", "")[:CLIENT_ID_SUFFIX_LENGTH]
+	}
+	opts := pahomqtt.NewClientOptions().
+		AddBroker(m.ResourceOpts.BrokerURL).
+		SetClientID(clientID).
+		SetUsername(m.ResourceOpts.Username).
+		SetPassword(m.ResourceOpts.Password).
+		SetCleanSession(!m.ResourceOpts.FixedClientID).
+		SetAutoReconnect(false).
+		SetConnectRetry(false).
+		SetConnectTimeout(DEFAULT_CONNECT_TIMEOUT * time.Second)
+
+	// protocol version 3 for MQTT 3.1, 4 for MQTT 3.1.1
+	switch m.ResourceOpts.ProtocolVersion {
+	case PROTOCOL_VERSION_3_1:
+		opts.SetProtocolVersion(3)
+	default:
+		opts.SetProtocolVersion(4)
+	}
+
+	if m.ResourceOpts.SSL.SSL {
+		tlsConfig, err := common.BuildTLSConfig(m.ResourceOpts.SSL.VerificationMode, m.ResourceOpts.SSL.CACert, m.ResourceOpts.SSL.ClientCert, m.ResourceOpts.SSL.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		opts.SetTLSConfig(tlsConfig)
+	}
+
+	// connect
+	client := pahomqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(DEFAULT_CONNECT_TIMEOUT * time.Second) {
+		return nil, errors.New("connect to mqtt broker timeout")
+	}
+	if err := token.Error(); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// waitToken wait the token within query timeout
+func waitToken(token pahomqtt.Token) error {
+	if !token.WaitTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT) {
+		return errors.New("wait mqtt broker response timeout")
+	}
+	return token.Error()
+}
@@ -0,0 +1,126 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"sync"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	pahomqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type CommandExecutor struct {
+	client  pahomqtt.Client
+	command Action
+}
+
+func (c *CommandExecutor) publish() (common.RuntimeResult, error) {
+	var publishCommandArgs PublishCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &publishCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate mqtt publish action options
+	validate := validator.New()
+	if err := validate.Struct(publishCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	token := c.client.Publish(publishCommandArgs.Topic, byte(publishCommandArgs.QoS), publishCommandArgs.Retain, publishCommandArgs.Payload)
+	if err := waitToken(token); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": "Published 1 message to topic " + publishCommandArgs.Topic + "."},
+	}, nil
+}
+
+func (c *CommandExecutor) subscribe() (common.RuntimeResult, error) {
+	var subscribeCommandArgs SubscribeCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &subscribeCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate mqtt subscribe action options
+	validate := validator.New()
+	if err := validate.Struct(subscribeCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	count := subscribeCommandArgs.Count
+	if count <= 0 {
+		count = DEFAULT_SUBSCRIBE_COUNT
+	}
+	if count > MAX_SUBSCRIBE_COUNT {
+		count = MAX_SUBSCRIBE_COUNT
+	}
+	duration := time.Duration(subscribeCommandArgs.Duration) * time.Millisecond
+	if duration <= 0 {
+		duration = DEFAULT_SUBSCRIBE_DURATION * time.Millisecond
+	}
+	if duration > common.DEFAULT_QUERY_AND_EXEC_TIMEOUT {
+		duration = common.DEFAULT_QUERY_AND_EXEC_TIMEOUT
+	}
+
+	// collect messages until count or duration reached, the handler runs in paho goroutine
+	var lock sync.Mutex
+	rows := make([]map[string]interface{}, 0, count)
+	done := make(chan struct{})
+	finished := false
+	handler := func(client pahomqtt.Client, message pahomqtt.Message) {
+		lock.Lock()
+		defer lock.Unlock()
+		if finished || len(rows) >= count {
+			return
+		}
+		rows = append(rows, map[string]interface{}{
+			"topic":      message.Topic(),
+			"payload":    string(message.Payload()),
+			"qos":        message.Qos(),
+			"retained":   message.Retained(),
+			"messageID":  message.MessageID(),
+			"receivedAt": time.Now().UTC().Format(time.RFC3339Nano),
+		})
+		if len(rows) == count {
+			close(done)
+		}
+	}
+	token := c.client.Subscribe(subscribeCommandArgs.TopicFilter, byte(subscribeCommandArgs.QoS), handler)
+	if err := waitToken(token); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	timer := time.NewTimer(duration)
+	defer timer.Stop()
+	select {
+	case <-done:
+	case <-timer.C:
+	}
+	// stop collecting, the late arrived messages will be dropped
+	lock.Lock()
+	finished = true
+	lock.Unlock()
+	c.client.Unsubscribe(subscribeCommandArgs.TopicFilter).WaitTimeout(time.Second)
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{},
+	}, nil
+}
@@ -0,0 +1,104 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (m *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &m.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate mqtt options
+	validate := validator.New()
+	if err := validate.Struct(m.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (m *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &m.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate mqtt options
+	validate := validator.New()
+	if err := validate.Struct(m.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (m *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get mqtt connection, the CONNACK means the connection works
+	client, err := m.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	client.Disconnect(0)
+
+	return common.ConnectionResult{Success: true}, nil
+}
+
+// MQTT broker have no topic list, so there is no meta info
+func (m *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{},
+	}, nil
+}
+
+func (m *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get mqtt connection
+	client, err := m.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get mqtt connection: " + err.Error())
+	}
+	defer client.Disconnect(250)
+
+	// format mqtt action
+	if err := mapstructure.Decode(actionOptions, &m.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{client: client, command: m.ActionOpts}
+	switch m.ActionOpts.Commands {
+	case PUBLISH_COMMAND:
+		result, err = commandExecutor.publish()
+	case SUBSCRIBE_COMMAND:
+		result, err = commandExecutor.subscribe()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported mqtt command: "+m.ActionOpts.Commands)
+	}
+
+	return result, err
+}
@@ -0,0 +1,72 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package mqtt
+
+const (
+	PUBLISH_COMMAND   = "publish"
+	SUBSCRIBE_COMMAND = "subscribe"
+)
+
+const (
+	PROTOCOL_VERSION_3_1   = "3.1"
+	PROTOCOL_VERSION_3_1_1 = "3.1.1"
+)
+
+const (
+	DEFAULT_SUBSCRIBE_DURATION = 5000 // ms
+	DEFAULT_SUBSCRIBE_COUNT    = 10
+	MAX_SUBSCRIBE_COUNT        = 1000
+	DEFAULT_CONNECT_TIMEOUT    = 10 // second
+	DEFAULT_CLIENT_ID_PREFIX   = "illa-builder"
+	CLIENT_ID_SUFFIX_LENGTH    = 10
+)
+
+type Resource struct {
+	BrokerURL       string `validate:"required"`                       // like tcp://host:1883, ssl://host:8883, ws://host:8080/mqtt
+	ClientID        string `validate:"required_if=FixedClientID true"` // prefix of the random client id, a duplicate id kicks the connected client
+	FixedClientID   bool   // use the ClientID as is and keep the session, for persistent sessions
+	Username        string
+	Password        string
+	ProtocolVersion string `validate:"omitempty,oneof=3.1 3.1.1"`
+	SSL             SSLOptions
+}
+
+type SSLOptions struct {
+	SSL              bool
+	VerificationMode string `validate:"required_unless=SSL false,omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+type Action struct {
+	Commands    string                 `validate:"required,oneof=publish subscribe"`
+	CommandArgs map[string]interface{} `validate:"required"`
+}
+
+type PublishCommandArgs struct {
+	Topic   string `json:"topic" validate:"required"`
+	Payload string `json:"payload"`
+	QoS     int    `json:"qos" validate:"min=0,max=2"`
+	Retain  bool   `json:"retain"`
+}
+
+// SubscribeCommandArgs describe a bounded subscribe, it returns when Count messages were collected or Duration reached.
+type SubscribeCommandArgs struct {
+	TopicFilter string `json:"topicFilter" validate:"required"`
+	QoS         int    `json:"qos" validate:"min=0,max=2"`
+	Duration    int    `json:"duration"` // ms
+	Count       int    `json:"count"`
+}
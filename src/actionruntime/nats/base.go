@@ -0,0 +1,164 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/nats-io/nkeys"
+)
+
+func (n *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*natsgo.Conn, error) {
+	if err := mapstructure.Decode(resourceOptions, &n.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	// split servers
+	servers := make([]string, 0)
+	for _, server := range strings.Split(n.ResourceOpts.Servers, ",") {
+		server = strings.TrimSpace(server)
+		if server != "" {
+			servers = append(servers, server)
+		}
+	}
+	if len(servers) == 0 {
+		return nil, errors.New("no nats server address")
+	}
+
+	opts := []natsgo.Option{
+		natsgo.Name("illa-builder"),
+		natsgo.Timeout(DEFAULT_CONNECT_TIMEOUT * time.Second),
+		natsgo.NoReconnect(),
+	}
+
+	// auth
+	authOption, err := n.buildAuthOption()
+	if err != nil {
+		return nil, err
+	}
+	if authOption != nil {
+		opts = append(opts, authOption)
+	}
+
+	// tls
+	if n.ResourceOpts.SSL.SSL {
+		tlsConfig, err := common.BuildTLSConfig(n.ResourceOpts.SSL.VerificationMode, n.ResourceOpts.SSL.CACert, n.ResourceOpts.SSL.ClientCert, n.ResourceOpts.SSL.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		opts = append(opts, natsgo.Secure(tlsConfig))
+	}
+
+	return natsgo.Connect(strings.Join(servers, ","), opts...)
+}
+
+func (n *Connector) buildAuthOption() (natsgo.Option, error) {
+	switch n.ResourceOpts.AuthType {
+	case AUTH_TYPE_USER_PASSWORD:
+		return natsgo.UserInfo(n.ResourceOpts.Username, n.ResourceOpts.Password), nil
+	case AUTH_TYPE_TOKEN:
+		return natsgo.Token(n.ResourceOpts.Token), nil
+	case AUTH_TYPE_NKEY:
+		keyPair, err := nkeys.FromSeed([]byte(strings.TrimSpace(n.ResourceOpts.NKeySeed)))
+		if err != nil {
+			return nil, err
+		}
+		publicKey, err := keyPair.PublicKey()
+		if err != nil {
+			return nil, err
+		}
+		return natsgo.Nkey(publicKey, keyPair.Sign), nil
+	case AUTH_TYPE_CREDENTIALS:
+		userJWT, err := nkeys.ParseDecoratedJWT([]byte(n.ResourceOpts.Credentials))
+		if err != nil {
+			return nil, err
+		}
+		keyPair, err := nkeys.ParseDecoratedNKey([]byte(n.ResourceOpts.Credentials))
+		if err != nil {
+			return nil, err
+		}
+		return natsgo.UserJWT(
+			func() (string, error) { return userJWT, nil },
+			keyPair.Sign,
+		), nil
+	default:
+		return nil, nil
+	}
+}
+
+// listStreams return all streams with their consumers, like:
+// {"stream": {"subjects": ["orders.>"], "messages": 42, "consumers": {"worker": {"numPending": 2, "numAckPending": 0}}}}
+func listStreams(ctx context.Context, js jetstream.JetStream) (map[string]interface{}, error) {
+	streams := make(map[string]interface{})
+	streamLister := js.ListStreams(ctx)
+	for streamInfo := range streamLister.Info() {
+		streams[streamInfo.Config.Name] = map[string]interface{}{
+			"subjects":  streamInfo.Config.Subjects,
+			"messages":  streamInfo.State.Msgs,
+			"bytes":     streamInfo.State.Bytes,
+			"consumers": map[string]interface{}{},
+		}
+	}
+	if err := streamLister.Err(); err != nil {
+		return nil, err
+	}
+
+	// list consumers of each stream
+	for streamName, streamInMap := range streams {
+		stream, err := js.Stream(ctx, streamName)
+		if err != nil {
+			return nil, err
+		}
+		consumers := make(map[string]interface{})
+		consumerLister := stream.ListConsumers(ctx)
+		for consumerInfo := range consumerLister.Info() {
+			consumers[consumerInfo.Name] = map[string]interface{}{
+				"durable":       consumerInfo.Config.Durable != "",
+				"numPending":    consumerInfo.NumPending,
+				"numAckPending": consumerInfo.NumAckPending,
+			}
+		}
+		if err := consumerLister.Err(); err != nil {
+			return nil, err
+		}
+		streamInMap.(map[string]interface{})["consumers"] = consumers
+	}
+	return streams, nil
+}
+
+func buildHeaders(headers []map[string]string) natsgo.Header {
+	natsHeaders := natsgo.Header{}
+	for _, header := range headers {
+		if header["key"] != "" {
+			natsHeaders.Add(header["key"], header["value"])
+		}
+	}
+	return natsHeaders
+}
+
+func exportHeaders(headers natsgo.Header) map[string]interface{} {
+	headersInMap := make(map[string]interface{}, len(headers))
+	for key := range headers {
+		headersInMap[key] = headers.Get(key)
+	}
+	return headersInMap
+}
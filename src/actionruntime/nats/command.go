@@ -0,0 +1,222 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	natsgo "github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type CommandExecutor struct {
+	conn    *natsgo.Conn
+	command Action
+}
+
+func (c *CommandExecutor) publish() (common.RuntimeResult, error) {
+	var publishCommandArgs PublishCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &publishCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate nats publish action options
+	validate := validator.New()
+	if err := validate.Struct(publishCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	message := &natsgo.Msg{
+		Subject: publishCommandArgs.Subject,
+		Data:    []byte(publishCommandArgs.Payload),
+		Header:  buildHeaders(publishCommandArgs.Headers),
+	}
+	if err := c.conn.PublishMsg(message); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// make sure the message reached the server before the connection closed
+	if err := c.conn.FlushTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": "Published 1 message to subject " + publishCommandArgs.Subject + "."},
+	}, nil
+}
+
+func (c *CommandExecutor) request() (common.RuntimeResult, error) {
+	var requestCommandArgs RequestCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &requestCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate nats request action options
+	validate := validator.New()
+	if err := validate.Struct(requestCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	timeout := time.Duration(requestCommandArgs.Timeout) * time.Millisecond
+	if timeout <= 0 {
+		timeout = DEFAULT_REQUEST_TIMEOUT * time.Millisecond
+	}
+	if timeout > common.DEFAULT_QUERY_AND_EXEC_TIMEOUT {
+		timeout = common.DEFAULT_QUERY_AND_EXEC_TIMEOUT
+	}
+
+	message := &natsgo.Msg{
+		Subject: requestCommandArgs.Subject,
+		Data:    []byte(requestCommandArgs.Payload),
+		Header:  buildHeaders(requestCommandArgs.Headers),
+	}
+	reply, err := c.conn.RequestMsg(message, timeout)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows: []map[string]interface{}{
+			{
+				"subject": reply.Subject,
+				"payload": string(reply.Data),
+				"headers": exportHeaders(reply.Header),
+			},
+		},
+		Extra: map[string]interface{}{},
+	}, nil
+}
+
+func (c *CommandExecutor) jetStreamPublish() (common.RuntimeResult, error) {
+	var jetStreamPublishCommandArgs JetStreamPublishCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &jetStreamPublishCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate nats jetstream publish action options
+	validate := validator.New()
+	if err := validate.Struct(jetStreamPublishCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	js, err := jetstream.New(c.conn)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	message := &natsgo.Msg{
+		Subject: jetStreamPublishCommandArgs.Subject,
+		Data:    []byte(jetStreamPublishCommandArgs.Payload),
+		Header:  buildHeaders(jetStreamPublishCommandArgs.Headers),
+	}
+	publishOpts := make([]jetstream.PublishOpt, 0)
+	if jetStreamPublishCommandArgs.MsgID != "" {
+		publishOpts = append(publishOpts, jetstream.WithMsgID(jetStreamPublishCommandArgs.MsgID))
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	pubAck, err := js.PublishMsg(ctx, message, publishOpts...)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows: []map[string]interface{}{
+			{
+				"stream":    pubAck.Stream,
+				"sequence":  pubAck.Sequence,
+				"duplicate": pubAck.Duplicate,
+			},
+		},
+		Extra: map[string]interface{}{},
+	}, nil
+}
+
+func (c *CommandExecutor) jetStreamFetch() (common.RuntimeResult, error) {
+	var jetStreamFetchCommandArgs JetStreamFetchCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &jetStreamFetchCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate nats jetstream fetch action options
+	validate := validator.New()
+	if err := validate.Struct(jetStreamFetchCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	batch := jetStreamFetchCommandArgs.Batch
+	if batch <= 0 {
+		batch = DEFAULT_FETCH_BATCH
+	}
+	if batch > MAX_FETCH_BATCH {
+		batch = MAX_FETCH_BATCH
+	}
+	maxWait := time.Duration(jetStreamFetchCommandArgs.MaxWait) * time.Millisecond
+	if maxWait <= 0 {
+		maxWait = DEFAULT_FETCH_MAX_WAIT * time.Millisecond
+	}
+	if maxWait > common.DEFAULT_QUERY_AND_EXEC_TIMEOUT {
+		maxWait = common.DEFAULT_QUERY_AND_EXEC_TIMEOUT
+	}
+
+	// get the durable consumer
+	js, err := jetstream.New(c.conn)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	consumer, err := js.Consumer(ctx, jetStreamFetchCommandArgs.Stream, jetStreamFetchCommandArgs.Consumer)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// fetch messages
+	messageBatch, err := consumer.Fetch(batch, jetstream.FetchMaxWait(maxWait))
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	rows := make([]map[string]interface{}, 0, batch)
+	for message := range messageBatch.Messages() {
+		row := map[string]interface{}{
+			"subject": message.Subject(),
+			"payload": string(message.Data()),
+			"headers": exportHeaders(message.Headers()),
+		}
+		if metadata, errInGetMetadata := message.Metadata(); errInGetMetadata == nil {
+			row["streamSequence"] = metadata.Sequence.Stream
+			row["consumerSequence"] = metadata.Sequence.Consumer
+			row["numDelivered"] = metadata.NumDelivered
+			row["numPending"] = metadata.NumPending
+			row["timestamp"] = metadata.Timestamp
+		}
+		if jetStreamFetchCommandArgs.Ack {
+			if err := message.Ack(); err != nil {
+				return common.RuntimeResult{Success: false}, err
+			}
+		}
+		rows = append(rows, row)
+	}
+	if err := messageBatch.Error(); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{},
+	}, nil
+}
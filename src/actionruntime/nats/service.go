@@ -0,0 +1,133 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (n *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &n.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate nats options
+	validate := validator.New()
+	if err := validate.Struct(n.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (n *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &n.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate nats options
+	validate := validator.New()
+	if err := validate.Struct(n.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (n *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get nats connection
+	conn, err := n.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	// test nats connection by a round trip to server
+	if err := conn.FlushTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (n *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get nats connection
+	conn, err := n.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer conn.Close()
+
+	// list jetstream streams and consumers
+	js, err := jetstream.New(conn)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	streams, err := listStreams(ctx, js)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"streams": streams},
+	}, nil
+}
+
+func (n *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get nats connection
+	conn, err := n.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get nats connection: " + err.Error())
+	}
+	defer conn.Close()
+
+	// format nats action
+	if err := mapstructure.Decode(actionOptions, &n.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{conn: conn, command: n.ActionOpts}
+	switch n.ActionOpts.Commands {
+	case PUBLISH_COMMAND:
+		result, err = commandExecutor.publish()
+	case REQUEST_COMMAND:
+		result, err = commandExecutor.request()
+	case JETSTREAM_PUBLISH_COMMAND:
+		result, err = commandExecutor.jetStreamPublish()
+	case JETSTREAM_FETCH_COMMAND:
+		result, err = commandExecutor.jetStreamFetch()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported nats command: "+n.ActionOpts.Commands)
+	}
+
+	return result, err
+}
@@ -0,0 +1,90 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package nats
+
+const (
+	PUBLISH_COMMAND           = "publish"
+	REQUEST_COMMAND           = "request"
+	JETSTREAM_PUBLISH_COMMAND = "jetStreamPublish"
+	JETSTREAM_FETCH_COMMAND   = "jetStreamFetch"
+)
+
+const (
+	AUTH_TYPE_NONE          = "none"
+	AUTH_TYPE_USER_PASSWORD = "userPassword"
+	AUTH_TYPE_TOKEN         = "token"
+	AUTH_TYPE_NKEY          = "nkey"
+	AUTH_TYPE_CREDENTIALS   = "credentials"
+)
+
+const (
+	DEFAULT_REQUEST_TIMEOUT = 5000 // ms
+	DEFAULT_FETCH_MAX_WAIT  = 5000 // ms
+	DEFAULT_FETCH_BATCH     = 10
+	MAX_FETCH_BATCH         = 1000
+	DEFAULT_CONNECT_TIMEOUT = 10 // second
+)
+
+type Resource struct {
+	Servers     string `validate:"required"` // comma separated server url list, like "nats://host1:4222,nats://host2:4222"
+	AuthType    string `validate:"omitempty,oneof=none userPassword token nkey credentials"`
+	Username    string `validate:"required_if=AuthType userPassword"`
+	Password    string
+	Token       string `validate:"required_if=AuthType token"`
+	NKeySeed    string `validate:"required_if=AuthType nkey"`        // the user nkey seed, starts with "SU"
+	Credentials string `validate:"required_if=AuthType credentials"` // the content of .creds file, includes user JWT and nkey seed
+	SSL         SSLOptions
+}
+
+type SSLOptions struct {
+	SSL              bool
+	VerificationMode string `validate:"required_unless=SSL false,omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+type Action struct {
+	Commands    string                 `validate:"required,oneof=publish request jetStreamPublish jetStreamFetch"`
+	CommandArgs map[string]interface{} `validate:"required"`
+}
+
+type PublishCommandArgs struct {
+	Subject string              `json:"subject" validate:"required"`
+	Payload string              `json:"payload"`
+	Headers []map[string]string `json:"headers"`
+}
+
+type RequestCommandArgs struct {
+	Subject string              `json:"subject" validate:"required"`
+	Payload string              `json:"payload"`
+	Headers []map[string]string `json:"headers"`
+	Timeout int                 `json:"timeout"` // ms
+}
+
+type JetStreamPublishCommandArgs struct {
+	Subject string              `json:"subject" validate:"required"`
+	Payload string              `json:"payload"`
+	Headers []map[string]string `json:"headers"`
+	MsgID   string              `json:"msgID"` // for deduplication
+}
+
+type JetStreamFetchCommandArgs struct {
+	Stream   string `json:"stream" validate:"required"`
+	Consumer string `json:"consumer" validate:"required"` // durable consumer name
+	Batch    int    `json:"batch"`
+	MaxWait  int    `json:"maxWait"` // ms
+	Ack      bool   `json:"ack"`     // not acked messages will be redelivered after the AckWait of consumer
+}
@@ -0,0 +1,193 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo4j
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	neo4jdriver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+	"github.com/neo4j/neo4j-go-driver/v5/neo4j/dbtype"
+)
+
+const (
+	labelsCypherStr            = "CALL db.labels() YIELD label RETURN label AS name"
+	relationshipTypesCypherStr = "CALL db.relationshipTypes() YIELD relationshipType RETURN relationshipType AS name"
+	propertyKeysCypherStr      = "CALL db.propertyKeys() YIELD propertyKey RETURN propertyKey AS name"
+)
+
+func (n *Connector) getDriverWithOptions(resourceOptions map[string]interface{}) (neo4jdriver.DriverWithContext, error) {
+	if err := mapstructure.Decode(resourceOptions, &n.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	auth := neo4jdriver.NoAuth()
+	if n.ResourceOpts.Username != "" {
+		auth = neo4jdriver.BasicAuth(n.ResourceOpts.Username, n.ResourceOpts.Password, "")
+	}
+	return neo4jdriver.NewDriverWithContext(n.ResourceOpts.URL, auth, func(config *neo4jdriver.Config) {
+		config.SocketConnectTimeout = DEFAULT_CONNECT_TIMEOUT * time.Second
+	})
+}
+
+func (n *Connector) executeQueryConfigurations(readAccessMode bool) []neo4jdriver.ExecuteQueryConfigurationOption {
+	configurations := make([]neo4jdriver.ExecuteQueryConfigurationOption, 0)
+	if n.ResourceOpts.Database != "" {
+		configurations = append(configurations, neo4jdriver.ExecuteQueryWithDatabase(n.ResourceOpts.Database))
+	}
+	if readAccessMode {
+		configurations = append(configurations, neo4jdriver.ExecuteQueryWithReadersRouting())
+	}
+	return configurations
+}
+
+// listNames runs the db.labels() like procedures and collects the "name" column.
+func (n *Connector) listNames(ctx context.Context, driver neo4jdriver.DriverWithContext, cypher string) ([]string, error) {
+	result, err := neo4jdriver.ExecuteQuery(ctx, driver, cypher, nil, neo4jdriver.EagerResultTransformer, n.executeQueryConfigurations(true)...)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(result.Records))
+	for _, record := range result.Records {
+		name, _, err := neo4jdriver.GetRecordValue[string](record, "name")
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+// buildParameters converts the serialized args generated by the escaper into the named cypher parameters.
+func buildParameters(args []interface{}) map[string]interface{} {
+	parameters := make(map[string]interface{}, len(args))
+	for serial, arg := range args {
+		parameters[PARAMETER_NAME_PREFIX+strconv.Itoa(serial+1)] = arg
+	}
+	return parameters
+}
+
+// exportRecords maps the records into rows, the node, relationship and path values are exported as objects.
+// When a record contains only one node or relationship column (like "MATCH (n) RETURN n"), the entity is flattened into
+// the row, with the properties as columns and the "_elementId", "_labels" or "_type" as meta columns.
+func exportRecords(keys []string, records []*neo4jdriver.Record) []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, len(records))
+	for _, record := range records {
+		if len(keys) == 1 {
+			if row, flattened := flattenEntity(record.Values[0]); flattened {
+				rows = append(rows, row)
+				continue
+			}
+		}
+		row := make(map[string]interface{}, len(keys))
+		for i, key := range keys {
+			row[key] = exportValue(record.Values[i])
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func flattenEntity(value interface{}) (map[string]interface{}, bool) {
+	var row map[string]interface{}
+	switch entity := value.(type) {
+	case dbtype.Node:
+		row = exportProperties(entity.Props)
+		row["_elementId"] = entity.ElementId
+		row["_labels"] = entity.Labels
+	case dbtype.Relationship:
+		row = exportProperties(entity.Props)
+		row["_elementId"] = entity.ElementId
+		row["_type"] = entity.Type
+		row["_startElementId"] = entity.StartElementId
+		row["_endElementId"] = entity.EndElementId
+	default:
+		return nil, false
+	}
+	return row, true
+}
+
+func exportProperties(properties map[string]interface{}) map[string]interface{} {
+	exported := make(map[string]interface{}, len(properties))
+	for key, value := range properties {
+		exported[key] = exportValue(value)
+	}
+	return exported
+}
+
+func exportNode(node dbtype.Node) map[string]interface{} {
+	return map[string]interface{}{
+		"elementId":  node.ElementId,
+		"labels":     node.Labels,
+		"properties": exportProperties(node.Props),
+	}
+}
+
+func exportRelationship(relationship dbtype.Relationship) map[string]interface{} {
+	return map[string]interface{}{
+		"elementId":      relationship.ElementId,
+		"type":           relationship.Type,
+		"startElementId": relationship.StartElementId,
+		"endElementId":   relationship.EndElementId,
+		"properties":     exportProperties(relationship.Props),
+	}
+}
+
+func exportValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case dbtype.Node:
+		return exportNode(v)
+	case dbtype.Relationship:
+		return exportRelationship(v)
+	case dbtype.Path:
+		nodes := make([]interface{}, 0, len(v.Nodes))
+		for _, node := range v.Nodes {
+			nodes = append(nodes, exportNode(node))
+		}
+		relationships := make([]interface{}, 0, len(v.Relationships))
+		for _, relationship := range v.Relationships {
+			relationships = append(relationships, exportRelationship(relationship))
+		}
+		return map[string]interface{}{"nodes": nodes, "relationships": relationships}
+	case []interface{}:
+		exported := make([]interface{}, 0, len(v))
+		for _, item := range v {
+			exported = append(exported, exportValue(item))
+		}
+		return exported
+	case map[string]interface{}:
+		return exportProperties(v)
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	case dbtype.Date:
+		return v.Time().Format("2006-01-02")
+	case dbtype.LocalTime:
+		return v.Time().Format("15:04:05.999999999")
+	case dbtype.Time:
+		return v.Time().Format("15:04:05.999999999Z07:00")
+	case dbtype.LocalDateTime:
+		return v.Time().Format("2006-01-02T15:04:05.999999999")
+	case dbtype.Duration:
+		return v.String()
+	case dbtype.Point2D:
+		return map[string]interface{}{"srid": v.SpatialRefId, "x": v.X, "y": v.Y}
+	case dbtype.Point3D:
+		return map[string]interface{}{"srid": v.SpatialRefId, "x": v.X, "y": v.Y, "z": v.Z}
+	default:
+		return v
+	}
+}
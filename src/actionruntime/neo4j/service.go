@@ -0,0 +1,160 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo4j
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	parser_sql "github.com/illacloud/builder-backend/src/utils/parser/sql"
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+	"github.com/mitchellh/mapstructure"
+	neo4jdriver "github.com/neo4j/neo4j-go-driver/v5/neo4j"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	Action       Query
+}
+
+func (n *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &n.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate neo4j options
+	validate := validator.New()
+	if err := validate.Struct(n.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (n *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format cypher options
+	if err := mapstructure.Decode(actionOptions, &n.Action); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate neo4j options
+	validate := validator.New()
+	if err := validate.Struct(n.Action); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (n *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get neo4j driver
+	driver, err := n.getDriverWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	defer driver.Close(ctx)
+
+	// test neo4j connection
+	if err := driver.VerifyConnectivity(ctx); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (n *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get neo4j driver
+	driver, err := n.getDriverWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	defer driver.Close(ctx)
+
+	// list labels, relationship types and property keys
+	labels, err := n.listNames(ctx, driver, labelsCypherStr)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	relationshipTypes, err := n.listNames(ctx, driver, relationshipTypesCypherStr)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	propertyKeys, err := n.listNames(ctx, driver, propertyKeysCypherStr)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema: map[string]interface{}{
+			"labels":            labels,
+			"relationshipTypes": relationshipTypes,
+			"propertyKeys":      propertyKeys,
+		},
+	}, nil
+}
+
+func (n *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get neo4j driver
+	driver, err := n.getDriverWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get neo4j driver: " + err.Error())
+	}
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+	defer driver.Close(ctx)
+
+	// format query
+	if err := mapstructure.Decode(actionOptions, &n.Action); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// set context field
+	errInSetRawQuery := n.Action.SetRawQueryAndContext(rawActionOptions)
+	if errInSetRawQuery != nil {
+		return common.RuntimeResult{Success: false}, errInSetRawQuery
+	}
+
+	// run cypher query
+	queryResult := common.RuntimeResult{
+		Success: false,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{},
+	}
+	// the template variables are bound as "$param1", "$param2"... in safe mode, the cypherArgs is empty in unsafe mode
+	sqlEscaper := parser_sql.NewSQLEscaper(resourcelist.TYPE_NEO4J_ID)
+	escapedCypher, cypherArgs, errInEscapeCypher := sqlEscaper.EscapeSQLActionTemplate(n.Action.RawQuery, n.Action.Context, n.Action.IsSafeMode())
+	if errInEscapeCypher != nil {
+		return queryResult, errInEscapeCypher
+	}
+	result, err := neo4jdriver.ExecuteQuery(ctx, driver, escapedCypher, buildParameters(cypherArgs), neo4jdriver.EagerResultTransformer, n.executeQueryConfigurations(n.Action.IsReadAccessMode())...)
+	if err != nil {
+		return queryResult, err
+	}
+
+	queryResult.Success = true
+	queryResult.Rows = exportRecords(result.Keys, result.Records)
+	counters := result.Summary.Counters()
+	if counters.ContainsUpdates() {
+		queryResult.Extra["message"] = fmt.Sprintf("Created %d nodes, deleted %d nodes, created %d relationships, deleted %d relationships, set %d properties.",
+			counters.NodesCreated(), counters.NodesDeleted(), counters.RelationshipsCreated(), counters.RelationshipsDeleted(), counters.PropertiesSet())
+	}
+	return queryResult, nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package neo4j
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+)
+
+const (
+	FIELD_CONTEXT = "context"
+	FIELD_QUERY   = "query"
+)
+
+const (
+	ACCESS_MODE_READ  = "read"
+	ACCESS_MODE_WRITE = "write"
+)
+
+// PARAMETER_NAME_PREFIX is the name prefix of the cypher parameters generated in safe mode, like "$param1".
+const PARAMETER_NAME_PREFIX = "param"
+
+const DEFAULT_CONNECT_TIMEOUT = 10 // second
+
+type Resource struct {
+	URL      string `validate:"required"` // like "neo4j://host:7687", "bolt+s://host:7687", the "+s" and "+ssc" scheme enables TLS
+	Database string
+	Username string
+	Password string
+}
+
+type Query struct {
+	Mode       string `validate:"required,oneof=sql sql-safe"`
+	AccessMode string `validate:"omitempty,oneof=read write"`
+	Query      string
+	RawQuery   string
+	Context    map[string]interface{}
+}
+
+func (q *Query) IsSafeMode() bool {
+	return q.Mode == common.MODE_SQL_SAFE
+}
+
+func (q *Query) IsReadAccessMode() bool {
+	return q.AccessMode == ACCESS_MODE_READ
+}
+
+func (q *Query) SetRawQueryAndContext(rawTemplate map[string]interface{}) error {
+	queryRaw, hit := rawTemplate[FIELD_QUERY]
+	if !hit {
+		return errors.New("missing query field for SetRawQueryAndContext() in query")
+	}
+	queryAsserted, assertPass := queryRaw.(string)
+	if !assertPass {
+		return errors.New("query field assert failed in SetRawQueryAndContext() method")
+
+	}
+	q.RawQuery = queryAsserted
+	contextRaw, hit := rawTemplate[FIELD_CONTEXT]
+	if !hit {
+		return errors.New("missing context field SetRawQueryAndContext() in query")
+	}
+	contextAsserted, assertPass := contextRaw.(map[string]interface{})
+	if !assertPass {
+		return errors.New("context field assert failed in SetRawQueryAndContext() method")
+
+	}
+	q.Context = contextAsserted
+	return nil
+}
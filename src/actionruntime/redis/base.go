@@ -15,31 +15,204 @@
 package redis
 
 import (
+	"context"
 	"crypto/tls"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/mitchellh/mapstructure"
 )
 
-func (r *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*redis.Client, error) {
+func (r *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (redis.UniversalClient, error) {
 	if err := mapstructure.Decode(resourceOptions, &r.Resource); err != nil {
 		return nil, err
 	}
 
-	options := redis.Options{
-		Addr:     r.Resource.Host + ":" + r.Resource.Port,
-		Username: r.Resource.DatabaseUsername,
-		Password: r.Resource.DatabasePassword,
-		DB:       r.Resource.DatabaseIndex,
-	}
+	var tlsConfig *tls.Config
 	if r.Resource.SSL {
-		tlsConfig := tls.Config{
+		tlsConfig = &tls.Config{
 			MinVersion: tls.VersionTLS12,
 			ServerName: r.Resource.Host,
 		}
-		options.TLSConfig = &tlsConfig
+	}
+
+	switch r.Resource.Topology {
+	case TOPOLOGY_SENTINEL:
+		// the server name is the host of master which is discovered by sentinels
+		if tlsConfig != nil {
+			tlsConfig.ServerName = ""
+		}
+		rdb := redis.NewFailoverClient(&redis.FailoverOptions{
+			MasterName:       r.Resource.MasterName,
+			SentinelAddrs:    splitAddrs(r.Resource.SentinelAddrs),
+			SentinelUsername: r.Resource.SentinelUsername,
+			SentinelPassword: r.Resource.SentinelPassword,
+			Username:         r.Resource.DatabaseUsername,
+			Password:         r.Resource.DatabasePassword,
+			DB:               r.Resource.DatabaseIndex,
+			TLSConfig:        tlsConfig,
+		})
+		return rdb, nil
+	case TOPOLOGY_CLUSTER:
+		if tlsConfig != nil {
+			tlsConfig.ServerName = ""
+		}
+		rdb := redis.NewClusterClient(&redis.ClusterOptions{
+			Addrs:     splitAddrs(r.Resource.ClusterNodes),
+			Username:  r.Resource.DatabaseUsername,
+			Password:  r.Resource.DatabasePassword,
+			TLSConfig: tlsConfig,
+		})
+		return rdb, nil
+	}
+
+	options := redis.Options{
+		Addr:      r.Resource.Host + ":" + r.Resource.Port,
+		Username:  r.Resource.DatabaseUsername,
+		Password:  r.Resource.DatabasePassword,
+		DB:        r.Resource.DatabaseIndex,
+		TLSConfig: tlsConfig,
 	}
 	rdb := redis.NewClient(&options)
 
 	return rdb, nil
 }
+
+func splitAddrs(addrs string) []string {
+	splitted := make([]string, 0)
+	for _, addr := range strings.Split(addrs, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			splitted = append(splitted, addr)
+		}
+	}
+	return splitted
+}
+
+// scanKeys scans up to MAX_SCAN_KEYS keys, the keys of cluster are scanned on every master.
+func scanKeys(ctx context.Context, rdb redis.UniversalClient) ([]string, bool, error) {
+	var lock sync.Mutex
+	keys := make([]string, 0)
+	truncated := false
+	scanNode := func(ctx context.Context, node redis.Cmdable) error {
+		var cursor uint64
+		for {
+			batch, nextCursor, err := node.Scan(ctx, cursor, "*", SCAN_BATCH_SIZE).Result()
+			if err != nil {
+				return err
+			}
+			lock.Lock()
+			for _, key := range batch {
+				if len(keys) >= MAX_SCAN_KEYS {
+					truncated = true
+					break
+				}
+				keys = append(keys, key)
+			}
+			full := len(keys) >= MAX_SCAN_KEYS
+			lock.Unlock()
+			if nextCursor == 0 {
+				return nil
+			}
+			if full {
+				lock.Lock()
+				truncated = true
+				lock.Unlock()
+				return nil
+			}
+			cursor = nextCursor
+		}
+	}
+
+	var err error
+	if clusterClient, ok := rdb.(*redis.ClusterClient); ok {
+		err = clusterClient.ForEachMaster(ctx, func(ctx context.Context, client *redis.Client) error {
+			return scanNode(ctx, client)
+		})
+	} else {
+		err = scanNode(ctx, rdb)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	sort.Strings(keys)
+	return keys, truncated, nil
+}
+
+// describeKeys gets the type and TTL (in seconds, -1 for no expiration) of keys in a pipeline.
+func describeKeys(ctx context.Context, rdb redis.UniversalClient, keys []string) ([]map[string]interface{}, error) {
+	typeCmds := make([]*redis.StatusCmd, len(keys))
+	ttlCmds := make([]*redis.DurationCmd, len(keys))
+	if _, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, key := range keys {
+			typeCmds[i] = pipe.Type(ctx, key)
+			ttlCmds[i] = pipe.TTL(ctx, key)
+		}
+		return nil
+	}); err != nil && err != redis.Nil {
+		return nil, err
+	}
+
+	described := make([]map[string]interface{}, 0, len(keys))
+	for i, key := range keys {
+		ttl := int64(-1)
+		if duration, err := ttlCmds[i].Result(); err == nil && duration > 0 {
+			ttl = int64(duration.Seconds())
+		}
+		keyType, _ := typeCmds[i].Result()
+		described = append(described, map[string]interface{}{"key": key, "type": keyType, "ttl": ttl})
+	}
+	return described, nil
+}
+
+// splitCommandArgs splits the command line like redis-cli, the double quoted argument supports escapes like "\n" and
+// the single quoted argument is kept as is.
+func splitCommandArgs(line string) ([]interface{}, error) {
+	args := make([]interface{}, 0)
+	var current strings.Builder
+	inArg := false
+	quote := rune(0)
+	runes := []rune(line)
+	for i := 0; i < len(runes); i++ {
+		char := runes[i]
+		switch {
+		case quote == '"' && char == '\\' && i+1 < len(runes):
+			i++
+			switch runes[i] {
+			case 'n':
+				current.WriteRune('\n')
+			case 'r':
+				current.WriteRune('\r')
+			case 't':
+				current.WriteRune('\t')
+			default:
+				current.WriteRune(runes[i])
+			}
+		case quote != 0 && char == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(char)
+		case char == '"' || char == '\'':
+			quote = char
+			inArg = true
+		case char == ' ' || char == '\t':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(char)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, errors.New("unbalanced quotes in command: " + line)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
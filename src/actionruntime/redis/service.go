@@ -16,9 +16,11 @@ package redis
 
 import (
 	"context"
+	"errors"
 	"strings"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/go-redis/redis/v8"
 	"github.com/mitchellh/mapstructure"
 
 	"github.com/illacloud/builder-backend/src/actionruntime/common"
@@ -40,6 +42,9 @@ func (r *Connector) ValidateResourceOptions(resourceOptions map[string]interface
 	if err := validate.Struct(r.Resource); err != nil {
 		return common.ValidateResult{Valid: false}, err
 	}
+	if err := r.Resource.validateTopology(); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
 	return common.ValidateResult{Valid: true}, nil
 }
 
@@ -74,10 +79,29 @@ func (r *Connector) TestConnection(resourceOptions map[string]interface{}) (comm
 }
 
 func (r *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+
+	// get redis client
+	rdb, err := r.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer rdb.Close()
+
+	// browse keyspace by SCAN, with the type and TTL of keys
+	keys, truncated, err := scanKeys(ctx, rdb)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	described, err := describeKeys(ctx, rdb, keys)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
 
 	return common.MetaInfoResult{
 		Success: true,
-		Schema:  nil,
+		Schema:  map[string]interface{}{"keys": described, "truncated": truncated},
 	}, nil
 }
 
@@ -103,6 +127,9 @@ func (r *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		return common.RuntimeResult{Success: false}, err
 	}
 	redisCMD := strings.TrimSpace(r.Action.Query)
+	if r.Action.Mode == MODE_PIPELINE {
+		return runPipelineCommands(ctx, rdb, redisCMD)
+	}
 	redisCMDSlice := strings.Fields(redisCMD)
 	inputRedisCMDSlice := make([]interface{}, len(redisCMDSlice))
 	for i, v := range redisCMDSlice {
@@ -123,3 +150,52 @@ func (r *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 
 	return cmdResult, nil
 }
+
+// runPipelineCommands runs the commands of each line in a pipeline, the error of each command is returned in its row.
+func runPipelineCommands(ctx context.Context, rdb redis.UniversalClient, query string) (common.RuntimeResult, error) {
+	lines := make([]string, 0)
+	commands := make([][]interface{}, 0)
+	for _, line := range strings.Split(query, "\n") {
+		if line = strings.TrimSpace(line); line == "" {
+			continue
+		}
+		args, err := splitCommandArgs(line)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		lines = append(lines, line)
+		commands = append(commands, args)
+	}
+	if len(commands) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("empty redis command")
+	}
+
+	// run commands in pipeline
+	cmds := make([]*redis.Cmd, len(commands))
+	_, err := rdb.Pipelined(ctx, func(pipe redis.Pipeliner) error {
+		for i, args := range commands {
+			cmds[i] = pipe.Do(ctx, args...)
+		}
+		return nil
+	})
+	if err != nil && ctx.Err() != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	rows := make([]map[string]interface{}, 0, len(cmds))
+	failed := 0
+	for i, cmd := range cmds {
+		val, err := cmd.Result()
+		row := map[string]interface{}{"command": lines[i], "result": val, "error": ""}
+		if err != nil && err != redis.Nil {
+			row["error"] = err.Error()
+			failed++
+		}
+		rows = append(rows, row)
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+		Extra:   map[string]interface{}{"pipelined": true, "failed": failed},
+	}, nil
+}
@@ -14,16 +14,57 @@
 
 package redis
 
+import "errors"
+
+const (
+	TOPOLOGY_STANDALONE = "standalone"
+	TOPOLOGY_SENTINEL   = "sentinel"
+	TOPOLOGY_CLUSTER    = "cluster"
+)
+
+const (
+	MODE_SELECT   = "select"
+	MODE_RAW      = "raw"
+	MODE_PIPELINE = "pipeline"
+)
+
+const (
+	SCAN_BATCH_SIZE = 100
+	MAX_SCAN_KEYS   = 1000
+)
+
+// Options describe the Redis deployment, the Topology is "standalone" by default. The Host and Port are for the
+// standalone server, the sentinel topology discovers the master of MasterName by the SentinelAddrs, and the cluster
+// topology discovers the nodes by the ClusterNodes. The addresses are comma separated like "10.0.0.1:26379,10.0.0.2:26379".
 type Options struct {
-	Host             string `validate:"required"`
-	Port             string `validate:"required"`
+	Topology         string `validate:"omitempty,oneof=standalone sentinel cluster"`
+	Host             string
+	Port             string
+	MasterName       string `validate:"required_if=Topology sentinel"`
+	SentinelAddrs    string `validate:"required_if=Topology sentinel"`
+	SentinelUsername string
+	SentinelPassword string
+	ClusterNodes     string `validate:"required_if=Topology cluster"`
 	DatabaseIndex    int    `validate:"gte=0"`
 	DatabaseUsername string
 	DatabasePassword string
 	SSL              bool
 }
 
+// validateTopology validates the Host and Port of standalone topology, they are required when the Topology is empty.
+func (o *Options) validateTopology() error {
+	if (o.Topology == "" || o.Topology == TOPOLOGY_STANDALONE) && (o.Host == "" || o.Port == "") {
+		return errors.New("host and port are required for standalone redis")
+	}
+	if o.Topology == TOPOLOGY_CLUSTER && o.DatabaseIndex != 0 {
+		return errors.New("redis cluster only supports database index 0")
+	}
+	return nil
+}
+
+// Command is the Redis command, the select and raw mode run a single command split by whitespace. The pipeline mode runs
+// the commands of multiple lines in a pipeline, and the arguments can be quoted like `SET greeting "hello world"`.
 type Command struct {
-	Mode  string `validate:"required,oneof=select raw"`
+	Mode  string `validate:"required,oneof=select raw pipeline"`
 	Query string
 }
@@ -16,9 +16,11 @@ package s3
 
 import (
 	"context"
+	"strings"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
@@ -39,7 +41,8 @@ func (s *Connector) getConnectionWithOptions(resourceOptions map[string]interfac
 	if s.ResourceOpts.Endpoint {
 		customResolver := aws.EndpointResolverWithOptionsFunc(func(service, region string, options ...interface{}) (aws.Endpoint, error) {
 			return aws.Endpoint{
-				URL: s.ResourceOpts.BaseURL,
+				URL:           s.ResourceOpts.BaseURL,
+				SigningRegion: s.ResourceOpts.Region,
 			}, nil
 		})
 		cfg, err = config.LoadDefaultConfig(context.Background(),
@@ -57,26 +60,33 @@ func (s *Connector) getConnectionWithOptions(resourceOptions map[string]interfac
 	}
 
 	// create an S3 service client
-	s3Client := s3.NewFromConfig(cfg)
+	s3Client := s3.NewFromConfig(cfg, func(o *s3.Options) {
+		o.UsePathStyle = s.ResourceOpts.ForcePathStyle
+	})
 
 	return s3Client, nil
 }
 
-func presignGetObject(client *s3.Client, bucket, objectKey string, expiry time.Duration) (string, error) {
+// presignGetObject presigns the download of the object, the non-empty contentType and contentDisposition override
+// the headers of the response.
+func presignGetObject(client *s3.Client, bucket, objectKey, contentType, contentDisposition string, expiry time.Duration) (*v4.PresignedHTTPRequest, error) {
 	presignClient := s3.NewPresignClient(client, s3.WithPresignExpires(expiry))
 	params := s3.GetObjectInput{
 		Bucket: &bucket,
 		Key:    &objectKey,
 	}
-	output, err := presignClient.PresignGetObject(context.TODO(), &params)
-	if err != nil {
-		return "", err
+	if contentType != "" {
+		params.ResponseContentType = &contentType
 	}
-
-	return output.URL, nil
+	if contentDisposition != "" {
+		params.ResponseContentDisposition = &contentDisposition
+	}
+	return presignClient.PresignGetObject(context.TODO(), &params)
 }
 
-func presignPutObject(client *s3.Client, bucket, objectKey, ACL string, expiry time.Duration) (string, error) {
+// presignPutObject presigns the upload of the object, the non-empty contentType and contentDisposition are signed,
+// so the upload must send them in the signed headers.
+func presignPutObject(client *s3.Client, bucket, objectKey, ACL, contentType, contentDisposition string, expiry time.Duration) (*v4.PresignedHTTPRequest, error) {
 	presignClient := s3.NewPresignClient(client, s3.WithPresignExpires(expiry))
 	params := s3.PutObjectInput{
 		Bucket: &bucket,
@@ -84,11 +94,25 @@ func presignPutObject(client *s3.Client, bucket, objectKey, ACL string, expiry t
 	}
 	if ACL != "" {
 		params.ACL = types.ObjectCannedACL(ACL)
-
 	}
-	output, err := presignClient.PresignPutObject(context.TODO(), &params)
-	if err != nil {
-		return "", err
+	if contentType != "" {
+		params.ContentType = &contentType
+	}
+	if contentDisposition != "" {
+		params.ContentDisposition = &contentDisposition
+	}
+	return presignClient.PresignPutObject(context.TODO(), &params)
+}
+
+// exportSignedHeaders returns the signed headers which must be sent along with the presigned url, the host header
+// is sent by the client anyway.
+func exportSignedHeaders(request *v4.PresignedHTTPRequest) map[string]string {
+	signedHeaders := make(map[string]string, len(request.SignedHeader))
+	for name := range request.SignedHeader {
+		if strings.EqualFold(name, "host") {
+			continue
+		}
+		signedHeaders[name] = request.SignedHeader.Get(name)
 	}
-	return output.URL, nil
+	return signedHeaders
 }
@@ -15,14 +15,21 @@
 package s3
 
 import (
+	"bytes"
 	"context"
+	"encoding/base64"
 	"errors"
+	"net/url"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/illacloud/builder-backend/src/actionruntime/common"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
+	v4 "github.com/aws/aws-sdk-go-v2/aws/signer/v4"
 	"github.com/aws/aws-sdk-go-v2/service/s3"
+	"github.com/aws/aws-sdk-go-v2/service/s3/types"
 	"github.com/go-playground/validator/v10"
 	"github.com/mitchellh/mapstructure"
 )
@@ -71,8 +78,10 @@ func (c *CommandExecutor) listObjects(region string) (common.RuntimeResult, erro
 	for _, obj := range res.Contents {
 		objRes := map[string]interface{}{"objectKey": *obj.Key}
 		expiryDuration := time.Duration(listCommandArgs.Expiry) * time.Minute
-		signedURL, _ := presignGetObject(c.client, listCommandArgs.BucketName, *obj.Key,
-			expiryDuration)
+		signedURL := ""
+		if request, err := presignGetObject(c.client, listCommandArgs.BucketName, *obj.Key, "", "", expiryDuration); err == nil {
+			signedURL = request.URL
+		}
 		if listCommandArgs.SignedURL {
 			objRes["signedURL"] = signedURL
 			objRes["urlExpiryDate"] = time.Now().UTC().Add(expiryDuration).Format("2006.01.02 15:04:07.000 UTC")
@@ -114,11 +123,11 @@ func (c *CommandExecutor) readAnObject(region string) (common.RuntimeResult, err
 	urlObj := make(map[string]interface{}, 2)
 	urlObj["key"] = readCommandArgs.ObjectKey
 	expiryDuration := time.Duration(readCommandArgs.Expiry) * time.Minute
-	signedURL, err := presignGetObject(c.client, readCommandArgs.BucketName, readCommandArgs.ObjectKey,
-		expiryDuration)
+	request, err := presignGetObject(c.client, readCommandArgs.BucketName, readCommandArgs.ObjectKey, "", "", expiryDuration)
 	if err != nil {
 		return common.RuntimeResult{Success: false}, err
 	}
+	signedURL := request.URL
 	if readCommandArgs.SignedURL {
 		urlObj["url"] = signedURL
 	} else {
@@ -157,11 +166,11 @@ func (c *CommandExecutor) downloadAnObject(region string) (common.RuntimeResult,
 	urlObj := make(map[string]interface{}, 2)
 	urlObj["key"] = downloadCommandArgs.ObjectKey
 	expiryDuration := time.Duration(downloadCommandArgs.Expiry) * time.Minute
-	signedURL, err := presignGetObject(c.client, downloadCommandArgs.BucketName, downloadCommandArgs.ObjectKey,
-		expiryDuration)
+	request, err := presignGetObject(c.client, downloadCommandArgs.BucketName, downloadCommandArgs.ObjectKey, "", "", expiryDuration)
 	if err != nil {
 		return common.RuntimeResult{Success: false}, err
 	}
+	signedURL := request.URL
 	if downloadCommandArgs.SignedURL {
 		urlObj["url"] = signedURL
 	} else {
@@ -279,10 +288,11 @@ func (c *CommandExecutor) uploadAnObject(ACL string) (common.RuntimeResult, erro
 
 	// build put presigned url
 	expiryDuration := time.Duration(uploadCommandArgs.Expiry) * time.Minute
-	signedURL, err := presignPutObject(c.client, uploadCommandArgs.BucketName, uploadCommandArgs.ObjectKey, ACL, expiryDuration)
+	request, err := presignPutObject(c.client, uploadCommandArgs.BucketName, uploadCommandArgs.ObjectKey, ACL, "", "", expiryDuration)
 	if err != nil {
 		return common.RuntimeResult{Success: false}, err
 	}
+	signedURL := request.URL
 	urlObj := make(map[string]interface{}, 3)
 	urlObj["url"] = signedURL
 	urlObj["key"] = uploadCommandArgs.ObjectKey
@@ -321,7 +331,10 @@ func (c *CommandExecutor) uploadMultipleObjects(ACL string) (common.RuntimeResul
 	res := make([]map[string]interface{}, 0, batchN)
 	for i := 0; i < batchN; i++ {
 		expiryDuration := time.Duration(batchUploadCommandArgs.Expiry) * time.Minute
-		signedURL, _ := presignPutObject(c.client, batchUploadCommandArgs.BucketName, batchUploadCommandArgs.ObjectKeyList[i], ACL, expiryDuration)
+		signedURL := ""
+		if request, err := presignPutObject(c.client, batchUploadCommandArgs.BucketName, batchUploadCommandArgs.ObjectKeyList[i], ACL, "", "", expiryDuration); err == nil {
+			signedURL = request.URL
+		}
 		urlObj := make(map[string]interface{}, 3)
 		urlObj["url"] = signedURL
 		urlObj["key"] = batchUploadCommandArgs.ObjectKeyList[i]
@@ -335,3 +348,639 @@ func (c *CommandExecutor) uploadMultipleObjects(ACL string) (common.RuntimeResul
 		Extra:   nil,
 	}, nil
 }
+
+func (c *CommandExecutor) resolveBucketName(bucketName string) (string, error) {
+	if bucketName != "" {
+		return bucketName, nil
+	}
+	if c.bucket == "" {
+		return "", errors.New("no bucket name")
+	}
+	return c.bucket, nil
+}
+
+func presignExpiry(expiry int64) time.Duration {
+	if expiry == 0 {
+		expiry = DEFAULT_PRESIGN_EXPIRY
+	}
+	return time.Duration(expiry) * time.Minute
+}
+
+func (c *CommandExecutor) presignURL(ACL string) (common.RuntimeResult, error) {
+	var presignCommandArgs PresignCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &presignCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 presign action options
+	validate := validator.New()
+	if err := validate.Struct(presignCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(presignCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	expiryDuration := presignExpiry(presignCommandArgs.Expiry)
+	var request *v4.PresignedHTTPRequest
+	if presignCommandArgs.Method == PRESIGN_METHOD_GET {
+		request, err = presignGetObject(c.client, bucketName, presignCommandArgs.ObjectKey, presignCommandArgs.ContentType, presignCommandArgs.ContentDisposition, expiryDuration)
+	} else {
+		request, err = presignPutObject(c.client, bucketName, presignCommandArgs.ObjectKey, ACL, presignCommandArgs.ContentType, presignCommandArgs.ContentDisposition, expiryDuration)
+	}
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// the signed headers must be sent along with the url
+	return common.RuntimeResult{
+		Success: true,
+		Rows: []map[string]interface{}{{
+			"key":           presignCommandArgs.ObjectKey,
+			"method":        request.Method,
+			"url":           request.URL,
+			"headers":       exportSignedHeaders(request),
+			"urlExpiryDate": time.Now().UTC().Add(expiryDuration).Format(time.RFC3339),
+		}},
+		Extra: nil,
+	}, nil
+}
+
+func (c *CommandExecutor) createMultipartUpload(ACL string) (common.RuntimeResult, error) {
+	var createCommandArgs CreateMultipartUploadCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &createCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 createMultipartUpload action options
+	validate := validator.New()
+	if err := validate.Struct(createCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(createCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// build CreateMultipartUploadInput
+	params := s3.CreateMultipartUploadInput{
+		Bucket: &bucketName,
+		Key:    &createCommandArgs.ObjectKey,
+	}
+	if ACL != "" {
+		params.ACL = types.ObjectCannedACL(ACL)
+	}
+	if createCommandArgs.ContentType != "" {
+		params.ContentType = &createCommandArgs.ContentType
+	}
+	res, err := c.client.CreateMultipartUpload(context.TODO(), &params)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	uploadID := aws.ToString(res.UploadId)
+
+	// presign the part urls for browser-direct upload, the "ETag" response header of each part is needed to complete
+	expiryDuration := presignExpiry(createCommandArgs.Expiry)
+	presignClient := s3.NewPresignClient(c.client, s3.WithPresignExpires(expiryDuration))
+	parts := make([]map[string]interface{}, 0, createCommandArgs.PartCount)
+	for partNumber := int32(1); partNumber <= createCommandArgs.PartCount; partNumber++ {
+		request, err := presignClient.PresignUploadPart(context.TODO(), &s3.UploadPartInput{
+			Bucket:     &bucketName,
+			Key:        &createCommandArgs.ObjectKey,
+			UploadId:   &uploadID,
+			PartNumber: partNumber,
+		})
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		parts = append(parts, map[string]interface{}{"partNumber": partNumber, "url": request.URL})
+	}
+
+	row := map[string]interface{}{
+		"key":      createCommandArgs.ObjectKey,
+		"uploadId": uploadID,
+		"parts":    parts,
+	}
+	if createCommandArgs.PartCount > 0 {
+		row["urlExpiryDate"] = time.Now().UTC().Add(expiryDuration).Format(time.RFC3339)
+	}
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{row},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) completeMultipartUpload() (common.RuntimeResult, error) {
+	var completeCommandArgs CompleteMultipartUploadCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &completeCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 completeMultipartUpload action options
+	validate := validator.New()
+	if err := validate.Struct(completeCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(completeCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// the parts must be listed in ascending part number order
+	completedParts := make([]types.CompletedPart, 0, len(completeCommandArgs.Parts))
+	for _, part := range completeCommandArgs.Parts {
+		completedParts = append(completedParts, types.CompletedPart{
+			ETag:       aws.String(part.ETag),
+			PartNumber: part.PartNumber,
+		})
+	}
+	sort.Slice(completedParts, func(i, j int) bool {
+		return completedParts[i].PartNumber < completedParts[j].PartNumber
+	})
+
+	res, err := c.client.CompleteMultipartUpload(context.TODO(), &s3.CompleteMultipartUploadInput{
+		Bucket:          &bucketName,
+		Key:             &completeCommandArgs.ObjectKey,
+		UploadId:        &completeCommandArgs.UploadID,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completedParts},
+	})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows: []map[string]interface{}{{
+			"key":      completeCommandArgs.ObjectKey,
+			"eTag":     aws.ToString(res.ETag),
+			"location": aws.ToString(res.Location),
+		}},
+		Extra: nil,
+	}, nil
+}
+
+func (c *CommandExecutor) abortMultipartUpload() (common.RuntimeResult, error) {
+	var abortCommandArgs AbortMultipartUploadCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &abortCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 abortMultipartUpload action options
+	validate := validator.New()
+	if err := validate.Struct(abortCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(abortCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	if _, err := c.client.AbortMultipartUpload(context.TODO(), &s3.AbortMultipartUploadInput{
+		Bucket:   &bucketName,
+		Key:      &abortCommandArgs.ObjectKey,
+		UploadId: &abortCommandArgs.UploadID,
+	}); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"key": abortCommandArgs.ObjectKey, "uploadId": abortCommandArgs.UploadID}},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) multipartUpload(ACL string) (common.RuntimeResult, error) {
+	var uploadCommandArgs MultipartUploadCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &uploadCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 multipartUpload action options
+	validate := validator.New()
+	if err := validate.Struct(uploadCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(uploadCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	objectData, err := base64.StdEncoding.DecodeString(uploadCommandArgs.ObjectData)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("object data must be base64 encoded: " + err.Error())
+	}
+	partSize := uploadCommandArgs.PartSize
+	if partSize == 0 {
+		partSize = DEFAULT_PART_SIZE
+	}
+	partCount := (int64(len(objectData)) + partSize - 1) / partSize
+	if partCount == 0 {
+		partCount = 1
+	}
+	if partCount > MAX_PART_COUNT {
+		return common.RuntimeResult{Success: false}, errors.New("too many parts, please use a larger part size")
+	}
+
+	// build CreateMultipartUploadInput
+	params := s3.CreateMultipartUploadInput{
+		Bucket: &bucketName,
+		Key:    &uploadCommandArgs.ObjectKey,
+	}
+	if ACL != "" {
+		params.ACL = types.ObjectCannedACL(ACL)
+	}
+	if uploadCommandArgs.ContentType != "" {
+		params.ContentType = &uploadCommandArgs.ContentType
+	}
+	created, err := c.client.CreateMultipartUpload(context.TODO(), &params)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// upload the parts, and abort the upload on failure so the parts are not left behind
+	completedParts := make([]types.CompletedPart, 0, partCount)
+	for i := int64(0); i < partCount; i++ {
+		start := i * partSize
+		end := start + partSize
+		if end > int64(len(objectData)) {
+			end = int64(len(objectData))
+		}
+		partNumber := int32(i + 1)
+		uploaded, err := c.client.UploadPart(context.TODO(), &s3.UploadPartInput{
+			Bucket:        &bucketName,
+			Key:           &uploadCommandArgs.ObjectKey,
+			UploadId:      created.UploadId,
+			PartNumber:    partNumber,
+			Body:          bytes.NewReader(objectData[start:end]),
+			ContentLength: end - start,
+		})
+		if err != nil {
+			c.client.AbortMultipartUpload(context.TODO(), &s3.AbortMultipartUploadInput{
+				Bucket:   &bucketName,
+				Key:      &uploadCommandArgs.ObjectKey,
+				UploadId: created.UploadId,
+			})
+			return common.RuntimeResult{Success: false}, err
+		}
+		completedParts = append(completedParts, types.CompletedPart{ETag: uploaded.ETag, PartNumber: partNumber})
+	}
+
+	completed, err := c.client.CompleteMultipartUpload(context.TODO(), &s3.CompleteMultipartUploadInput{
+		Bucket:          &bucketName,
+		Key:             &uploadCommandArgs.ObjectKey,
+		UploadId:        created.UploadId,
+		MultipartUpload: &types.CompletedMultipartUpload{Parts: completedParts},
+	})
+	if err != nil {
+		c.client.AbortMultipartUpload(context.TODO(), &s3.AbortMultipartUploadInput{
+			Bucket:   &bucketName,
+			Key:      &uploadCommandArgs.ObjectKey,
+			UploadId: created.UploadId,
+		})
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows: []map[string]interface{}{{
+			"key":       uploadCommandArgs.ObjectKey,
+			"eTag":      aws.ToString(completed.ETag),
+			"size":      len(objectData),
+			"partCount": partCount,
+		}},
+		Extra: nil,
+	}, nil
+}
+
+// copySource builds the url encoded "bucket/key" source of CopyObject, "+" is escaped too since s3 decodes it as a space.
+func copySource(bucketName, objectKey string) string {
+	escapedPath := (&url.URL{Path: bucketName + "/" + objectKey}).EscapedPath()
+	return strings.ReplaceAll(escapedPath, "+", "%2B")
+}
+
+func (c *CommandExecutor) copyObject(ACL string, deleteSource bool) (common.RuntimeResult, error) {
+	var copyCommandArgs CopyCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &copyCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 copy action options
+	validate := validator.New()
+	if err := validate.Struct(copyCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(copyCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	sourceBucketName := copyCommandArgs.SourceBucketName
+	if sourceBucketName == "" {
+		sourceBucketName = bucketName
+	}
+	if sourceBucketName == bucketName && copyCommandArgs.SourceObjectKey == copyCommandArgs.ObjectKey {
+		return common.RuntimeResult{Success: false}, errors.New("source and target object are the same")
+	}
+
+	// build CopyObjectInput
+	params := s3.CopyObjectInput{
+		Bucket:     &bucketName,
+		Key:        &copyCommandArgs.ObjectKey,
+		CopySource: aws.String(copySource(sourceBucketName, copyCommandArgs.SourceObjectKey)),
+	}
+	if ACL != "" {
+		params.ACL = types.ObjectCannedACL(ACL)
+	}
+	res, err := c.client.CopyObject(context.TODO(), &params)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	row := map[string]interface{}{
+		"sourceBucketName": sourceBucketName,
+		"sourceObjectKey":  copyCommandArgs.SourceObjectKey,
+		"bucketName":       bucketName,
+		"objectKey":        copyCommandArgs.ObjectKey,
+	}
+	if res.CopyObjectResult != nil {
+		row["eTag"] = aws.ToString(res.CopyObjectResult.ETag)
+	}
+
+	// move deletes the source after the copy succeeded
+	if deleteSource {
+		if _, err := c.client.DeleteObject(context.TODO(), &s3.DeleteObjectInput{
+			Bucket: &sourceBucketName,
+			Key:    &copyCommandArgs.SourceObjectKey,
+		}); err != nil {
+			return common.RuntimeResult{Success: false}, errors.New("object copied but failed to delete the source: " + err.Error())
+		}
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{row},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) getObjectMetadata() (common.RuntimeResult, error) {
+	var objectCommandArgs ObjectCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &objectCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 getMetadata action options
+	validate := validator.New()
+	if err := validate.Struct(objectCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(objectCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	res, err := c.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+		Bucket: &bucketName,
+		Key:    &objectCommandArgs.ObjectKey,
+	})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	row := map[string]interface{}{
+		"objectKey":          objectCommandArgs.ObjectKey,
+		"contentType":        aws.ToString(res.ContentType),
+		"contentLength":      res.ContentLength,
+		"contentDisposition": aws.ToString(res.ContentDisposition),
+		"cacheControl":       aws.ToString(res.CacheControl),
+		"eTag":               aws.ToString(res.ETag),
+		"storageClass":       string(res.StorageClass),
+		"versionId":          aws.ToString(res.VersionId),
+		"metadata":           res.Metadata,
+	}
+	if res.LastModified != nil {
+		row["lastModified"] = res.LastModified.UTC().Format(time.RFC3339)
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{row},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) setObjectMetadata(ACL string) (common.RuntimeResult, error) {
+	var setMetadataCommandArgs SetMetadataCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &setMetadataCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 setMetadata action options
+	validate := validator.New()
+	if err := validate.Struct(setMetadataCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(setMetadataCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// s3 metadata is immutable, so copy the object onto itself and replace the metadata. The headers which are not
+	// given keep their current value, and the storage class, encryption and website redirect of the object are kept,
+	// the copy falls back to the bucket defaults for them otherwise.
+	current, err := c.client.HeadObject(context.TODO(), &s3.HeadObjectInput{
+		Bucket: &bucketName,
+		Key:    &setMetadataCommandArgs.ObjectKey,
+	})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	params := s3.CopyObjectInput{
+		Bucket:                  &bucketName,
+		Key:                     &setMetadataCommandArgs.ObjectKey,
+		CopySource:              aws.String(copySource(bucketName, setMetadataCommandArgs.ObjectKey)),
+		MetadataDirective:       types.MetadataDirectiveReplace,
+		Metadata:                setMetadataCommandArgs.Metadata,
+		ContentType:             current.ContentType,
+		CacheControl:            current.CacheControl,
+		ContentDisposition:      current.ContentDisposition,
+		ContentEncoding:         current.ContentEncoding,
+		ContentLanguage:         current.ContentLanguage,
+		Expires:                 current.Expires,
+		StorageClass:            current.StorageClass,
+		ServerSideEncryption:    current.ServerSideEncryption,
+		SSEKMSKeyId:             current.SSEKMSKeyId,
+		BucketKeyEnabled:        current.BucketKeyEnabled,
+		WebsiteRedirectLocation: current.WebsiteRedirectLocation,
+	}
+	if ACL != "" {
+		params.ACL = types.ObjectCannedACL(ACL)
+	}
+	if setMetadataCommandArgs.ContentType != "" {
+		params.ContentType = &setMetadataCommandArgs.ContentType
+	}
+	if setMetadataCommandArgs.CacheControl != "" {
+		params.CacheControl = &setMetadataCommandArgs.CacheControl
+	}
+	if setMetadataCommandArgs.ContentDisposition != "" {
+		params.ContentDisposition = &setMetadataCommandArgs.ContentDisposition
+	}
+	if _, err := c.client.CopyObject(context.TODO(), &params); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows: []map[string]interface{}{{
+			"objectKey":   setMetadataCommandArgs.ObjectKey,
+			"contentType": aws.ToString(params.ContentType),
+			"metadata":    setMetadataCommandArgs.Metadata,
+		}},
+		Extra: nil,
+	}, nil
+}
+
+func (c *CommandExecutor) getObjectTags() (common.RuntimeResult, error) {
+	var objectCommandArgs ObjectCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &objectCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 getTags action options
+	validate := validator.New()
+	if err := validate.Struct(objectCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(objectCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	res, err := c.client.GetObjectTagging(context.TODO(), &s3.GetObjectTaggingInput{
+		Bucket: &bucketName,
+		Key:    &objectCommandArgs.ObjectKey,
+	})
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	tags := make(map[string]string, len(res.TagSet))
+	for _, tag := range res.TagSet {
+		tags[aws.ToString(tag.Key)] = aws.ToString(tag.Value)
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"objectKey": objectCommandArgs.ObjectKey, "tags": tags}},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) setObjectTags() (common.RuntimeResult, error) {
+	var setTagsCommandArgs SetTagsCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &setTagsCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 setTags action options
+	validate := validator.New()
+	if err := validate.Struct(setTagsCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(setTagsCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// the tag set replaces all the tags of the object
+	tagSet := make([]types.Tag, 0, len(setTagsCommandArgs.Tags))
+	for key, value := range setTagsCommandArgs.Tags {
+		tagSet = append(tagSet, types.Tag{Key: aws.String(key), Value: aws.String(value)})
+	}
+	sort.Slice(tagSet, func(i, j int) bool {
+		return aws.ToString(tagSet[i].Key) < aws.ToString(tagSet[j].Key)
+	})
+	if _, err := c.client.PutObjectTagging(context.TODO(), &s3.PutObjectTaggingInput{
+		Bucket:  &bucketName,
+		Key:     &setTagsCommandArgs.ObjectKey,
+		Tagging: &types.Tagging{TagSet: tagSet},
+	}); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"objectKey": setTagsCommandArgs.ObjectKey, "tags": setTagsCommandArgs.Tags}},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) deleteObjectsByPrefix() (common.RuntimeResult, error) {
+	var deletePrefixCommandArgs DeletePrefixCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &deletePrefixCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate s3 deletePrefix action options
+	validate := validator.New()
+	if err := validate.Struct(deletePrefixCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	bucketName, err := c.resolveBucketName(deletePrefixCommandArgs.BucketName)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	limit := deletePrefixCommandArgs.Limit
+	if limit == 0 {
+		limit = DEFAULT_DELETE_LIMIT
+	}
+
+	// list the objects page by page, and delete each page with one DeleteObjects request
+	matched := 0
+	deletedKeys := make([]string, 0)
+	failures := make([]map[string]interface{}, 0)
+	hasMore := false
+	paginator := s3.NewListObjectsV2Paginator(c.client, &s3.ListObjectsV2Input{
+		Bucket:  &bucketName,
+		Prefix:  &deletePrefixCommandArgs.Prefix,
+		MaxKeys: MAX_DELETE_BATCH_SIZE,
+	})
+	for paginator.HasMorePages() {
+		if matched >= limit {
+			hasMore = true
+			break
+		}
+		page, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		objects := make([]types.ObjectIdentifier, 0, len(page.Contents))
+		for _, object := range page.Contents {
+			if matched >= limit {
+				hasMore = true
+				break
+			}
+			objects = append(objects, types.ObjectIdentifier{Key: object.Key})
+			matched++
+		}
+		if len(objects) == 0 {
+			continue
+		}
+		if deletePrefixCommandArgs.DryRun {
+			for _, object := range objects {
+				deletedKeys = append(deletedKeys, aws.ToString(object.Key))
+			}
+			continue
+		}
+		res, err := c.client.DeleteObjects(context.TODO(), &s3.DeleteObjectsInput{
+			Bucket: &bucketName,
+			Delete: &types.Delete{Objects: objects},
+		})
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		for _, deleted := range res.Deleted {
+			deletedKeys = append(deletedKeys, aws.ToString(deleted.Key))
+		}
+		for _, failure := range res.Errors {
+			failures = append(failures, map[string]interface{}{
+				"objectKey": aws.ToString(failure.Key),
+				"code":      aws.ToString(failure.Code),
+				"message":   aws.ToString(failure.Message),
+			})
+		}
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"count": matched, "success": len(deletedKeys), "deleted": deletedKeys, "failure": failures}},
+		Extra:   map[string]interface{}{"dryRun": deletePrefixCommandArgs.DryRun, "hasMore": hasMore},
+	}, nil
+}
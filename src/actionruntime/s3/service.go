@@ -128,6 +128,30 @@ func (s *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		result, err = commandExecutor.uploadAnObject(s.ResourceOpts.ACL)
 	case BATCH_UPLOAD_COMMAND:
 		result, err = commandExecutor.uploadMultipleObjects(s.ResourceOpts.ACL)
+	case PRESIGN_COMMAND:
+		result, err = commandExecutor.presignURL(s.ResourceOpts.ACL)
+	case CREATE_MULTIPART_UPLOAD_COMMAND:
+		result, err = commandExecutor.createMultipartUpload(s.ResourceOpts.ACL)
+	case COMPLETE_MULTIPART_UPLOAD_COMMAND:
+		result, err = commandExecutor.completeMultipartUpload()
+	case ABORT_MULTIPART_UPLOAD_COMMAND:
+		result, err = commandExecutor.abortMultipartUpload()
+	case MULTIPART_UPLOAD_COMMAND:
+		result, err = commandExecutor.multipartUpload(s.ResourceOpts.ACL)
+	case COPY_COMMAND:
+		result, err = commandExecutor.copyObject(s.ResourceOpts.ACL, false)
+	case MOVE_COMMAND:
+		result, err = commandExecutor.copyObject(s.ResourceOpts.ACL, true)
+	case GET_METADATA_COMMAND:
+		result, err = commandExecutor.getObjectMetadata()
+	case SET_METADATA_COMMAND:
+		result, err = commandExecutor.setObjectMetadata(s.ResourceOpts.ACL)
+	case GET_TAGS_COMMAND:
+		result, err = commandExecutor.getObjectTags()
+	case SET_TAGS_COMMAND:
+		result, err = commandExecutor.setObjectTags()
+	case DELETE_PREFIX_COMMAND:
+		result, err = commandExecutor.deleteObjectsByPrefix()
 	}
 
 	return result, err
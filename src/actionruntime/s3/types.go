@@ -22,6 +22,32 @@ const (
 	BATCH_DELETE_COMMAND = "batchDelete"
 	UPLOAD_COMMAND       = "upload"
 	BATCH_UPLOAD_COMMAND = "batchUpload"
+
+	PRESIGN_COMMAND                   = "presign"
+	CREATE_MULTIPART_UPLOAD_COMMAND   = "createMultipartUpload"
+	COMPLETE_MULTIPART_UPLOAD_COMMAND = "completeMultipartUpload"
+	ABORT_MULTIPART_UPLOAD_COMMAND    = "abortMultipartUpload"
+	MULTIPART_UPLOAD_COMMAND          = "multipartUpload"
+	COPY_COMMAND                      = "copy"
+	MOVE_COMMAND                      = "move"
+	GET_METADATA_COMMAND              = "getMetadata"
+	SET_METADATA_COMMAND              = "setMetadata"
+	GET_TAGS_COMMAND                  = "getTags"
+	SET_TAGS_COMMAND                  = "setTags"
+	DELETE_PREFIX_COMMAND             = "deletePrefix"
+)
+
+const (
+	PRESIGN_METHOD_GET = "get"
+	PRESIGN_METHOD_PUT = "put"
+
+	DEFAULT_PRESIGN_EXPIRY = 15          // in minutes
+	MAX_PRESIGN_EXPIRY     = 7 * 24 * 60 // in minutes, the limit of signature version 4
+	MIN_PART_SIZE          = 5 << 20
+	DEFAULT_PART_SIZE      = 8 << 20
+	MAX_PART_COUNT         = 10000
+	MAX_DELETE_BATCH_SIZE  = 1000
+	DEFAULT_DELETE_LIMIT   = 10000
 )
 
 type Resource struct {
@@ -30,6 +56,7 @@ type Resource struct {
 	ACL             string
 	Endpoint        bool
 	BaseURL         string `validate:"required_unless=Endpoint false"`
+	ForcePathStyle  bool   // for MinIO and other S3 compatible stores
 	AccessKeyID     string `validate:"required"`
 	SecretAccessKey string `validate:"required"`
 }
@@ -45,7 +72,7 @@ var ACLs = map[string]bool{
 }
 
 type Action struct {
-	Commands    string                 `validate:"required,oneof=list read download delete batchDelete upload batchUpload"`
+	Commands    string                 `validate:"required,oneof=list read download delete batchDelete upload batchUpload presign createMultipartUpload completeMultipartUpload abortMultipartUpload multipartUpload copy move getMetadata setMetadata getTags setTags deletePrefix"`
 	CommandArgs map[string]interface{} `validate:"required"`
 }
 
@@ -85,3 +112,85 @@ type BatchUploadCommandArgs struct {
 	ObjectKeyList  []string `json:"objectKeyList" validate:"required,gt=0,dive,required"`
 	ObjectDataList []string `json:"objectDataList"`
 }
+
+type PresignCommandArgs struct {
+	BucketName         string `json:"bucketName"`
+	ObjectKey          string `json:"objectKey" validate:"required"`
+	Method             string `json:"method" validate:"required,oneof=get put"`
+	Expiry             int64  `json:"expiry" validate:"gte=0,lte=10080"`
+	ContentType        string `json:"contentType"`
+	ContentDisposition string `json:"contentDisposition"`
+}
+
+// CreateMultipartUploadCommandArgs starts a multipart upload, when PartCount is set the presigned URLs of the parts
+// are returned so the browser can upload the parts directly.
+type CreateMultipartUploadCommandArgs struct {
+	BucketName  string `json:"bucketName"`
+	ObjectKey   string `json:"objectKey" validate:"required"`
+	ContentType string `json:"contentType"`
+	PartCount   int32  `json:"partCount" validate:"gte=0,lte=10000"`
+	Expiry      int64  `json:"expiry" validate:"gte=0,lte=10080"`
+}
+
+type CompletedPartArgs struct {
+	PartNumber int32  `json:"partNumber" validate:"gte=1,lte=10000"`
+	ETag       string `json:"eTag" validate:"required"`
+}
+
+type CompleteMultipartUploadCommandArgs struct {
+	BucketName string              `json:"bucketName"`
+	ObjectKey  string              `json:"objectKey" validate:"required"`
+	UploadID   string              `json:"uploadId" validate:"required"`
+	Parts      []CompletedPartArgs `json:"parts" validate:"required,gt=0,dive"`
+}
+
+type AbortMultipartUploadCommandArgs struct {
+	BucketName string `json:"bucketName"`
+	ObjectKey  string `json:"objectKey" validate:"required"`
+	UploadID   string `json:"uploadId" validate:"required"`
+}
+
+type MultipartUploadCommandArgs struct {
+	BucketName  string `json:"bucketName"`
+	ObjectKey   string `json:"objectKey" validate:"required"`
+	ContentType string `json:"contentType"`
+	ObjectData  string `json:"objectData" validate:"required"` // base64 encoded
+	PartSize    int64  `json:"partSize" validate:"omitempty,gte=5242880"`
+}
+
+// CopyCommandArgs copies the source object to the object, the source bucket defaults to the target bucket.
+type CopyCommandArgs struct {
+	SourceBucketName string `json:"sourceBucketName"`
+	SourceObjectKey  string `json:"sourceObjectKey" validate:"required"`
+	BucketName       string `json:"bucketName"`
+	ObjectKey        string `json:"objectKey" validate:"required"`
+}
+
+type ObjectCommandArgs struct {
+	BucketName string `json:"bucketName"`
+	ObjectKey  string `json:"objectKey" validate:"required"`
+}
+
+type SetMetadataCommandArgs struct {
+	BucketName         string            `json:"bucketName"`
+	ObjectKey          string            `json:"objectKey" validate:"required"`
+	Metadata           map[string]string `json:"metadata"`
+	ContentType        string            `json:"contentType"`
+	CacheControl       string            `json:"cacheControl"`
+	ContentDisposition string            `json:"contentDisposition"`
+}
+
+type SetTagsCommandArgs struct {
+	BucketName string            `json:"bucketName"`
+	ObjectKey  string            `json:"objectKey" validate:"required"`
+	Tags       map[string]string `json:"tags" validate:"lte=10"`
+}
+
+// DeletePrefixCommandArgs deletes the objects under the Prefix, up to Limit objects in one run. The DryRun only
+// lists the objects which would be deleted.
+type DeletePrefixCommandArgs struct {
+	BucketName string `json:"bucketName"`
+	Prefix     string `json:"prefix" validate:"required"`
+	Limit      int    `json:"limit" validate:"gte=0"`
+	DryRun     bool   `json:"dryRun"`
+}
@@ -0,0 +1,340 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/jlaffaye/ftp"
+	"github.com/mitchellh/mapstructure"
+	pkgsftp "github.com/pkg/sftp"
+	"golang.org/x/crypto/ssh"
+)
+
+type FileInfo struct {
+	Name         string
+	Size         int64
+	IsDir        bool
+	ModifiedTime time.Time
+}
+
+// FileClient is the common operations of the SFTP and FTP clients, the paths are resolved by the caller.
+type FileClient interface {
+	List(dir string) ([]FileInfo, error)
+	Stat(filePath string) (FileInfo, error)
+	Read(filePath string) ([]byte, error)
+	Write(filePath string, data []byte) error
+	Remove(filePath string) error
+	Close() error
+}
+
+func (s *Connector) getClientWithOptions(resourceOptions map[string]interface{}) (FileClient, error) {
+	if err := mapstructure.Decode(resourceOptions, &s.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	if s.ResourceOpts.Protocol == PROTOCOL_SFTP {
+		return newSFTPClient(&s.ResourceOpts)
+	}
+	return newFTPClient(&s.ResourceOpts)
+}
+
+// resolvePath joins the object key with the root directory, the absolute object key is kept as it is. The path out of
+// the root directory is rejected, include the absolute object key and the object key climbing up with "..".
+func resolvePath(rootDirectory string, objectKey string) (string, error) {
+	if rootDirectory == "" {
+		return path.Clean(objectKey), nil
+	}
+	root := path.Clean(rootDirectory)
+	resolvedPath := path.Clean(objectKey)
+	if !path.IsAbs(resolvedPath) {
+		resolvedPath = path.Join(root, resolvedPath)
+	}
+	if !isInDirectory(root, resolvedPath) {
+		return "", errors.New("object key " + objectKey + " is out of the root directory")
+	}
+	return resolvedPath, nil
+}
+
+// isInDirectory reports whether the cleaned path is the directory or under it.
+func isInDirectory(directory string, cleanedPath string) bool {
+	switch directory {
+	case cleanedPath:
+		return true
+	case "/":
+		return path.IsAbs(cleanedPath)
+	case ".":
+		return !path.IsAbs(cleanedPath) && cleanedPath != ".." && !strings.HasPrefix(cleanedPath, "../")
+	}
+	return strings.HasPrefix(cleanedPath, directory+"/")
+}
+
+func exportFileInfo(objectKey string, fileInfo FileInfo) map[string]interface{} {
+	return map[string]interface{}{
+		"objectKey":    objectKey,
+		"name":         fileInfo.Name,
+		"size":         fileInfo.Size,
+		"isDir":        fileInfo.IsDir,
+		"modifiedTime": fileInfo.ModifiedTime.UTC().Format(time.RFC3339),
+	}
+}
+
+type sftpClient struct {
+	sshClient  *ssh.Client
+	sftpClient *pkgsftp.Client
+}
+
+func newSFTPClient(resource *Resource) (*sftpClient, error) {
+	// build auth method
+	authMethods := make([]ssh.AuthMethod, 0)
+	if resource.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if resource.Passphrase != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(resource.PrivateKey), []byte(resource.Passphrase))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(resource.PrivateKey))
+		}
+		if err != nil {
+			return nil, errors.New("invalid private key: " + err.Error())
+		}
+		authMethods = append(authMethods, ssh.PublicKeys(signer))
+	}
+	if resource.Password != "" {
+		authMethods = append(authMethods, ssh.Password(resource.Password))
+	}
+	hostKeyCallback, err := buildHostKeyCallback(resource.HostKey, resource.SkipHostKeyVerification)
+	if err != nil {
+		return nil, err
+	}
+
+	// connect
+	port := resource.Port
+	if port == 0 {
+		port = DEFAULT_SFTP_PORT
+	}
+	sshClient, err := ssh.Dial("tcp", net.JoinHostPort(resource.Host, strconv.Itoa(port)), &ssh.ClientConfig{
+		User:            resource.Username,
+		Auth:            authMethods,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         DEFAULT_CONNECT_TIMEOUT * time.Second,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client, err := pkgsftp.NewClient(sshClient)
+	if err != nil {
+		sshClient.Close()
+		return nil, err
+	}
+	return &sftpClient{sshClient: sshClient, sftpClient: client}, nil
+}
+
+func buildHostKeyCallback(hostKey string, skipVerification bool) (ssh.HostKeyCallback, error) {
+	if skipVerification {
+		return ssh.InsecureIgnoreHostKey(), nil
+	}
+	hostKey = strings.TrimSpace(hostKey)
+	if hostKey == "" {
+		return nil, errors.New("host key is required for sftp, or skip the host key verification explicitly")
+	}
+	if strings.HasPrefix(hostKey, "SHA256:") {
+		return func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+			if ssh.FingerprintSHA256(key) != hostKey {
+				return errors.New("host key mismatch, got " + ssh.FingerprintSHA256(key))
+			}
+			return nil
+		}, nil
+	}
+	publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(hostKey))
+	if err != nil {
+		return nil, errors.New("invalid host key: " + err.Error())
+	}
+	return ssh.FixedHostKey(publicKey), nil
+}
+
+func (c *sftpClient) List(dir string) ([]FileInfo, error) {
+	entries, err := c.sftpClient.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	fileInfos := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		fileInfos = append(fileInfos, FileInfo{Name: entry.Name(), Size: entry.Size(), IsDir: entry.IsDir(), ModifiedTime: entry.ModTime()})
+	}
+	return fileInfos, nil
+}
+
+func (c *sftpClient) Stat(filePath string) (FileInfo, error) {
+	entry, err := c.sftpClient.Stat(filePath)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	return FileInfo{Name: entry.Name(), Size: entry.Size(), IsDir: entry.IsDir(), ModifiedTime: entry.ModTime()}, nil
+}
+
+func (c *sftpClient) Read(filePath string) ([]byte, error) {
+	file, err := c.sftpClient.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return io.ReadAll(io.LimitReader(file, MAX_FILE_SIZE))
+}
+
+func (c *sftpClient) Write(filePath string, data []byte) error {
+	if err := c.sftpClient.MkdirAll(path.Dir(filePath)); err != nil {
+		return err
+	}
+	file, err := c.sftpClient.Create(filePath)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+func (c *sftpClient) Remove(filePath string) error {
+	return c.sftpClient.Remove(filePath)
+}
+
+func (c *sftpClient) Close() error {
+	c.sftpClient.Close()
+	return c.sshClient.Close()
+}
+
+type ftpClient struct {
+	conn *ftp.ServerConn
+}
+
+func newFTPClient(resource *Resource) (*ftpClient, error) {
+	port := resource.Port
+	if port == 0 {
+		port = DEFAULT_FTP_PORT
+		if resource.Protocol == PROTOCOL_FTPS && resource.ImplicitTLS {
+			port = DEFAULT_FTPS_IMPLICIT_PORT
+		}
+	}
+	dialOptions := []ftp.DialOption{ftp.DialWithTimeout(DEFAULT_CONNECT_TIMEOUT * time.Second)}
+	if resource.Protocol == PROTOCOL_FTPS {
+		tlsConfig, err := common.BuildTLSConfig(resource.SSL.VerificationMode, resource.SSL.CACert, resource.SSL.ClientCert, resource.SSL.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ServerName = resource.Host
+		if resource.ImplicitTLS {
+			dialOptions = append(dialOptions, ftp.DialWithTLS(tlsConfig))
+		} else {
+			dialOptions = append(dialOptions, ftp.DialWithExplicitTLS(tlsConfig))
+		}
+	}
+
+	// connect and login, the anonymous login is used when the username is empty
+	conn, err := ftp.Dial(net.JoinHostPort(resource.Host, strconv.Itoa(port)), dialOptions...)
+	if err != nil {
+		return nil, err
+	}
+	username := resource.Username
+	if username == "" {
+		username = "anonymous"
+	}
+	if err := conn.Login(username, resource.Password); err != nil {
+		conn.Quit()
+		return nil, err
+	}
+	return &ftpClient{conn: conn}, nil
+}
+
+func (c *ftpClient) List(dir string) ([]FileInfo, error) {
+	entries, err := c.conn.List(dir)
+	if err != nil {
+		return nil, err
+	}
+	fileInfos := make([]FileInfo, 0, len(entries))
+	for _, entry := range entries {
+		if entry.Name == "." || entry.Name == ".." {
+			continue
+		}
+		fileInfos = append(fileInfos, FileInfo{Name: entry.Name, Size: int64(entry.Size), IsDir: entry.Type == ftp.EntryTypeFolder, ModifiedTime: entry.Time})
+	}
+	return fileInfos, nil
+}
+
+func (c *ftpClient) Stat(filePath string) (FileInfo, error) {
+	size, err := c.conn.FileSize(filePath)
+	if err != nil {
+		return FileInfo{}, err
+	}
+	fileInfo := FileInfo{Name: path.Base(filePath), Size: size}
+	if modifiedTime, err := c.conn.GetTime(filePath); err == nil {
+		fileInfo.ModifiedTime = modifiedTime
+	}
+	return fileInfo, nil
+}
+
+func (c *ftpClient) Read(filePath string) ([]byte, error) {
+	resp, err := c.conn.Retr(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Close()
+	return io.ReadAll(io.LimitReader(resp, MAX_FILE_SIZE))
+}
+
+func (c *ftpClient) Write(filePath string, data []byte) error {
+	// create the parent directories, the error is ignored since the directory may already exist
+	dir := path.Dir(filePath)
+	if dir != "." && dir != "/" {
+		current := ""
+		if path.IsAbs(dir) {
+			current = "/"
+		}
+		for _, segment := range strings.Split(strings.Trim(dir, "/"), "/") {
+			current = path.Join(current, segment)
+			c.conn.MakeDir(current)
+		}
+	}
+	return c.conn.Stor(filePath, bytes.NewReader(data))
+}
+
+func (c *ftpClient) Remove(filePath string) error {
+	return c.conn.Delete(filePath)
+}
+
+func (c *ftpClient) Close() error {
+	return c.conn.Quit()
+}
+
+func checkFileSize(fileInfo FileInfo) error {
+	if fileInfo.IsDir {
+		return errors.New(fileInfo.Name + " is a directory")
+	}
+	if fileInfo.Size > MAX_FILE_SIZE {
+		return fmt.Errorf("file size %d exceeds the %d bytes limit", fileInfo.Size, MAX_FILE_SIZE)
+	}
+	return nil
+}
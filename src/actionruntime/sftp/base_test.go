@@ -0,0 +1,65 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolvePath(t *testing.T) {
+	testCases := []struct {
+		name          string
+		rootDirectory string
+		objectKey     string
+		resolvedPath  string
+	}{
+		{name: "no root directory", rootDirectory: "", objectKey: "/etc/passwd", resolvedPath: "/etc/passwd"},
+		{name: "relative key", rootDirectory: "/data", objectKey: "reports/a.csv", resolvedPath: "/data/reports/a.csv"},
+		{name: "root directory itself", rootDirectory: "/data/", objectKey: ".", resolvedPath: "/data"},
+		{name: "absolute key in root directory", rootDirectory: "/data", objectKey: "/data/a.csv", resolvedPath: "/data/a.csv"},
+		{name: "dot dot in root directory", rootDirectory: "/data", objectKey: "reports/../a.csv", resolvedPath: "/data/a.csv"},
+		{name: "relative root directory", rootDirectory: "upload", objectKey: "a.csv", resolvedPath: "upload/a.csv"},
+		{name: "filesystem root", rootDirectory: "/", objectKey: "etc/hosts", resolvedPath: "/etc/hosts"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			resolvedPath, err := resolvePath(testCase.rootDirectory, testCase.objectKey)
+			assert.Nil(t, err)
+			assert.Equal(t, testCase.resolvedPath, resolvedPath)
+		})
+	}
+}
+
+func TestResolvePathRejectsPathOutOfRootDirectory(t *testing.T) {
+	testCases := []struct {
+		name          string
+		rootDirectory string
+		objectKey     string
+	}{
+		{name: "absolute key", rootDirectory: "/data", objectKey: "/etc/passwd"},
+		{name: "climb up", rootDirectory: "/data", objectKey: "../../etc/passwd"},
+		{name: "sibling with the same prefix", rootDirectory: "/data", objectKey: "../data-backup/a.csv"},
+		{name: "climb up from relative root directory", rootDirectory: "upload", objectKey: "../.ssh/authorized_keys"},
+		{name: "climb up from current directory", rootDirectory: ".", objectKey: "../a.csv"},
+	}
+	for _, testCase := range testCases {
+		t.Run(testCase.name, func(t *testing.T) {
+			_, err := resolvePath(testCase.rootDirectory, testCase.objectKey)
+			assert.NotNil(t, err)
+		})
+	}
+}
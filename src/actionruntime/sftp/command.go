@@ -0,0 +1,264 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp
+
+import (
+	"encoding/base64"
+	"errors"
+	"path"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type CommandExecutor struct {
+	client        FileClient
+	command       Action
+	rootDirectory string
+}
+
+func (c *CommandExecutor) listFiles() (common.RuntimeResult, error) {
+	var listCommandArgs ListCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &listCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate sftp list action options
+	validate := validator.New()
+	if err := validate.Struct(listCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if listCommandArgs.Directory == "" {
+		listCommandArgs.Directory = "."
+	}
+	if listCommandArgs.MaxKeys <= 0 {
+		listCommandArgs.MaxKeys = DEFAULT_MAX_KEYS
+	}
+
+	directory, err := resolvePath(c.rootDirectory, listCommandArgs.Directory)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	fileInfos, err := c.client.List(directory)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	listFileRes := make([]map[string]interface{}, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		if len(listFileRes) >= listCommandArgs.MaxKeys {
+			break
+		}
+		listFileRes = append(listFileRes, exportFileInfo(path.Join(listCommandArgs.Directory, fileInfo.Name), fileInfo))
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    listFileRes,
+		Extra:   nil,
+	}, nil
+}
+
+// readFile returns the file info and content, the file larger than MAX_FILE_SIZE is rejected.
+func (c *CommandExecutor) readFile() (map[string]interface{}, []byte, error) {
+	var readCommandArgs BaseCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &readCommandArgs); err != nil {
+		return nil, nil, err
+	}
+	// validate sftp read action options
+	validate := validator.New()
+	if err := validate.Struct(readCommandArgs); err != nil {
+		return nil, nil, err
+	}
+
+	filePath, err := resolvePath(c.rootDirectory, readCommandArgs.ObjectKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileInfo, err := c.client.Stat(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := checkFileSize(fileInfo); err != nil {
+		return nil, nil, err
+	}
+	content, err := c.client.Read(filePath)
+	if err != nil {
+		return nil, nil, err
+	}
+	fileInfo.Size = int64(len(content))
+	return exportFileInfo(readCommandArgs.ObjectKey, fileInfo), content, nil
+}
+
+func (c *CommandExecutor) readAFile() (common.RuntimeResult, error) {
+	fileObj, content, err := c.readFile()
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	fileObj["objectData"] = string(content)
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{fileObj},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) downloadAFile() (common.RuntimeResult, error) {
+	fileObj, content, err := c.readFile()
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	fileObj["objectData"] = base64.StdEncoding.EncodeToString(content)
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{fileObj},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) deleteAFile() (common.RuntimeResult, error) {
+	var deleteCommandArgs BaseCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &deleteCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate sftp delete action options
+	validate := validator.New()
+	if err := validate.Struct(deleteCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	filePath, err := resolvePath(c.rootDirectory, deleteCommandArgs.ObjectKey)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if err := c.client.Remove(filePath); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"objectKey": deleteCommandArgs.ObjectKey}},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) deleteMultipleFiles() (common.RuntimeResult, error) {
+	var batchDeleteCommandArgs BatchDeleteCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &batchDeleteCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate sftp batchDelete action options
+	validate := validator.New()
+	if err := validate.Struct(batchDeleteCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	batchN := len(batchDeleteCommandArgs.ObjectKeyList)
+	failedKeys := make([]string, 0, batchN)
+	successN := 0
+	for i := 0; i < batchN; i++ {
+		filePath, err := resolvePath(c.rootDirectory, batchDeleteCommandArgs.ObjectKeyList[i])
+		if err != nil {
+			failedKeys = append(failedKeys, batchDeleteCommandArgs.ObjectKeyList[i])
+			continue
+		}
+		if err := c.client.Remove(filePath); err != nil {
+			failedKeys = append(failedKeys, batchDeleteCommandArgs.ObjectKeyList[i])
+			continue
+		}
+		successN += 1
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"count": batchN, "success": successN, "failure": failedKeys}},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) uploadAFile() (common.RuntimeResult, error) {
+	var uploadCommandArgs UploadCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &uploadCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate sftp upload action options
+	validate := validator.New()
+	if err := validate.Struct(uploadCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	content, err := base64.StdEncoding.DecodeString(uploadCommandArgs.ObjectData)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("object data is not base64 encoded: " + err.Error())
+	}
+	filePath, err := resolvePath(c.rootDirectory, uploadCommandArgs.ObjectKey)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if err := c.client.Write(filePath, content); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"objectKey": uploadCommandArgs.ObjectKey, "size": len(content)}},
+		Extra:   nil,
+	}, nil
+}
+
+func (c *CommandExecutor) uploadMultipleFiles() (common.RuntimeResult, error) {
+	var batchUploadCommandArgs BatchUploadCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &batchUploadCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate sftp upload action options
+	validate := validator.New()
+	if err := validate.Struct(batchUploadCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	batchN := len(batchUploadCommandArgs.ObjectKeyList)
+	if len(batchUploadCommandArgs.ObjectKeyList) != len(batchUploadCommandArgs.ObjectDataList) {
+		return common.RuntimeResult{Success: false}, errors.New("mismatch between object keys and object data")
+	}
+
+	failedKeys := make([]string, 0, batchN)
+	successN := 0
+	for i := 0; i < batchN; i++ {
+		content, err := base64.StdEncoding.DecodeString(batchUploadCommandArgs.ObjectDataList[i])
+		if err != nil {
+			failedKeys = append(failedKeys, batchUploadCommandArgs.ObjectKeyList[i])
+			continue
+		}
+		filePath, err := resolvePath(c.rootDirectory, batchUploadCommandArgs.ObjectKeyList[i])
+		if err != nil {
+			failedKeys = append(failedKeys, batchUploadCommandArgs.ObjectKeyList[i])
+			continue
+		}
+		if err := c.client.Write(filePath, content); err != nil {
+			failedKeys = append(failedKeys, batchUploadCommandArgs.ObjectKeyList[i])
+			continue
+		}
+		successN += 1
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"count": batchN, "success": successN, "failure": failedKeys}},
+		Extra:   nil,
+	}, nil
+}
@@ -0,0 +1,142 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (s *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &s.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate sftp options
+	validate := validator.New()
+	if err := validate.Struct(s.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	if s.ResourceOpts.Protocol == PROTOCOL_SFTP && s.ResourceOpts.Password == "" && s.ResourceOpts.PrivateKey == "" {
+		return common.ValidateResult{Valid: false}, errors.New("password or private key is required for sftp")
+	}
+	if s.ResourceOpts.Protocol == PROTOCOL_SFTP && s.ResourceOpts.HostKey == "" && !s.ResourceOpts.SkipHostKeyVerification {
+		return common.ValidateResult{Valid: false}, errors.New("host key is required for sftp, or skip the host key verification explicitly")
+	}
+
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (s *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &s.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate sftp options
+	validate := validator.New()
+	if err := validate.Struct(s.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (s *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get sftp client, the client is logged in after connected
+	client, err := s.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer client.Close()
+
+	// test the root directory
+	rootDirectory, _ := resolvePath(s.ResourceOpts.RootDirectory, ".")
+	if _, err := client.List(rootDirectory); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (s *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get sftp client
+	client, err := s.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer client.Close()
+
+	// list the root directory
+	rootDirectory, _ := resolvePath(s.ResourceOpts.RootDirectory, ".")
+	fileInfos, err := client.List(rootDirectory)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	files := make([]map[string]interface{}, 0, len(fileInfos))
+	for _, fileInfo := range fileInfos {
+		files = append(files, exportFileInfo(fileInfo.Name, fileInfo))
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"files": files},
+	}, nil
+}
+
+func (s *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get sftp client
+	client, err := s.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get " + s.ResourceOpts.Protocol + " connection: " + err.Error())
+	}
+	defer client.Close()
+
+	// format sftp action
+	if err := mapstructure.Decode(actionOptions, &s.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{client: client, command: s.ActionOpts, rootDirectory: s.ResourceOpts.RootDirectory}
+	switch s.ActionOpts.Commands {
+	case LIST_COMMAND:
+		result, err = commandExecutor.listFiles()
+	case READ_COMMAND:
+		result, err = commandExecutor.readAFile()
+	case DOWNLOAD_COMMAND:
+		result, err = commandExecutor.downloadAFile()
+	case DELETE_COMMAND:
+		result, err = commandExecutor.deleteAFile()
+	case BATCH_DELETE_COMMAND:
+		result, err = commandExecutor.deleteMultipleFiles()
+	case UPLOAD_COMMAND:
+		result, err = commandExecutor.uploadAFile()
+	case BATCH_UPLOAD_COMMAND:
+		result, err = commandExecutor.uploadMultipleFiles()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported sftp command: "+s.ActionOpts.Commands)
+	}
+
+	return result, err
+}
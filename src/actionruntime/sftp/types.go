@@ -0,0 +1,99 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sftp
+
+const (
+	LIST_COMMAND         = "list"
+	READ_COMMAND         = "read"
+	DOWNLOAD_COMMAND     = "download"
+	DELETE_COMMAND       = "delete"
+	BATCH_DELETE_COMMAND = "batchDelete"
+	UPLOAD_COMMAND       = "upload"
+	BATCH_UPLOAD_COMMAND = "batchUpload"
+)
+
+const (
+	PROTOCOL_SFTP = "sftp"
+	PROTOCOL_FTP  = "ftp"
+	PROTOCOL_FTPS = "ftps"
+)
+
+const (
+	DEFAULT_SFTP_PORT          = 22
+	DEFAULT_FTP_PORT           = 21
+	DEFAULT_FTPS_IMPLICIT_PORT = 990
+	DEFAULT_CONNECT_TIMEOUT    = 10 // second
+	DEFAULT_MAX_KEYS           = 100
+	MAX_FILE_SIZE              = 32 << 20 // 32 MiB, the file content is returned inline
+)
+
+// Resource describe a SFTP, FTP or FTPS server.
+// For SFTP, the PrivateKey takes precedence over the Password, and the HostKey is the expected server public key in
+// authorized_keys format (like "ssh-ed25519 AAAA...") or its SHA256 fingerprint (like "SHA256:..."), it is required
+// unless SkipHostKeyVerification is set.
+// For FTPS, the explicit TLS (AUTH TLS) is used by default, set ImplicitTLS for the servers which only accept TLS on connect.
+type Resource struct {
+	Protocol                string `validate:"required,oneof=sftp ftp ftps"`
+	Host                    string `validate:"required"`
+	Port                    int
+	Username                string
+	Password                string
+	PrivateKey              string
+	Passphrase              string
+	HostKey                 string
+	SkipHostKeyVerification bool
+	RootDirectory           string
+	ImplicitTLS             bool
+	SSL                     SSLOptions
+}
+
+type SSLOptions struct {
+	VerificationMode string `validate:"omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+type Action struct {
+	Commands    string                 `validate:"required,oneof=list read download delete batchDelete upload batchUpload"`
+	CommandArgs map[string]interface{} `validate:"required"`
+}
+
+// The command args mirror the s3 connector, the object key is the file path relative to the root directory of the resource.
+// The "read" command returns the file content as text, and the "download" command returns base64 encoded content,
+// the object data of "upload" and "batchUpload" is base64 encoded.
+
+type ListCommandArgs struct {
+	Directory string `json:"directory"`
+	MaxKeys   int    `json:"maxKeys"`
+}
+
+type BaseCommandArgs struct {
+	ObjectKey string `json:"objectKey" validate:"required"`
+}
+
+type BatchDeleteCommandArgs struct {
+	ObjectKeyList []string `json:"objectKeyList" validate:"required,gt=0,dive,required"`
+}
+
+type UploadCommandArgs struct {
+	ObjectKey  string `json:"objectKey" validate:"required"`
+	ObjectData string `json:"objectData"`
+}
+
+type BatchUploadCommandArgs struct {
+	ObjectKeyList  []string `json:"objectKeyList" validate:"required,gt=0,dive,required"`
+	ObjectDataList []string `json:"objectDataList"`
+}
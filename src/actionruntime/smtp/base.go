@@ -15,21 +15,187 @@
 package smtp
 
 import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/smtp"
 	"os"
 	"strconv"
+	"strings"
 
 	"github.com/mitchellh/mapstructure"
 	"gopkg.in/gomail.v2"
 )
 
-func (s *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*gomail.Dialer, error) {
+func (s *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*smtp.Client, error) {
 	if err := mapstructure.Decode(resourceOptions, &s.ResourceOpts); err != nil {
 		return nil, err
 	}
+	return dialSMTP(s.ResourceOpts)
+}
+
+// dialSMTP dials the server with the security mode of the resource and authenticates.
+func dialSMTP(resource Resource) (*smtp.Client, error) {
+	address := net.JoinHostPort(resource.Host, strconv.Itoa(resource.Port))
+	conn, err := net.DialTimeout("tcp", address, DIAL_TIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig := &tls.Config{ServerName: resource.Host}
+	if resource.IsImplicitTLS() {
+		conn = tls.Client(conn, tlsConfig)
+	}
+	smtpClient, err := smtp.NewClient(conn, resource.Host)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+
+	// upgrade the connection
+	switch resource.Security {
+	case SECURITY_STARTTLS:
+		if ok, _ := smtpClient.Extension("STARTTLS"); !ok {
+			smtpClient.Close()
+			return nil, errors.New("smtp server does not support STARTTLS")
+		}
+		if err := smtpClient.StartTLS(tlsConfig); err != nil {
+			smtpClient.Close()
+			return nil, err
+		}
+	case "", SECURITY_AUTO:
+		if resource.IsImplicitTLS() {
+			break
+		}
+		if ok, _ := smtpClient.Extension("STARTTLS"); ok {
+			if err := smtpClient.StartTLS(tlsConfig); err != nil {
+				smtpClient.Close()
+				return nil, err
+			}
+		}
+	}
+
+	// authenticate
+	var auth smtp.Auth
+	if resource.Authentication == AUTHENTICATION_OAUTH2 {
+		auth = &xoauth2Auth{username: resource.Username, accessToken: resource.AccessToken, host: resource.Host}
+	} else if resource.Username != "" {
+		_, mechanisms := smtpClient.Extension("AUTH")
+		if strings.Contains(mechanisms, "CRAM-MD5") {
+			auth = smtp.CRAMMD5Auth(resource.Username, resource.Password)
+		} else if strings.Contains(mechanisms, "LOGIN") && !strings.Contains(mechanisms, "PLAIN") {
+			auth = &loginAuth{username: resource.Username, password: resource.Password, host: resource.Host}
+		} else {
+			auth = smtp.PlainAuth("", resource.Username, resource.Password, resource.Host)
+		}
+	}
+	if auth != nil {
+		if err := smtpClient.Auth(auth); err != nil {
+			smtpClient.Close()
+			return nil, err
+		}
+	}
+	return smtpClient, nil
+}
+
+// sendMessage sends the message with a new connection, the envelope recipients include the Bcc addresses.
+func sendMessage(resource Resource, message *gomail.Message) error {
+	sender := gomail.SendFunc(func(from string, to []string, msg io.WriterTo) error {
+		return sendEnvelope(resource, from, to, msg)
+	})
+	return gomail.Send(sender, message)
+}
+
+// sendEnvelope sends the rendered message to the envelope recipients with a new connection.
+func sendEnvelope(resource Resource, from string, to []string, msg io.WriterTo) error {
+	smtpClient, err := dialSMTP(resource)
+	if err != nil {
+		return err
+	}
+	defer smtpClient.Close()
+
+	if err := smtpClient.Mail(from); err != nil {
+		return err
+	}
+	for _, address := range to {
+		if err := smtpClient.Rcpt(address); err != nil {
+			return err
+		}
+	}
+	writer, err := smtpClient.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := msg.WriteTo(writer); err != nil {
+		writer.Close()
+		return err
+	}
+	if err := writer.Close(); err != nil {
+		return err
+	}
+	return smtpClient.Quit()
+}
+
+// isLocalhost allows the plain text credentials to a local relay, same as net/smtp does.
+func isLocalhost(host string) bool {
+	return host == "localhost" || host == "127.0.0.1" || host == "::1"
+}
+
+// xoauth2Auth implements the SASL XOAUTH2 mechanism used by Gmail and Office 365.
+type xoauth2Auth struct {
+	username    string
+	accessToken string
+	host        string
+}
 
-	smtpDialer := gomail.NewDialer(s.ResourceOpts.Host, s.ResourceOpts.Port, s.ResourceOpts.Username, s.ResourceOpts.Password)
+func (a *xoauth2Auth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "XOAUTH2", []byte("user=" + a.username + "\x01auth=Bearer " + a.accessToken + "\x01\x01"), nil
+}
 
-	return smtpDialer, nil
+// Next responds the error challenge with empty response, then the server fails the authentication with the error.
+func (a *xoauth2Auth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if more {
+		return []byte{}, nil
+	}
+	return nil, nil
+}
+
+// loginAuth implements the LOGIN mechanism which is not in net/smtp, some servers like Office 365 only offer it.
+type loginAuth struct {
+	username string
+	password string
+	host     string
+}
+
+func (a *loginAuth) Start(server *smtp.ServerInfo) (string, []byte, error) {
+	if !server.TLS && !isLocalhost(server.Name) {
+		return "", nil, errors.New("unencrypted connection")
+	}
+	if server.Name != a.host {
+		return "", nil, errors.New("wrong host name")
+	}
+	return "LOGIN", nil, nil
+}
+
+func (a *loginAuth) Next(fromServer []byte, more bool) ([]byte, error) {
+	if !more {
+		return nil, nil
+	}
+	switch strings.ToLower(strings.TrimSpace(string(fromServer))) {
+	case "username:":
+		return []byte(a.username), nil
+	case "password:":
+		return []byte(a.password), nil
+	default:
+		return nil, fmt.Errorf("unexpected server challenge: %s", fromServer)
+	}
 }
 
 func attachSizeLimiter(contentLength int64) bool {
@@ -0,0 +1,393 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/mapstructure"
+	redis "github.com/redis/go-redis/v9"
+	"gopkg.in/gomail.v2"
+)
+
+const (
+	SEND_QUEUE_KEY              = "smtp_send_queue"
+	SEND_QUEUE_PROCESSING_KEY   = "smtp_send_queue_processing"
+	SEND_QUEUE_RETRY_KEY        = "smtp_send_queue_retry"
+	SEND_QUEUE_EMAIL_KEY_PREFIX = "smtp_send_queue_email:"
+	SEND_QUEUE_LEASE_KEY_PREFIX = "smtp_send_queue_lease:"
+	DELIVERY_STATUS_KEY_PREFIX  = "smtp_delivery_status:"
+)
+
+// DeliveryStatus describe a queued email, it is stored in redis under the team and resource which queued the email,
+// and expires DELIVERY_STATUS_EXPIRY after the last update.
+type DeliveryStatus struct {
+	MessageID   string
+	Status      string
+	Attempts    int
+	MaxAttempts int
+	LastError   string
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+func (s *DeliveryStatus) ExportToMap() map[string]interface{} {
+	return map[string]interface{}{
+		"messageID":   s.MessageID,
+		"status":      s.Status,
+		"attempts":    s.Attempts,
+		"maxAttempts": s.MaxAttempts,
+		"lastError":   s.LastError,
+		"createdAt":   s.CreatedAt,
+		"updatedAt":   s.UpdatedAt,
+	}
+}
+
+// queuedEmail is the email stored in redis, the message is rendered into raw bytes with its envelope, so the workers
+// of any instance can send it. Only the team and resource of the email are stored, the credentials are loaded on
+// each attempt.
+type queuedEmail struct {
+	MessageID  string
+	TeamID     int
+	ResourceID int
+	From       string
+	To         []string
+	Message    []byte
+}
+
+// ResourceLoader loads the stored options of the smtp resource, the worker sends the email with the latest options,
+// so the credentials are never stored in the queue and a refreshed access token is used by the retries.
+type ResourceLoader func(teamID int, resourceID int) (map[string]interface{}, error)
+
+// popEmailScript moves the next queued email to the processing list and takes its lease in one step, so the email is
+// never seen in the processing list without lease by queueAbandonedEmails.
+var popEmailScript = redis.NewScript(`
+local messageID = redis.call('RPOPLPUSH', KEYS[1], KEYS[2])
+if not messageID then
+	return false
+end
+redis.call('SET', ARGV[1] .. messageID, 1, 'PX', ARGV[2])
+return messageID
+`)
+
+// requeueAbandonedEmailScript queues the processing email again when it has no lease, the check and the move are in
+// one step, so an email taken by another worker in between is never queued again.
+var requeueAbandonedEmailScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[3]) == 1 then
+	return 0
+end
+if redis.call('LREM', KEYS[1], 1, ARGV[1]) == 0 then
+	return 0
+end
+redis.call('LPUSH', KEYS[2], ARGV[1])
+return 1
+`)
+
+// ErrDeliveryStatusUnavailable is returned when the email is not queued by the resource, or the status is expired.
+var ErrDeliveryStatusUnavailable = errors.New("delivery status unavailable, the email is not queued by this resource or the status is expired")
+
+// SendQueue sends the queued emails in background workers, a failed send is retried with exponential backoff.
+// The emails, the queue and the delivery statuses are stored in redis, so an email queued by one instance can be sent
+// by the workers of any instance, it survives restarts and its status can be looked up on any instance.
+// The worker holds a lease of the email and renews it while sending, the email of an expired lease is queued again,
+// so the delivery is at least once.
+type SendQueue struct {
+	rdb          redis.UniversalClient
+	start        sync.Once
+	workers      int
+	size         int64
+	loadResource ResourceLoader
+	send         func(resource Resource, from string, to []string, message io.WriterTo) error
+}
+
+// defaultSendQueue is started by the server which runs actions, see StartDefaultSendQueue.
+var defaultSendQueue *SendQueue
+
+// StartDefaultSendQueue starts the workers of the default send queue with the redis of ILLA, the queued emails are
+// sent with the resource options loaded by loadResource.
+func StartDefaultSendQueue(rdb redis.UniversalClient, loadResource ResourceLoader) {
+	defaultSendQueue = NewSendQueue(rdb, loadResource, SEND_QUEUE_WORKERS, SEND_QUEUE_SIZE)
+	defaultSendQueue.Start()
+}
+
+func getDefaultSendQueue() (*SendQueue, error) {
+	if defaultSendQueue == nil {
+		return nil, errors.New("smtp send queue is not started on this server")
+	}
+	return defaultSendQueue, nil
+}
+
+func NewSendQueue(rdb redis.UniversalClient, loadResource ResourceLoader, workers int, size int64) *SendQueue {
+	return &SendQueue{
+		rdb:          rdb,
+		workers:      workers,
+		size:         size,
+		loadResource: loadResource,
+		send:         sendEnvelope,
+	}
+}
+
+func (q *SendQueue) Start() {
+	q.start.Do(func() {
+		for i := 0; i < q.workers; i++ {
+			go q.work()
+		}
+		go q.schedule()
+	})
+}
+
+func (q *SendQueue) Enqueue(resource Resource, messageID string, message *gomail.Message, maxRetries int) (*DeliveryStatus, error) {
+	ctx := context.Background()
+	length, err := q.rdb.LLen(ctx, SEND_QUEUE_KEY).Result()
+	if err != nil {
+		return nil, err
+	}
+	if length >= q.size {
+		return nil, errors.New("smtp send queue is full, please retry later")
+	}
+
+	// render the message with the envelope gomail uses, the Bcc header is dropped from the message
+	email := &queuedEmail{MessageID: messageID, TeamID: resource.TeamID, ResourceID: resource.ResourceID}
+	capture := gomail.SendFunc(func(from string, to []string, msg io.WriterTo) error {
+		var buf bytes.Buffer
+		if _, err := msg.WriteTo(&buf); err != nil {
+			return err
+		}
+		email.From = from
+		email.To = to
+		email.Message = buf.Bytes()
+		return nil
+	})
+	if err := gomail.Send(capture, message); err != nil {
+		return nil, err
+	}
+	emailInJSON, err := json.Marshal(email)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	status := &DeliveryStatus{
+		MessageID:   messageID,
+		Status:      DELIVERY_STATUS_QUEUED,
+		MaxAttempts: maxRetries + 1,
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	statusInJSON, err := json.Marshal(status)
+	if err != nil {
+		return nil, err
+	}
+	_, err = q.rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+		pipe.Set(ctx, emailKey(messageID), emailInJSON, DELIVERY_STATUS_EXPIRY)
+		pipe.Set(ctx, statusKey(resource.TeamID, resource.ResourceID, messageID), statusInJSON, DELIVERY_STATUS_EXPIRY)
+		pipe.LPush(ctx, SEND_QUEUE_KEY, messageID)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+// Status looks up the delivery status of the email queued by the resource of the team.
+func (q *SendQueue) Status(teamID int, resourceID int, messageID string) (*DeliveryStatus, error) {
+	statusInJSON, err := q.rdb.Get(context.Background(), statusKey(teamID, resourceID, messageID)).Bytes()
+	if err == redis.Nil {
+		return nil, ErrDeliveryStatusUnavailable
+	}
+	if err != nil {
+		return nil, err
+	}
+	status := &DeliveryStatus{}
+	if err := json.Unmarshal(statusInJSON, status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func (q *SendQueue) work() {
+	for {
+		// wait before polling again when the queue is empty or redis is unavailable
+		if processed, err := q.processNext(context.Background()); !processed || err != nil {
+			time.Sleep(SEND_QUEUE_POLL_INTERVAL)
+		}
+	}
+}
+
+// schedule queues the emails which are due to retry, and the emails whose worker lost the lease.
+func (q *SendQueue) schedule() {
+	ticker := time.NewTicker(SEND_QUEUE_SCHEDULE_INTERVAL)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		ctx := context.Background()
+		q.queueDueRetries(ctx, now)
+		q.queueAbandonedEmails(ctx)
+	}
+}
+
+// processNext sends the next queued email, it returns false when no email is queued.
+func (q *SendQueue) processNext(ctx context.Context) (bool, error) {
+	messageID, err := popEmailScript.Run(ctx, q.rdb, []string{SEND_QUEUE_KEY, SEND_QUEUE_PROCESSING_KEY}, SEND_QUEUE_LEASE_KEY_PREFIX, SEND_LEASE_TIMEOUT.Milliseconds()).Text()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer func() {
+		q.rdb.LRem(ctx, SEND_QUEUE_PROCESSING_KEY, 1, messageID)
+		q.rdb.Del(ctx, leaseKey(messageID))
+	}()
+	stopRenewLease := q.renewLease(ctx, messageID)
+	defer stopRenewLease()
+
+	// the email is expired
+	emailInJSON, err := q.rdb.Get(ctx, emailKey(messageID)).Bytes()
+	if err == redis.Nil {
+		return true, nil
+	}
+	if err != nil {
+		return true, err
+	}
+	email := &queuedEmail{}
+	if err := json.Unmarshal(emailInJSON, email); err != nil {
+		q.rdb.Del(ctx, emailKey(messageID))
+		return true, err
+	}
+	status, err := q.Status(email.TeamID, email.ResourceID, messageID)
+	if err != nil {
+		q.rdb.Del(ctx, emailKey(messageID))
+		return true, err
+	}
+
+	// send email
+	status.Status = DELIVERY_STATUS_SENDING
+	status.Attempts++
+	q.saveStatus(ctx, email, status)
+	resource, errInSend := q.resource(email)
+	if errInSend == nil {
+		errInSend = q.send(resource, email.From, email.To, bytes.NewReader(email.Message))
+	}
+
+	// update delivery status, the failed email is retried after 2^attempts seconds
+	switch {
+	case errInSend == nil:
+		status.Status = DELIVERY_STATUS_SENT
+		status.LastError = ""
+		q.rdb.Del(ctx, emailKey(messageID))
+	case status.Attempts < status.MaxAttempts:
+		status.Status = DELIVERY_STATUS_RETRYING
+		status.LastError = errInSend.Error()
+		backoff := time.Duration(1<<uint(status.Attempts)) * time.Second
+		if backoff > MAX_RETRY_BACKOFF {
+			backoff = MAX_RETRY_BACKOFF
+		}
+		q.rdb.ZAdd(ctx, SEND_QUEUE_RETRY_KEY, redis.Z{Score: float64(time.Now().Add(backoff).Unix()), Member: messageID})
+	default:
+		status.Status = DELIVERY_STATUS_FAILED
+		status.LastError = errInSend.Error()
+		q.rdb.Del(ctx, emailKey(messageID))
+	}
+	q.saveStatus(ctx, email, status)
+	return true, nil
+}
+
+// resource loads the options of the resource which queued the email.
+func (q *SendQueue) resource(email *queuedEmail) (Resource, error) {
+	resource := Resource{}
+	resourceOptions, err := q.loadResource(email.TeamID, email.ResourceID)
+	if err != nil {
+		return resource, errors.New("load smtp resource failed: " + err.Error())
+	}
+	if err := mapstructure.Decode(resourceOptions, &resource); err != nil {
+		return resource, err
+	}
+	resource.TeamID = email.TeamID
+	resource.ResourceID = email.ResourceID
+	return resource, nil
+}
+
+// renewLease keeps the lease of the email until the returned stop function is called, so a slow send is not taken
+// as abandoned.
+func (q *SendQueue) renewLease(ctx context.Context, messageID string) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(SEND_LEASE_RENEW_INTERVAL)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				q.rdb.Expire(ctx, leaseKey(messageID), SEND_LEASE_TIMEOUT)
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+// queueDueRetries moves the emails due to retry back to the queue, the instance removed the email from the retry set
+// queues it, so an email is queued once by the instances.
+func (q *SendQueue) queueDueRetries(ctx context.Context, now time.Time) {
+	messageIDs, err := q.rdb.ZRangeByScore(ctx, SEND_QUEUE_RETRY_KEY, &redis.ZRangeBy{Min: "-inf", Max: strconv.FormatInt(now.Unix(), 10)}).Result()
+	if err != nil {
+		return
+	}
+	for _, messageID := range messageIDs {
+		if removed, _ := q.rdb.ZRem(ctx, SEND_QUEUE_RETRY_KEY, messageID).Result(); removed > 0 {
+			q.rdb.LPush(ctx, SEND_QUEUE_KEY, messageID)
+		}
+	}
+}
+
+// queueAbandonedEmails queues the processing emails without lease again, the instance sending them is stopped.
+func (q *SendQueue) queueAbandonedEmails(ctx context.Context) {
+	messageIDs, err := q.rdb.LRange(ctx, SEND_QUEUE_PROCESSING_KEY, 0, -1).Result()
+	if err != nil {
+		return
+	}
+	for _, messageID := range messageIDs {
+		requeueAbandonedEmailScript.Run(ctx, q.rdb, []string{SEND_QUEUE_PROCESSING_KEY, SEND_QUEUE_KEY, leaseKey(messageID)}, messageID)
+	}
+}
+
+func (q *SendQueue) saveStatus(ctx context.Context, email *queuedEmail, status *DeliveryStatus) {
+	status.UpdatedAt = time.Now().UTC()
+	statusInJSON, err := json.Marshal(status)
+	if err != nil {
+		return
+	}
+	q.rdb.Set(ctx, statusKey(email.TeamID, email.ResourceID, status.MessageID), statusInJSON, DELIVERY_STATUS_EXPIRY)
+}
+
+func emailKey(messageID string) string {
+	return SEND_QUEUE_EMAIL_KEY_PREFIX + messageID
+}
+
+func leaseKey(messageID string) string {
+	return SEND_QUEUE_LEASE_KEY_PREFIX + messageID
+}
+
+func statusKey(teamID int, resourceID int, messageID string) string {
+	return DELIVERY_STATUS_KEY_PREFIX + strconv.Itoa(teamID) + ":" + strconv.Itoa(resourceID) + ":" + messageID
+}
@@ -0,0 +1,198 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smtp
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	redis "github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/gomail.v2"
+)
+
+type sentEmail struct {
+	resource Resource
+	from     string
+	to       []string
+	message  string
+}
+
+func newTestSendQueue(t *testing.T, sendErrors ...error) (*SendQueue, *miniredis.Miniredis, *[]sentEmail) {
+	server := miniredis.RunT(t)
+	rdb := redis.NewClient(&redis.Options{Addr: server.Addr()})
+	t.Cleanup(func() { rdb.Close() })
+	sent := make([]sentEmail, 0)
+	loadResource := func(teamID int, resourceID int) (map[string]interface{}, error) {
+		return map[string]interface{}{"host": "smtp.illa.test", "port": 587, "password": "secret"}, nil
+	}
+	queue := NewSendQueue(rdb, loadResource, 1, 10)
+	queue.send = func(resource Resource, from string, to []string, message io.WriterTo) error {
+		var buf bytes.Buffer
+		message.WriteTo(&buf)
+		sent = append(sent, sentEmail{resource: resource, from: from, to: to, message: buf.String()})
+		if len(sendErrors) == 0 {
+			return nil
+		}
+		err := sendErrors[0]
+		sendErrors = sendErrors[1:]
+		return err
+	}
+	return queue, server, &sent
+}
+
+func newTestMessage() *gomail.Message {
+	message := gomail.NewMessage()
+	message.SetHeader("From", "noreply@illa.test")
+	message.SetHeader("To", "alice@illa.test")
+	message.SetHeader("Bcc", "audit@illa.test")
+	message.SetHeader("Subject", "Hello")
+	message.SetBody("text/plain", "hello world")
+	return message
+}
+
+func TestSendQueueDeliversEmail(t *testing.T) {
+	queue, _, sent := newTestSendQueue(t)
+	resource := Resource{Host: "smtp.illa.test", Password: "secret", TeamID: 1, ResourceID: 2}
+	status, err := queue.Enqueue(resource, "message-1", newTestMessage(), 3)
+	assert.Nil(t, err)
+	assert.Equal(t, DELIVERY_STATUS_QUEUED, status.Status)
+	assert.Equal(t, 4, status.MaxAttempts)
+
+	// the credentials are not stored in the queue
+	emailInJSON, err := queue.rdb.Get(context.Background(), emailKey("message-1")).Result()
+	assert.Nil(t, err)
+	assert.NotContains(t, emailInJSON, "secret")
+
+	// the resource options are loaded when sending
+	processed, err := queue.processNext(context.Background())
+	assert.Nil(t, err)
+	assert.True(t, processed)
+	assert.Len(t, *sent, 1)
+	assert.Equal(t, Resource{Host: "smtp.illa.test", Port: 587, Password: "secret", TeamID: 1, ResourceID: 2}, (*sent)[0].resource)
+	assert.Equal(t, "noreply@illa.test", (*sent)[0].from)
+	assert.ElementsMatch(t, []string{"alice@illa.test", "audit@illa.test"}, (*sent)[0].to)
+	assert.Contains(t, (*sent)[0].message, "hello world")
+	assert.NotContains(t, (*sent)[0].message, "audit@illa.test")
+
+	status, err = queue.Status(1, 2, "message-1")
+	assert.Nil(t, err)
+	assert.Equal(t, DELIVERY_STATUS_SENT, status.Status)
+	assert.Equal(t, 1, status.Attempts)
+	processing, _ := queue.rdb.LLen(context.Background(), SEND_QUEUE_PROCESSING_KEY).Result()
+	assert.Equal(t, int64(0), processing)
+}
+
+func TestSendQueueStatusIsScopedByResource(t *testing.T) {
+	queue, _, _ := newTestSendQueue(t)
+	_, err := queue.Enqueue(Resource{TeamID: 1, ResourceID: 2}, "message-1", newTestMessage(), 0)
+	assert.Nil(t, err)
+
+	_, err = queue.Status(1, 3, "message-1")
+	assert.Equal(t, ErrDeliveryStatusUnavailable, err)
+	_, err = queue.Status(9, 2, "message-1")
+	assert.Equal(t, ErrDeliveryStatusUnavailable, err)
+	_, err = queue.Status(1, 2, "message-1")
+	assert.Nil(t, err)
+}
+
+func TestSendQueueRetriesThenFails(t *testing.T) {
+	queue, _, sent := newTestSendQueue(t, errors.New("421 try again later"), errors.New("421 try again later"))
+	resource := Resource{TeamID: 1, ResourceID: 2}
+	_, err := queue.Enqueue(resource, "message-1", newTestMessage(), 1)
+	assert.Nil(t, err)
+
+	// the first failure is retried later
+	_, err = queue.processNext(context.Background())
+	assert.Nil(t, err)
+	status, _ := queue.Status(1, 2, "message-1")
+	assert.Equal(t, DELIVERY_STATUS_RETRYING, status.Status)
+	assert.Equal(t, "421 try again later", status.LastError)
+	processed, err := queue.processNext(context.Background())
+	assert.Nil(t, err)
+	assert.False(t, processed)
+
+	// the email is queued again when it is due, the last attempt fails the email
+	queue.queueDueRetries(context.Background(), time.Now().Add(MAX_RETRY_BACKOFF))
+	_, err = queue.processNext(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, *sent, 2)
+	status, _ = queue.Status(1, 2, "message-1")
+	assert.Equal(t, DELIVERY_STATUS_FAILED, status.Status)
+	assert.Equal(t, 2, status.Attempts)
+	exists, _ := queue.rdb.Exists(context.Background(), emailKey("message-1")).Result()
+	assert.Equal(t, int64(0), exists)
+}
+
+func TestSendQueueRecoversAbandonedEmail(t *testing.T) {
+	queue, server, sent := newTestSendQueue(t)
+	_, err := queue.Enqueue(Resource{TeamID: 1, ResourceID: 2}, "message-1", newTestMessage(), 0)
+	assert.Nil(t, err)
+
+	// an instance took the email and stopped before sending it
+	ctx := context.Background()
+	assert.Nil(t, queue.rdb.RPopLPush(ctx, SEND_QUEUE_KEY, SEND_QUEUE_PROCESSING_KEY).Err())
+	assert.Nil(t, queue.rdb.Set(ctx, leaseKey("message-1"), 1, SEND_LEASE_TIMEOUT).Err())
+	queue.queueAbandonedEmails(ctx)
+	queued, _ := queue.rdb.LLen(ctx, SEND_QUEUE_KEY).Result()
+	assert.Equal(t, int64(0), queued)
+
+	// the lease is expired
+	server.FastForward(SEND_LEASE_TIMEOUT)
+	queue.queueAbandonedEmails(ctx)
+	_, err = queue.processNext(ctx)
+	assert.Nil(t, err)
+	assert.Len(t, *sent, 1)
+	status, _ := queue.Status(1, 2, "message-1")
+	assert.Equal(t, DELIVERY_STATUS_SENT, status.Status)
+}
+
+func TestSendQueueWithoutRetries(t *testing.T) {
+	queue, _, sent := newTestSendQueue(t, errors.New("550 mailbox unavailable"))
+	_, err := queue.Enqueue(Resource{TeamID: 1, ResourceID: 2}, "message-1", newTestMessage(), 0)
+	assert.Nil(t, err)
+
+	_, err = queue.processNext(context.Background())
+	assert.Nil(t, err)
+	assert.Len(t, *sent, 1)
+	status, _ := queue.Status(1, 2, "message-1")
+	assert.Equal(t, DELIVERY_STATUS_FAILED, status.Status)
+	assert.Equal(t, 1, status.MaxAttempts)
+}
+
+func TestSendQueueKeepsLeaseWhileSending(t *testing.T) {
+	queue, server, _ := newTestSendQueue(t)
+	_, err := queue.Enqueue(Resource{TeamID: 1, ResourceID: 2}, "message-1", newTestMessage(), 0)
+	assert.Nil(t, err)
+
+	// the email is leased as soon as it is taken from the queue, the scheduler never queues it again while sending
+	ctx := context.Background()
+	queue.send = func(resource Resource, from string, to []string, message io.WriterTo) error {
+		assert.True(t, server.Exists(leaseKey("message-1")))
+		queue.queueAbandonedEmails(ctx)
+		queued, _ := queue.rdb.LLen(ctx, SEND_QUEUE_KEY).Result()
+		assert.Equal(t, int64(0), queued)
+		return nil
+	}
+	processed, err := queue.processNext(ctx)
+	assert.Nil(t, err)
+	assert.True(t, processed)
+	assert.False(t, server.Exists(leaseKey("message-1")))
+}
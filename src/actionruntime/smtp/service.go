@@ -21,6 +21,7 @@ import (
 	"net/url"
 
 	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
 	"github.com/illacloud/builder-backend/src/actionruntime/common"
 	"github.com/mitchellh/mapstructure"
 	"gopkg.in/gomail.v2"
@@ -50,19 +51,16 @@ func (s *Connector) ValidateActionTemplate(actionOptions map[string]interface{})
 }
 
 func (s *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
-	// get smtp dialer
-	smtpDialer, err := s.getConnectionWithOptions(resourceOptions)
+	// get smtp client, it dials and authenticates to the SMTP server
+	smtpClient, err := s.getConnectionWithOptions(resourceOptions)
 	if err != nil {
 		return common.ConnectionResult{Success: false}, err
 	}
+	defer smtpClient.Close()
 
-	// Dial dials and authenticates to an SMTP server
-	sendCloser, err := smtpDialer.Dial()
-	if err != nil {
+	if err := smtpClient.Quit(); err != nil {
 		return common.ConnectionResult{Success: false}, err
 	}
-	defer sendCloser.Close()
-
 	return common.ConnectionResult{Success: true}, nil
 }
 
@@ -74,10 +72,9 @@ func (s *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.
 }
 
 func (s *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
-	// get smtp dialer
-	smtpDialer, err := s.getConnectionWithOptions(resourceOptions)
-	if err != nil {
-		return common.RuntimeResult{Success: false}, errors.New("failed to get smtp dialer")
+	// format smtp resource
+	if err := mapstructure.Decode(resourceOptions, &s.ResourceOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
 	}
 
 	// format smtp action
@@ -85,6 +82,32 @@ func (s *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		return common.RuntimeResult{Success: false}, err
 	}
 
+	// look up the delivery status of a queued email
+	if s.ActionOpts.Operation == STATUS_OPERATION {
+		if s.ActionOpts.MessageID == "" {
+			return common.RuntimeResult{Success: false}, errors.New("messageID is required for status operation")
+		}
+		sendQueue, err := getDefaultSendQueue()
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		status, err := sendQueue.Status(s.ResourceOpts.TeamID, s.ResourceOpts.ResourceID, s.ActionOpts.MessageID)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		return common.RuntimeResult{
+			Success: true,
+			Rows:    []map[string]interface{}{status.ExportToMap()},
+		}, nil
+	}
+
+	// render subject and body on server side
+	if s.ActionOpts.RenderTemplate {
+		if err := s.ActionOpts.renderSubjectAndBody(rawActionOptions); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+
 	// validate smtp options
 	validate := validator.New()
 	if err := validate.Struct(s.ActionOpts); err != nil {
@@ -93,8 +116,10 @@ func (s *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 
 	// build message
 	emailMessage := gomail.NewMessage()
+	messageID := uuid.New().String()
 
 	// set header
+	emailMessage.SetHeader("Message-ID", "<"+messageID+"@"+s.ResourceOpts.Host+">")
 	emailMessage.SetHeader("From", s.ActionOpts.From)
 	emailMessage.SetHeader("To", s.ActionOpts.To...)
 	emailMessage.SetHeader("Subject", s.ActionOpts.Subject)
@@ -133,12 +158,33 @@ func (s *Connector) Run(resourceOptions map[string]interface{}, actionOptions ma
 		}
 	}
 
-	if err := smtpDialer.DialAndSend(emailMessage); err != nil {
+	// queue the email, the status operation looks up the delivery
+	if s.ActionOpts.Queue {
+		maxRetries := DEFAULT_MAX_RETRIES
+		if s.ActionOpts.MaxRetries != nil {
+			maxRetries = *s.ActionOpts.MaxRetries
+		}
+		sendQueue, err := getDefaultSendQueue()
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		status, err := sendQueue.Enqueue(s.ResourceOpts, messageID, emailMessage, maxRetries)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		return common.RuntimeResult{
+			Success: true,
+			Rows:    []map[string]interface{}{status.ExportToMap()},
+			Extra:   map[string]interface{}{"message": "email queued"},
+		}, nil
+	}
+
+	if err := sendMessage(s.ResourceOpts, emailMessage); err != nil {
 		return common.RuntimeResult{Success: false}, err
 	}
 
 	return common.RuntimeResult{
 		Success: true,
-		Rows:    []map[string]interface{}{{"message": "email sent successfully"}},
+		Rows:    []map[string]interface{}{{"message": "email sent successfully", "messageID": messageID}},
 	}, nil
 }
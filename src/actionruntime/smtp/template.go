@@ -0,0 +1,110 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package smtp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"strings"
+)
+
+// renderTemplate renders the "{{ }}" variables of the template with the context. The values are HTML escaped when
+// escapeHTML is set, except the "{{{ }}}" variables which insert the raw value.
+func renderTemplate(template string, context map[string]interface{}, escapeHTML bool) (string, error) {
+	var ret strings.Builder
+	rest := template
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			ret.WriteString(rest)
+			break
+		}
+		ret.WriteString(rest[:start])
+		rest = rest[start:]
+		openDelimiter, closeDelimiter := "{{", "}}"
+		if strings.HasPrefix(rest, "{{{") {
+			openDelimiter, closeDelimiter = "{{{", "}}}"
+		}
+		end := strings.Index(rest[len(openDelimiter):], closeDelimiter)
+		if end < 0 {
+			ret.WriteString(rest)
+			break
+		}
+		variable := strings.TrimSpace(rest[len(openDelimiter) : len(openDelimiter)+end])
+		rest = rest[len(openDelimiter)+end+len(closeDelimiter):]
+
+		value, hit := context[variable]
+		if !hit {
+			return "", fmt.Errorf("missing context value for variable {{%s}}", variable)
+		}
+		valueInString, err := exportValueAsText(value)
+		if err != nil {
+			return "", err
+		}
+		if escapeHTML && openDelimiter == "{{" {
+			valueInString = html.EscapeString(valueInString)
+		}
+		ret.WriteString(valueInString)
+	}
+	return ret.String(), nil
+}
+
+func exportValueAsText(value interface{}) (string, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return typedValue, nil
+	default:
+		valueInByte, err := json.Marshal(typedValue)
+		if err != nil {
+			return "", err
+		}
+		return string(valueInByte), nil
+	}
+}
+
+// renderSubjectAndBody renders the Subject and Body of the raw template on server side.
+func (a *Action) renderSubjectAndBody(rawTemplate map[string]interface{}) error {
+	context := make(map[string]interface{}, 0)
+	if contextRaw, hit := rawTemplate[FIELD_CONTEXT]; hit && contextRaw != nil {
+		contextAsserted, assertPass := contextRaw.(map[string]interface{})
+		if !assertPass {
+			return errors.New("context field assert failed in renderSubjectAndBody() method")
+		}
+		for key, value := range contextAsserted {
+			context[strings.TrimSpace(key)] = value
+		}
+	}
+
+	if rawSubject, assertPass := rawTemplate[FIELD_SUBJECT].(string); assertPass {
+		subject, err := renderTemplate(rawSubject, context, false)
+		if err != nil {
+			return err
+		}
+		// a rendered line break must not start a new header
+		a.Subject = strings.NewReplacer("\r\n", " ", "\r", " ", "\n", " ").Replace(subject)
+	}
+	if rawBody, assertPass := rawTemplate[FIELD_BODY].(string); assertPass {
+		body, err := renderTemplate(rawBody, context, a.ContentType == "text/html")
+		if err != nil {
+			return err
+		}
+		a.Body = body
+	}
+	return nil
+}
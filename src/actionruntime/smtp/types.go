@@ -14,24 +14,99 @@
 
 package smtp
 
+import "time"
+
+const (
+	SECURITY_AUTO     = "auto"
+	SECURITY_SSL      = "ssl"
+	SECURITY_STARTTLS = "starttls"
+	SECURITY_NONE     = "none"
+
+	SMTPS_PORT = 465
+
+	AUTHENTICATION_PASSWORD = "password"
+	AUTHENTICATION_OAUTH2   = "oauth2"
+)
+
+const (
+	SEND_OPERATION   = "send"
+	STATUS_OPERATION = "status"
+)
+
+const (
+	FIELD_CONTEXT = "context"
+	FIELD_SUBJECT = "subject"
+	FIELD_BODY    = "body"
+)
+
+const (
+	DELIVERY_STATUS_QUEUED   = "queued"
+	DELIVERY_STATUS_SENDING  = "sending"
+	DELIVERY_STATUS_RETRYING = "retrying"
+	DELIVERY_STATUS_SENT     = "sent"
+	DELIVERY_STATUS_FAILED   = "failed"
+)
+
+const (
+	DIAL_TIMEOUT                 = 10 * time.Second
+	SEND_QUEUE_SIZE              = 1000
+	SEND_QUEUE_WORKERS           = 4
+	SEND_QUEUE_POLL_INTERVAL     = 1 * time.Second
+	SEND_QUEUE_SCHEDULE_INTERVAL = 1 * time.Second
+	SEND_LEASE_TIMEOUT           = 5 * time.Minute
+	SEND_LEASE_RENEW_INTERVAL    = 1 * time.Minute
+	DEFAULT_MAX_RETRIES          = 3
+	MAX_RETRY_BACKOFF            = 5 * time.Minute
+	DELIVERY_STATUS_EXPIRY       = 24 * time.Hour
+)
+
+// Resource describe an SMTP server. The Security is "auto" by default, which uses implicit TLS on port 465 and
+// upgrades the connection with STARTTLS on other ports when the server supports it, "ssl" is implicit TLS and
+// "starttls" requires the upgrade.
+// The oauth2 authentication uses the AccessToken with SASL XOAUTH2, like Gmail and Office 365.
+// The TeamID and ResourceID are filled by the server, the queued emails and their statuses are scoped by them.
 type Resource struct {
-	Host     string `validate:"required"`
-	Port     int    `validate:"gt=0"`
-	Username string
-	Password string
+	Host           string `validate:"required"`
+	Port           int    `validate:"gt=0"`
+	Security       string `validate:"omitempty,oneof=auto ssl starttls none"`
+	Authentication string `validate:"omitempty,oneof=password oauth2"`
+	Username       string `validate:"required_if=Authentication oauth2"`
+	Password       string
+	AccessToken    string `validate:"required_if=Authentication oauth2"`
+	TeamID         int
+	ResourceID     int
+}
+
+// IsImplicitTLS reports whether the connection is TLS from the start, the auto mode uses it on the SMTPS port
+// since the server never answers the plain greeting there.
+func (r *Resource) IsImplicitTLS() bool {
+	if r.Security == SECURITY_SSL {
+		return true
+	}
+	return (r.Security == "" || r.Security == SECURITY_AUTO) && r.Port == SMTPS_PORT
 }
 
+// Action describe an email. With RenderTemplate the Subject and Body are rendered on server side from the raw
+// template and its context, the variables of a text/html Body are HTML escaped, "{{{ }}}" inserts the raw value.
+// With Queue the email is sent in background with retries, and the "status" operation looks up the delivery
+// status by the MessageID. The failed email is retried DEFAULT_MAX_RETRIES times when MaxRetries is not given, 0
+// turns the retries off. The queue is stored in redis and shared by the instances, see SendQueue.
 type Action struct {
-	From        string   `validate:"required"`
-	To          []string `validate:"required,gt=0,dive,required"`
-	Bcc         []string
-	Cc          []string
-	SetReplyTo  bool
-	ReplyTo     string `validate:"required_unless=SetReplyTo false"`
-	Subject     string `validate:"required"`
-	ContentType string `validate:"required,oneof=text/plain text/html"`
-	Body        string `validate:"required"`
-	Attachment  []Attachment
+	Operation      string   `validate:"omitempty,oneof=send status"`
+	From           string   `validate:"required"`
+	To             []string `validate:"required,gt=0,dive,required"`
+	Bcc            []string
+	Cc             []string
+	SetReplyTo     bool
+	ReplyTo        string `validate:"required_unless=SetReplyTo false"`
+	Subject        string `validate:"required"`
+	ContentType    string `validate:"required,oneof=text/plain text/html"`
+	Body           string `validate:"required"`
+	Attachment     []Attachment
+	RenderTemplate bool
+	Queue          bool
+	MaxRetries     *int `validate:"omitempty,gte=0,lte=10"`
+	MessageID      string
 }
 
 type Attachment struct {
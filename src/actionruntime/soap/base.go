@@ -0,0 +1,140 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soap
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/mitchellh/mapstructure"
+)
+
+// soapResponse is the outcome of an operation call, the Fault is set when the service responds a SOAP fault.
+type soapResponse struct {
+	StatusCode int
+	Header     interface{}
+	Body       map[string]interface{}
+	Fault      map[string]interface{}
+}
+
+func (s *Connector) getClientWithOptions(resourceOptions map[string]interface{}) (*resty.Client, error) {
+	if err := mapstructure.Decode(resourceOptions, &s.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	client := resty.New().SetTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	if s.ResourceOpts.Authentication == AUTHENTICATION_BASIC {
+		client.SetBasicAuth(s.ResourceOpts.Username, s.ResourceOpts.Password)
+	}
+	setHeaders(client, s.ResourceOpts.Headers)
+	return client, nil
+}
+
+func setHeaders(client *resty.Client, headers []map[string]string) {
+	for _, header := range headers {
+		if header["key"] != "" {
+			client.SetHeader(header["key"], header["value"])
+		}
+	}
+}
+
+// loadOperations loads the WSDL and resolves the operations, the Endpoint of the resource overrides the port address.
+func (s *Connector) loadOperations(client *resty.Client) ([]Operation, error) {
+	content := []byte(s.ResourceOpts.WSDLContent)
+	if s.ResourceOpts.WSDLSource == WSDL_SOURCE_URL {
+		resp, err := client.R().Get(s.ResourceOpts.WSDLURL)
+		if err != nil {
+			return nil, errors.New("failed to fetch wsdl: " + err.Error())
+		}
+		if !resp.IsSuccess() {
+			return nil, errors.New("failed to fetch wsdl: " + resp.Status())
+		}
+		content = resp.Body()
+	}
+
+	operations, err := parseWSDL(content)
+	if err != nil {
+		return nil, err
+	}
+	if s.ResourceOpts.Endpoint != "" {
+		for i := range operations {
+			operations[i].Endpoint = s.ResourceOpts.Endpoint
+		}
+	}
+	return operations, nil
+}
+
+// callOperation posts the envelope to the endpoint of the operation and parses the response envelope.
+func (s *Connector) callOperation(client *resty.Client, operation Operation, envelope []byte, headers []map[string]string) (*soapResponse, error) {
+	if operation.Endpoint == "" {
+		return nil, errors.New("no endpoint for operation: " + operation.Name)
+	}
+	request := client.R().SetBody(envelope)
+	if operation.SOAPVersion == SOAP_VERSION_12 {
+		contentType := "application/soap+xml; charset=utf-8"
+		if operation.SOAPAction != "" {
+			contentType += `; action="` + operation.SOAPAction + `"`
+		}
+		request.SetHeader("Content-Type", contentType)
+	} else {
+		request.SetHeader("Content-Type", "text/xml; charset=utf-8")
+		request.SetHeader("SOAPAction", strconv.Quote(operation.SOAPAction))
+	}
+	for _, header := range headers {
+		if header["key"] != "" {
+			request.SetHeader(header["key"], header["value"])
+		}
+	}
+	resp, err := request.Post(operation.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// the non SOAP response (like an HTML error page) is returned as error
+	root, errInParse := parseXMLNode(resp.Body())
+	if errInParse != nil || root.Name.Local != "Envelope" {
+		return nil, errors.New("unexpected response with status " + resp.Status() + ": " + truncate(string(resp.Body()), 512))
+	}
+	result := &soapResponse{StatusCode: resp.StatusCode(), Body: map[string]interface{}{}}
+	if header := root.child(root.Name.Space, "Header"); header != nil {
+		result.Header = header.toValue()
+	}
+	body := root.child(root.Name.Space, "Body")
+	if body == nil {
+		return nil, errors.New("missing body in response envelope")
+	}
+	for _, element := range body.Children {
+		if element.Name.Local == "Fault" && element.Name.Space == root.Name.Space {
+			result.Fault = exportFault(element)
+			return result, nil
+		}
+		result.Body[element.Name.Local] = element.toValue()
+	}
+	if !resp.IsSuccess() {
+		return nil, errors.New("unexpected response with status " + resp.Status())
+	}
+	return result, nil
+}
+
+func truncate(content string, limit int) string {
+	content = strings.TrimSpace(content)
+	if len(content) > limit {
+		return content[:limit] + "..."
+	}
+	return content
+}
@@ -0,0 +1,430 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soap
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	SOAP_11_ENVELOPE_NAMESPACE = "http://schemas.xmlsoap.org/soap/envelope/"
+	SOAP_12_ENVELOPE_NAMESPACE = "http://www.w3.org/2003/05/soap-envelope"
+	WSSE_NAMESPACE             = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-secext-1.0.xsd"
+	WSU_NAMESPACE              = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-wssecurity-utility-1.0.xsd"
+	WSSE_PASSWORD_TEXT         = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordText"
+	WSSE_PASSWORD_DIGEST       = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-username-token-profile-1.0#PasswordDigest"
+	WSSE_BASE64_BINARY         = "http://docs.oasis-open.org/wss/2004/01/oasis-200401-wss-soap-message-security-1.0#Base64Binary"
+)
+
+const (
+	ATTRIBUTE_KEY_PREFIX = "@"
+	TEXT_KEY             = "#text"
+)
+
+// orderedField and orderedObject keep the key order of JSON object, the order of elements matters for xsd:sequence.
+type orderedField struct {
+	Key   string
+	Value interface{}
+}
+
+type orderedObject []orderedField
+
+func (o orderedObject) get(key string) (interface{}, bool) {
+	for _, field := range o {
+		if field.Key == key {
+			return field.Value, true
+		}
+	}
+	return nil, false
+}
+
+// decodeOrderedJSON decodes JSON into orderedObject, []interface{}, json.Number, string, bool or nil.
+func decodeOrderedJSON(raw string) (interface{}, error) {
+	decoder := json.NewDecoder(strings.NewReader(raw))
+	decoder.UseNumber()
+	value, err := decodeOrderedValue(decoder)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return nil, errors.New("unexpected content after JSON value")
+	}
+	return value, nil
+}
+
+func decodeOrderedValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		object := orderedObject{}
+		for decoder.More() {
+			keyToken, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			object = append(object, orderedField{Key: keyToken.(string), Value: value})
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return object, nil
+	case json.Delim('['):
+		array := make([]interface{}, 0)
+		for decoder.More() {
+			value, err := decodeOrderedValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+		if _, err := decoder.Token(); err != nil {
+			return nil, err
+		}
+		return array, nil
+	default:
+		return token, nil
+	}
+}
+
+func envelopeNamespace(soapVersion string) string {
+	if soapVersion == SOAP_VERSION_12 {
+		return SOAP_12_ENVELOPE_NAMESPACE
+	}
+	return SOAP_11_ENVELOPE_NAMESPACE
+}
+
+// buildEnvelope builds the request envelope of the operation, the body is the ordered JSON of the Action.Body.
+func buildEnvelope(operation Operation, body interface{}, securityHeader string) ([]byte, error) {
+	buffer := &bytes.Buffer{}
+	buffer.WriteString(xml.Header)
+	buffer.WriteString(`<soap:Envelope xmlns:soap="` + envelopeNamespace(operation.SOAPVersion) + `">`)
+	if securityHeader != "" {
+		buffer.WriteString("<soap:Header>" + securityHeader + "</soap:Header>")
+	}
+	buffer.WriteString("<soap:Body>")
+
+	object, _ := body.(orderedObject)
+	if operation.Style == STYLE_RPC {
+		// rpc style, the operation wrapper element contains the parts
+		buffer.WriteString(`<ns0:` + operation.Name + ` xmlns:ns0="` + escapeAttr(operation.BodyNamespace) + `">`)
+		for _, part := range operation.InputParts {
+			if value, hit := object.get(part.Name); hit {
+				if err := writeElement(buffer, part.Name, "", value); err != nil {
+					return nil, err
+				}
+			}
+		}
+		buffer.WriteString(`</ns0:` + operation.Name + `>`)
+	} else {
+		// document style, the body is the content of the part element, or keyed by element names for multiple parts
+		for _, part := range operation.InputParts {
+			value := body
+			if len(operation.InputParts) > 1 {
+				var hit bool
+				if value, hit = object.get(partElementName(part)); !hit {
+					continue
+				}
+			}
+			if part.Element.Local == "" {
+				if err := writeElement(buffer, part.Name, "", value); err != nil {
+					return nil, err
+				}
+				continue
+			}
+			name, namespaceAttr := "ns0:"+part.Element.Local, ` xmlns:ns0="`+escapeAttr(part.Element.Space)+`"`
+			if part.ElementQualified {
+				name, namespaceAttr = part.Element.Local, ` xmlns="`+escapeAttr(part.Element.Space)+`"`
+			}
+			if err := writeElement(buffer, name, namespaceAttr, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	buffer.WriteString("</soap:Body></soap:Envelope>")
+	return buffer.Bytes(), nil
+}
+
+func partElementName(part Part) string {
+	if part.Element.Local != "" {
+		return part.Element.Local
+	}
+	return part.Name
+}
+
+// writeElement writes the JSON value as element, repeated elements for array, attributes and text for "@" and "#text" keys.
+func writeElement(buffer *bytes.Buffer, name string, extraAttrs string, value interface{}) error {
+	if !isXMLName(name) {
+		return errors.New("invalid element name: " + name)
+	}
+	switch typedValue := value.(type) {
+	case []interface{}:
+		for _, item := range typedValue {
+			if err := writeElement(buffer, name, extraAttrs, item); err != nil {
+				return err
+			}
+		}
+		return nil
+	case orderedObject:
+		attrs := extraAttrs
+		for _, field := range typedValue {
+			if strings.HasPrefix(field.Key, ATTRIBUTE_KEY_PREFIX) {
+				attrName := strings.TrimPrefix(field.Key, ATTRIBUTE_KEY_PREFIX)
+				if !isXMLName(attrName) {
+					return errors.New("invalid attribute name: " + attrName)
+				}
+				attrs += " " + attrName + `="` + escapeAttr(scalarToString(field.Value)) + `"`
+			}
+		}
+		buffer.WriteString("<" + name + attrs + ">")
+		for _, field := range typedValue {
+			switch {
+			case strings.HasPrefix(field.Key, ATTRIBUTE_KEY_PREFIX):
+			case field.Key == TEXT_KEY:
+				if err := xml.EscapeText(buffer, []byte(scalarToString(field.Value))); err != nil {
+					return err
+				}
+			default:
+				if err := writeElement(buffer, field.Key, "", field.Value); err != nil {
+					return err
+				}
+			}
+		}
+		buffer.WriteString("</" + name + ">")
+		return nil
+	case nil:
+		buffer.WriteString("<" + name + extraAttrs + "/>")
+		return nil
+	default:
+		buffer.WriteString("<" + name + extraAttrs + ">")
+		if err := xml.EscapeText(buffer, []byte(scalarToString(typedValue))); err != nil {
+			return err
+		}
+		buffer.WriteString("</" + name + ">")
+		return nil
+	}
+}
+
+// isXMLName checks the name against the Name production of XML 1.0, https://www.w3.org/TR/xml/#NT-Name
+func isXMLName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		if !isNameStartChar(r) && (i == 0 || !isNameChar(r)) {
+			return false
+		}
+	}
+	return true
+}
+
+func isNameStartChar(r rune) bool {
+	return r == ':' || r == '_' ||
+		(r >= 'A' && r <= 'Z') || (r >= 'a' && r <= 'z') ||
+		(r >= 0xC0 && r <= 0xD6) || (r >= 0xD8 && r <= 0xF6) || (r >= 0xF8 && r <= 0x2FF) ||
+		(r >= 0x370 && r <= 0x37D) || (r >= 0x37F && r <= 0x1FFF) || (r >= 0x200C && r <= 0x200D) ||
+		(r >= 0x2070 && r <= 0x218F) || (r >= 0x2C00 && r <= 0x2FEF) || (r >= 0x3001 && r <= 0xD7FF) ||
+		(r >= 0xF900 && r <= 0xFDCF) || (r >= 0xFDF0 && r <= 0xFFFD) || (r >= 0x10000 && r <= 0xEFFFF)
+}
+
+func isNameChar(r rune) bool {
+	return isNameStartChar(r) || r == '-' || r == '.' || (r >= '0' && r <= '9') || r == 0xB7 ||
+		(r >= 0x300 && r <= 0x36F) || (r >= 0x203F && r <= 0x2040)
+}
+
+func scalarToString(value interface{}) string {
+	switch typedValue := value.(type) {
+	case string:
+		return typedValue
+	case json.Number:
+		return typedValue.String()
+	case bool:
+		if typedValue {
+			return "true"
+		}
+		return "false"
+	case nil:
+		return ""
+	default:
+		encoded, _ := json.Marshal(typedValue)
+		return string(encoded)
+	}
+}
+
+func escapeAttr(value string) string {
+	buffer := &bytes.Buffer{}
+	xml.EscapeText(buffer, []byte(value))
+	return buffer.String()
+}
+
+// buildSecurityHeader builds the WS-Security UsernameToken header, the digest is Base64(SHA-1(nonce + created + password)).
+func buildSecurityHeader(username string, password string, passwordType string) (string, error) {
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	created := time.Now().UTC().Format("2006-01-02T15:04:05.000Z")
+	passwordValue, passwordTypeURI := password, WSSE_PASSWORD_TEXT
+	if passwordType == PASSWORD_TYPE_DIGEST {
+		digest := sha1.Sum(append(append(append([]byte{}, nonce...), []byte(created)...), []byte(password)...))
+		passwordValue, passwordTypeURI = base64.StdEncoding.EncodeToString(digest[:]), WSSE_PASSWORD_DIGEST
+	}
+
+	return `<wsse:Security xmlns:wsse="` + WSSE_NAMESPACE + `" xmlns:wsu="` + WSU_NAMESPACE + `" soap:mustUnderstand="1">` +
+		`<wsse:UsernameToken wsu:Id="UsernameToken-1">` +
+		`<wsse:Username>` + escapeAttr(username) + `</wsse:Username>` +
+		`<wsse:Password Type="` + passwordTypeURI + `">` + escapeAttr(passwordValue) + `</wsse:Password>` +
+		`<wsse:Nonce EncodingType="` + WSSE_BASE64_BINARY + `">` + base64.StdEncoding.EncodeToString(nonce) + `</wsse:Nonce>` +
+		`<wsu:Created>` + created + `</wsu:Created>` +
+		`</wsse:UsernameToken></wsse:Security>`, nil
+}
+
+// xmlNode is a generic XML element tree of the response.
+type xmlNode struct {
+	Name     xml.Name
+	Attrs    []xml.Attr
+	Children []*xmlNode
+	Text     string
+}
+
+func parseXMLNode(content []byte) (*xmlNode, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	var root *xmlNode
+	stack := make([]*xmlNode, 0)
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		switch typedToken := token.(type) {
+		case xml.StartElement:
+			node := &xmlNode{Name: typedToken.Name, Attrs: typedToken.Attr}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.Children = append(parent.Children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			if len(stack) > 0 {
+				stack[len(stack)-1].Text += string(typedToken)
+			}
+		}
+	}
+	if root == nil {
+		return nil, errors.New("empty xml document")
+	}
+	return root, nil
+}
+
+func (n *xmlNode) child(namespace string, local string) *xmlNode {
+	for _, child := range n.Children {
+		if child.Name.Local == local && (namespace == "" || child.Name.Space == namespace) {
+			return child
+		}
+	}
+	return nil
+}
+
+// toValue converts the element into JSON value, the element without attributes and children is converted into its text.
+func (n *xmlNode) toValue() interface{} {
+	attrs := make([]xml.Attr, 0, len(n.Attrs))
+	for _, attr := range n.Attrs {
+		if attr.Name.Space == xmlNamespaceDeclaration || (attr.Name.Space == "" && attr.Name.Local == xmlNamespaceDeclaration) {
+			continue
+		}
+		attrs = append(attrs, attr)
+	}
+	text := strings.TrimSpace(n.Text)
+	if len(attrs) == 0 && len(n.Children) == 0 {
+		return text
+	}
+	value := make(map[string]interface{})
+	for _, attr := range attrs {
+		value[ATTRIBUTE_KEY_PREFIX+attr.Name.Local] = attr.Value
+	}
+	for _, child := range n.Children {
+		childValue := child.toValue()
+		if existed, hit := value[child.Name.Local]; hit {
+			if array, isArray := existed.([]interface{}); isArray {
+				value[child.Name.Local] = append(array, childValue)
+			} else {
+				value[child.Name.Local] = []interface{}{existed, childValue}
+			}
+			continue
+		}
+		value[child.Name.Local] = childValue
+	}
+	if text != "" {
+		value[TEXT_KEY] = text
+	}
+	return value
+}
+
+// exportFault converts the SOAP 1.1 or SOAP 1.2 fault into the same shape.
+func exportFault(fault *xmlNode) map[string]interface{} {
+	exported := map[string]interface{}{"code": "", "message": "", "actor": "", "detail": nil}
+	textOf := func(node *xmlNode) string {
+		if node == nil {
+			return ""
+		}
+		return strings.TrimSpace(node.Text)
+	}
+	if fault.Name.Space == SOAP_12_ENVELOPE_NAMESPACE {
+		if code := fault.child("", "Code"); code != nil {
+			exported["code"] = textOf(code.child("", "Value"))
+			if subcode := code.child("", "Subcode"); subcode != nil {
+				exported["subcode"] = textOf(subcode.child("", "Value"))
+			}
+		}
+		if reason := fault.child("", "Reason"); reason != nil {
+			exported["message"] = textOf(reason.child("", "Text"))
+		}
+		exported["actor"] = textOf(fault.child("", "Role"))
+		if detail := fault.child("", "Detail"); detail != nil {
+			exported["detail"] = detail.toValue()
+		}
+		return exported
+	}
+	exported["code"] = textOf(fault.child("", "faultcode"))
+	exported["message"] = textOf(fault.child("", "faultstring"))
+	exported["actor"] = textOf(fault.child("", "faultactor"))
+	if detail := fault.child("", "detail"); detail != nil {
+		exported["detail"] = detail.toValue()
+	}
+	return exported
+}
@@ -0,0 +1,167 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soap
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (s *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &s.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate soap options
+	validate := validator.New()
+	if err := validate.Struct(s.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	if (s.ResourceOpts.Authentication == AUTHENTICATION_BASIC || s.ResourceOpts.Authentication == AUTHENTICATION_WS_SECURITY) && s.ResourceOpts.Username == "" {
+		return common.ValidateResult{Valid: false}, errors.New("username is required for " + s.ResourceOpts.Authentication + " authentication")
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (s *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &s.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate soap options
+	validate := validator.New()
+	if err := validate.Struct(s.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (s *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get soap client
+	client, err := s.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+
+	// test wsdl loading
+	if _, err := s.loadOperations(client); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (s *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get soap client
+	client, err := s.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	// list operations
+	operations, err := s.loadOperations(client)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"operations": operations},
+	}, nil
+}
+
+func (s *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get soap client
+	client, err := s.getClientWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get soap connection: " + err.Error())
+	}
+
+	// format soap action
+	if err := mapstructure.Decode(actionOptions, &s.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// resolve operation and build request envelope
+	operations, err := s.loadOperations(client)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	operation, err := findOperation(operations, s.ActionOpts.Operation, s.ActionOpts.Service, s.ActionOpts.Port)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	rawBody := s.ActionOpts.Body
+	if rawBody == "" {
+		rawBody = "{}"
+	}
+	body, err := decodeOrderedJSON(rawBody)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("invalid request body: " + err.Error())
+	}
+	securityHeader := ""
+	if s.ResourceOpts.Authentication == AUTHENTICATION_WS_SECURITY {
+		if securityHeader, err = buildSecurityHeader(s.ResourceOpts.Username, s.ResourceOpts.Password, s.ResourceOpts.PasswordType); err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+	}
+	envelope, err := buildEnvelope(operation, body, securityHeader)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// call operation
+	response, err := s.callOperation(client, operation, envelope, s.ActionOpts.Headers)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	extra := map[string]interface{}{"statusCode": response.StatusCode, "header": response.Header}
+	if response.Fault != nil {
+		extra["fault"] = true
+		return common.RuntimeResult{
+			Success: false,
+			Rows:    []map[string]interface{}{response.Fault},
+			Extra:   extra,
+		}, nil
+	}
+
+	// the response element content is the row, like the content of <GetPriceResponse>
+	row := response.Body
+	if len(response.Body) == 1 {
+		for _, value := range response.Body {
+			if content, isMap := value.(map[string]interface{}); isMap {
+				row = content
+			} else {
+				row = map[string]interface{}{"value": value}
+			}
+		}
+	}
+	extra["fault"] = false
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{row},
+		Extra:   extra,
+	}, nil
+}
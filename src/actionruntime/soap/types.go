@@ -0,0 +1,69 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soap
+
+const (
+	WSDL_SOURCE_URL  = "url"
+	WSDL_SOURCE_FILE = "file"
+)
+
+const (
+	AUTHENTICATION_NONE        = "none"
+	AUTHENTICATION_BASIC       = "basic"
+	AUTHENTICATION_WS_SECURITY = "wsSecurity"
+)
+
+const (
+	PASSWORD_TYPE_TEXT   = "text"
+	PASSWORD_TYPE_DIGEST = "digest"
+)
+
+const (
+	SOAP_VERSION_11 = "1.1"
+	SOAP_VERSION_12 = "1.2"
+)
+
+const (
+	STYLE_DOCUMENT = "document"
+	STYLE_RPC      = "rpc"
+)
+
+// Resource describe a SOAP service by its WSDL, the WSDL is fetched from the WSDLURL or given as the WSDLContent.
+// The Endpoint overrides the address of the service port in the WSDL.
+// The imported WSDL and schema documents are not followed, so the WSDL should be self-contained.
+type Resource struct {
+	WSDLSource     string `validate:"required,oneof=url file"`
+	WSDLURL        string `validate:"required_if=WSDLSource url"`
+	WSDLContent    string `validate:"required_if=WSDLSource file"`
+	Endpoint       string
+	Authentication string `validate:"omitempty,oneof=none basic wsSecurity"`
+	Username       string
+	Password       string
+	PasswordType   string `validate:"omitempty,oneof=text digest"` // for WS-Security UsernameToken, "text" by default
+	Headers        []map[string]string
+}
+
+// Action describe an operation call, the Body is a JSON object which is converted into the XML request message.
+// The JSON keys are the element names (the order of keys is kept), the "@" prefixed keys are attributes, the "#text" key
+// is the text content, and the array values are repeated elements.
+// For the document style operation, the Body is the content of the request element (or an object keyed by element
+// names when the message has multiple parts). For the rpc style operation, the Body is keyed by the part names.
+type Action struct {
+	Operation string `validate:"required"`
+	Service   string
+	Port      string
+	Body      string
+	Headers   []map[string]string
+}
@@ -0,0 +1,310 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package soap
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"strings"
+)
+
+const (
+	wsdlNamespace           = "http://schemas.xmlsoap.org/wsdl/"
+	soap11BindingNamespace  = "http://schemas.xmlsoap.org/wsdl/soap/"
+	soap12BindingNamespace  = "http://schemas.xmlsoap.org/wsdl/soap12/"
+	xmlSchemaNamespace      = "http://www.w3.org/2001/XMLSchema"
+	elementFormQualified    = "qualified"
+	xmlNamespaceDeclaration = "xmlns"
+)
+
+type wsdlDefinitions struct {
+	XMLName         xml.Name       `xml:"http://schemas.xmlsoap.org/wsdl/ definitions"`
+	TargetNamespace string         `xml:"targetNamespace,attr"`
+	Attrs           []xml.Attr     `xml:",any,attr"`
+	Types           wsdlTypes      `xml:"http://schemas.xmlsoap.org/wsdl/ types"`
+	Messages        []wsdlMessage  `xml:"http://schemas.xmlsoap.org/wsdl/ message"`
+	PortTypes       []wsdlPortType `xml:"http://schemas.xmlsoap.org/wsdl/ portType"`
+	Bindings        []wsdlBinding  `xml:"http://schemas.xmlsoap.org/wsdl/ binding"`
+	Services        []wsdlService  `xml:"http://schemas.xmlsoap.org/wsdl/ service"`
+}
+
+type wsdlTypes struct {
+	Schemas []xmlSchema `xml:"http://www.w3.org/2001/XMLSchema schema"`
+}
+
+type xmlSchema struct {
+	TargetNamespace    string     `xml:"targetNamespace,attr"`
+	ElementFormDefault string     `xml:"elementFormDefault,attr"`
+	Attrs              []xml.Attr `xml:",any,attr"`
+}
+
+type wsdlMessage struct {
+	Name  string     `xml:"name,attr"`
+	Parts []wsdlPart `xml:"http://schemas.xmlsoap.org/wsdl/ part"`
+}
+
+type wsdlPart struct {
+	Name    string `xml:"name,attr"`
+	Element string `xml:"element,attr"`
+	Type    string `xml:"type,attr"`
+}
+
+type wsdlPortType struct {
+	Name       string                  `xml:"name,attr"`
+	Operations []wsdlPortTypeOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+}
+
+type wsdlPortTypeOperation struct {
+	Name          string     `xml:"name,attr"`
+	Documentation string     `xml:"http://schemas.xmlsoap.org/wsdl/ documentation"`
+	Input         wsdlParams `xml:"http://schemas.xmlsoap.org/wsdl/ input"`
+	Output        wsdlParams `xml:"http://schemas.xmlsoap.org/wsdl/ output"`
+}
+
+type wsdlParams struct {
+	Message string `xml:"message,attr"`
+}
+
+type wsdlBinding struct {
+	Name          string                 `xml:"name,attr"`
+	Type          string                 `xml:"type,attr"`
+	SOAP11Binding *soapBinding           `xml:"http://schemas.xmlsoap.org/wsdl/soap/ binding"`
+	SOAP12Binding *soapBinding           `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ binding"`
+	Operations    []wsdlBindingOperation `xml:"http://schemas.xmlsoap.org/wsdl/ operation"`
+}
+
+type soapBinding struct {
+	Style string `xml:"style,attr"`
+}
+
+type wsdlBindingOperation struct {
+	Name            string            `xml:"name,attr"`
+	SOAP11Operation *soapOperation    `xml:"http://schemas.xmlsoap.org/wsdl/soap/ operation"`
+	SOAP12Operation *soapOperation    `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ operation"`
+	Input           wsdlBindingParams `xml:"http://schemas.xmlsoap.org/wsdl/ input"`
+}
+
+type soapOperation struct {
+	SOAPAction string `xml:"soapAction,attr"`
+	Style      string `xml:"style,attr"`
+}
+
+type wsdlBindingParams struct {
+	SOAP11Body *soapBody `xml:"http://schemas.xmlsoap.org/wsdl/soap/ body"`
+	SOAP12Body *soapBody `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ body"`
+}
+
+type soapBody struct {
+	Use       string `xml:"use,attr"`
+	Namespace string `xml:"namespace,attr"`
+}
+
+type wsdlService struct {
+	Name  string     `xml:"name,attr"`
+	Ports []wsdlPort `xml:"http://schemas.xmlsoap.org/wsdl/ port"`
+}
+
+type wsdlPort struct {
+	Name          string       `xml:"name,attr"`
+	Binding       string       `xml:"binding,attr"`
+	SOAP11Address *soapAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap/ address"`
+	SOAP12Address *soapAddress `xml:"http://schemas.xmlsoap.org/wsdl/soap12/ address"`
+}
+
+type soapAddress struct {
+	Location string `xml:"location,attr"`
+}
+
+// Part is a resolved message part, the Element or Type is the qualified name referenced by the part.
+type Part struct {
+	Name    string   `json:"name"`
+	Element xml.Name `json:"-"`
+	Type    xml.Name `json:"-"`
+	// ElementQualified is true when the children of the part element are namespace qualified (elementFormDefault="qualified")
+	ElementQualified bool `json:"-"`
+}
+
+func (p Part) MarshalJSON() ([]byte, error) {
+	exported := map[string]string{"name": p.Name}
+	if p.Element.Local != "" {
+		exported["element"] = "{" + p.Element.Space + "}" + p.Element.Local
+	}
+	if p.Type.Local != "" {
+		exported["type"] = "{" + p.Type.Space + "}" + p.Type.Local
+	}
+	return json.Marshal(exported)
+}
+
+// Operation is a SOAP operation resolved from the service, port, binding, port type and messages of the WSDL.
+type Operation struct {
+	Name          string `json:"name"`
+	Service       string `json:"service"`
+	Port          string `json:"port"`
+	Endpoint      string `json:"endpoint"`
+	SOAPAction    string `json:"soapAction"`
+	SOAPVersion   string `json:"soapVersion"`
+	Style         string `json:"style"`
+	BodyNamespace string `json:"bodyNamespace"`
+	Documentation string `json:"documentation"`
+	InputParts    []Part `json:"inputParts"`
+	OutputParts   []Part `json:"outputParts"`
+}
+
+// parseWSDL parses the WSDL 1.1 document into SOAP operations, the non SOAP bindings (like HTTP GET) are skipped.
+func parseWSDL(content []byte) ([]Operation, error) {
+	var definitions wsdlDefinitions
+	if err := xml.Unmarshal(content, &definitions); err != nil {
+		return nil, errors.New("invalid wsdl: " + err.Error())
+	}
+
+	// collect the namespace prefixes and the element form of schemas
+	prefixes := namespacePrefixes(definitions.Attrs)
+	qualifiedNamespaces := make(map[string]bool)
+	for _, schema := range definitions.Types.Schemas {
+		for prefix, namespace := range namespacePrefixes(schema.Attrs) {
+			if _, hit := prefixes[prefix]; !hit {
+				prefixes[prefix] = namespace
+			}
+		}
+		if schema.ElementFormDefault == elementFormQualified {
+			qualifiedNamespaces[schema.TargetNamespace] = true
+		}
+	}
+	resolveQName := func(qname string) xml.Name {
+		if qname == "" {
+			return xml.Name{}
+		}
+		prefix, local := "", qname
+		if index := strings.Index(qname, ":"); index >= 0 {
+			prefix, local = qname[:index], qname[index+1:]
+		}
+		namespace, hit := prefixes[prefix]
+		if !hit {
+			namespace = definitions.TargetNamespace
+		}
+		return xml.Name{Space: namespace, Local: local}
+	}
+
+	messages := make(map[string][]Part)
+	for _, message := range definitions.Messages {
+		parts := make([]Part, 0, len(message.Parts))
+		for _, part := range message.Parts {
+			resolved := Part{Name: part.Name, Element: resolveQName(part.Element), Type: resolveQName(part.Type)}
+			resolved.ElementQualified = qualifiedNamespaces[resolved.Element.Space]
+			parts = append(parts, resolved)
+		}
+		messages[message.Name] = parts
+	}
+	portTypes := make(map[string]wsdlPortType)
+	for _, portType := range definitions.PortTypes {
+		portTypes[portType.Name] = portType
+	}
+	bindings := make(map[string]wsdlBinding)
+	for _, binding := range definitions.Bindings {
+		bindings[binding.Name] = binding
+	}
+
+	operations := make([]Operation, 0)
+	for _, service := range definitions.Services {
+		for _, port := range service.Ports {
+			binding, hit := bindings[resolveQName(port.Binding).Local]
+			if !hit {
+				continue
+			}
+			soapVersion, bindingStyle, endpoint := "", "", ""
+			switch {
+			case binding.SOAP11Binding != nil && port.SOAP11Address != nil:
+				soapVersion, bindingStyle, endpoint = SOAP_VERSION_11, binding.SOAP11Binding.Style, port.SOAP11Address.Location
+			case binding.SOAP12Binding != nil && port.SOAP12Address != nil:
+				soapVersion, bindingStyle, endpoint = SOAP_VERSION_12, binding.SOAP12Binding.Style, port.SOAP12Address.Location
+			default:
+				continue
+			}
+			portType := portTypes[resolveQName(binding.Type).Local]
+			portTypeOperations := make(map[string]wsdlPortTypeOperation, len(portType.Operations))
+			for _, portTypeOperation := range portType.Operations {
+				portTypeOperations[portTypeOperation.Name] = portTypeOperation
+			}
+
+			for _, bindingOperation := range binding.Operations {
+				operation := Operation{
+					Name:        bindingOperation.Name,
+					Service:     service.Name,
+					Port:        port.Name,
+					Endpoint:    endpoint,
+					SOAPVersion: soapVersion,
+					Style:       bindingStyle,
+				}
+				soapOperation, body := bindingOperation.SOAP11Operation, bindingOperation.Input.SOAP11Body
+				if soapVersion == SOAP_VERSION_12 {
+					soapOperation, body = bindingOperation.SOAP12Operation, bindingOperation.Input.SOAP12Body
+				}
+				if soapOperation != nil {
+					operation.SOAPAction = soapOperation.SOAPAction
+					if soapOperation.Style != "" {
+						operation.Style = soapOperation.Style
+					}
+				}
+				if operation.Style == "" {
+					operation.Style = STYLE_DOCUMENT
+				}
+				if body != nil {
+					operation.BodyNamespace = body.Namespace
+				}
+				if operation.BodyNamespace == "" {
+					operation.BodyNamespace = definitions.TargetNamespace
+				}
+				if portTypeOperation, hit := portTypeOperations[bindingOperation.Name]; hit {
+					operation.Documentation = strings.TrimSpace(portTypeOperation.Documentation)
+					operation.InputParts = messages[resolveQName(portTypeOperation.Input.Message).Local]
+					operation.OutputParts = messages[resolveQName(portTypeOperation.Output.Message).Local]
+				}
+				operations = append(operations, operation)
+			}
+		}
+	}
+	if len(operations) == 0 {
+		return nil, errors.New("no soap operation found in wsdl")
+	}
+	return operations, nil
+}
+
+// namespacePrefixes collects the namespace declarations, the default namespace is keyed by empty prefix.
+func namespacePrefixes(attrs []xml.Attr) map[string]string {
+	prefixes := make(map[string]string)
+	for _, attr := range attrs {
+		if attr.Name.Space == xmlNamespaceDeclaration {
+			prefixes[attr.Name.Local] = attr.Value
+		} else if attr.Name.Space == "" && attr.Name.Local == xmlNamespaceDeclaration {
+			prefixes[""] = attr.Value
+		}
+	}
+	return prefixes
+}
+
+// findOperation finds the operation by name, the service and port narrow down the candidates when they are given.
+func findOperation(operations []Operation, name string, service string, port string) (Operation, error) {
+	for _, operation := range operations {
+		if operation.Name != name {
+			continue
+		}
+		if (service != "" && operation.Service != service) || (port != "" && operation.Port != port) {
+			continue
+		}
+		return operation, nil
+	}
+	return Operation{}, errors.New("operation not found in wsdl: " + name)
+}
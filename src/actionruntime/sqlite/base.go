@@ -0,0 +1,139 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"net/url"
+	"os"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	illadrivesdk "github.com/illacloud/builder-backend/src/utils/illadrivesdk"
+	"github.com/mitchellh/mapstructure"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const (
+	tableSQLStr  = "SELECT name FROM sqlite_master WHERE type IN ('table', 'view') AND name NOT LIKE 'sqlite_%' ORDER BY name"
+	columnSQLStr = "SELECT name, type FROM pragma_table_info(?)"
+)
+
+// getConnectionWithOptions open the sqlite database file, the returned release function closes the connection
+// and cleans the temporary database file which downloaded from team drive.
+// The limit of attached databases is bound to the connection, so a single connection is returned instead of the pool.
+func (s *Connector) getConnectionWithOptions(resourceOptions map[string]interface{}) (*sql.Conn, func(), error) {
+	if err := mapstructure.Decode(resourceOptions, &s.Resource); err != nil {
+		return nil, nil, err
+	}
+
+	// resolve database file, the local file must be stored in data file directory
+	var databaseFile string
+	cleanDatabaseFile := func() {}
+	if !s.Resource.IsDriveSource() {
+		resolvedPath, errInResolve := common.ResolveDataFilePath(s.Resource.Path)
+		if errInResolve != nil {
+			return nil, nil, errInResolve
+		}
+		databaseFile = resolvedPath
+	} else {
+		driveAPI := illadrivesdk.NewIllaDriveRestAPI(s.Resource.TeamID, 0, 0, 0)
+		tempFile, errInDownload := driveAPI.DownloadFileToTemp(s.Resource.FileID, "illa-sqlite-*.db")
+		if errInDownload != nil {
+			return nil, nil, errInDownload
+		}
+		databaseFile = tempFile
+		cleanDatabaseFile = func() { os.Remove(tempFile) }
+	}
+
+	// open database, the "rw" mode will not create a new database file when path is wrong
+	dsn := "file:" + (&url.URL{Path: databaseFile}).EscapedPath() + "?_pragma=busy_timeout(5000)"
+	if s.Resource.IsReadOnly() {
+		dsn += "&mode=ro"
+	} else {
+		dsn += "&mode=rw"
+	}
+	db, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		cleanDatabaseFile()
+		return nil, nil, err
+	}
+	conn, err := db.Conn(context.Background())
+	if err != nil {
+		db.Close()
+		cleanDatabaseFile()
+		return nil, nil, err
+	}
+
+	// disable ATTACH, the attached database file can be any file on the host
+	if _, err := sqlite.Limit(conn, sqlite3.SQLITE_LIMIT_ATTACHED, 0); err != nil {
+		conn.Close()
+		db.Close()
+		cleanDatabaseFile()
+		return nil, nil, err
+	}
+	release := func() {
+		conn.Close()
+		db.Close()
+		cleanDatabaseFile()
+	}
+	return conn, release, nil
+}
+
+func tablesInfo(conn *sql.Conn) []string {
+	tableNames := make([]string, 0, 0)
+	tableRows, err := conn.QueryContext(context.Background(), tableSQLStr)
+	if err != nil {
+		return nil
+	}
+	defer tableRows.Close()
+	for tableRows.Next() {
+		var tableName string
+		err = tableRows.Scan(&tableName)
+		if err != nil {
+			return nil
+		}
+
+		tableNames = append(tableNames, tableName)
+	}
+
+	return tableNames
+}
+
+func fieldsInfo(conn *sql.Conn, tableNames []string) map[string]interface{} {
+	columns := make(map[string]interface{})
+	for _, tableName := range tableNames {
+		columnRows, err := conn.QueryContext(context.Background(), columnSQLStr, tableName)
+		if err != nil {
+			return nil
+		}
+		tables := make(map[string]interface{})
+
+		for columnRows.Next() {
+			var columnName, columnType string
+			err = columnRows.Scan(&columnName, &columnType)
+			if err != nil {
+				columnRows.Close()
+				return nil
+			}
+			tables[columnName] = map[string]string{"data_type": columnType}
+
+		}
+		columnRows.Close()
+		columns[tableName] = tables
+	}
+	return columns
+}
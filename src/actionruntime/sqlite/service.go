@@ -0,0 +1,166 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	parser_sql "github.com/illacloud/builder-backend/src/utils/parser/sql"
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	Resource Options
+	Action   Query
+}
+
+func (s *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &s.Resource); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate sqlite options
+	validate := validator.New()
+	if err := validate.Struct(s.Resource); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (s *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format sql options
+	if err := mapstructure.Decode(actionOptions, &s.Action); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate sqlite options
+	validate := validator.New()
+	if err := validate.Struct(s.Action); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (s *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	// get sqlite connection
+	conn, release, err := s.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer release()
+
+	// test sqlite connection
+	if err := conn.PingContext(context.Background()); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (s *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	// get sqlite connection
+	conn, release, err := s.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer release()
+
+	// test sqlite connection
+	if err := conn.PingContext(context.Background()); err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	columns := fieldsInfo(conn, tablesInfo(conn))
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  columns,
+	}, nil
+}
+
+func (s *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	// get sqlite connection
+	conn, release, err := s.getConnectionWithOptions(resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get sqlite connection: " + err.Error())
+	}
+	defer release()
+
+	// format query
+	if err := mapstructure.Decode(actionOptions, &s.Action); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// set context field
+	errInSetRawQuery := s.Action.SetRawQueryAndContext(rawActionOptions)
+	if errInSetRawQuery != nil {
+		return common.RuntimeResult{Success: false}, errInSetRawQuery
+	}
+
+	// run sqlite query
+	queryResult := common.RuntimeResult{
+		Success: false,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{},
+	}
+	// check if s.Action.Query is select query
+	sqlEscaper := parser_sql.NewSQLEscaper(resourcelist.TYPE_SQLITE_ID)
+	escapedSQL, sqlArgs, errInEscapeSQL := sqlEscaper.EscapeSQLActionTemplate(s.Action.RawQuery, s.Action.Context, s.Action.IsSafeMode())
+	if errInEscapeSQL != nil {
+		return queryResult, errInEscapeSQL
+	}
+	lexer := parser_sql.NewLexer(s.Action.Query)
+	isSelectQuery, err := parser_sql.IsSelectSQL(lexer)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// start a default context
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+
+	// fetch data, the sqlArgs is empty in unsafe mode
+	if isSelectQuery {
+		rows, err := conn.QueryContext(ctx, escapedSQL, sqlArgs...)
+		if err != nil {
+			return queryResult, err
+		}
+		defer rows.Close()
+		mapRes, err := common.RetrieveToMap(rows)
+		if err != nil {
+			return queryResult, err
+		}
+		queryResult.Success = true
+		queryResult.Rows = mapRes
+	} else {
+		execResult, err := conn.ExecContext(ctx, escapedSQL, sqlArgs...)
+		if err != nil {
+			return queryResult, err
+		}
+		affectedRows, err := execResult.RowsAffected()
+		if err != nil {
+			return queryResult, err
+		}
+		queryResult.Success = true
+		queryResult.Extra["message"] = fmt.Sprintf("Affeted %d rows.", affectedRows)
+	}
+
+	return queryResult, nil
+}
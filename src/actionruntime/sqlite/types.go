@@ -0,0 +1,83 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sqlite
+
+import (
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+)
+
+const (
+	FIELD_CONTEXT = "context"
+	FIELD_QUERY   = "query"
+)
+
+const (
+	SOURCE_PATH  = "path"
+	SOURCE_DRIVE = "drive"
+)
+
+type Options struct {
+	Source   string `validate:"required,oneof=path drive"`
+	Path     string `validate:"required_if=Source path"`
+	FileID   string `validate:"required_if=Source drive"`
+	ReadOnly bool
+	TeamID   int
+}
+
+func (o *Options) IsDriveSource() bool {
+	return o.Source == SOURCE_DRIVE
+}
+
+// the database file downloaded from team drive is a temporary copy, so it is always opened in read-only mode.
+func (o *Options) IsReadOnly() bool {
+	return o.ReadOnly || o.IsDriveSource()
+}
+
+type Query struct {
+	Mode     string `validate:"required,oneof=gui sql sql-safe"`
+	Query    string
+	RawQuery string
+	Context  map[string]interface{}
+}
+
+func (q *Query) IsSafeMode() bool {
+	return q.Mode == common.MODE_SQL_SAFE
+}
+
+func (q *Query) SetRawQueryAndContext(rawTemplate map[string]interface{}) error {
+	queryRaw, hit := rawTemplate[FIELD_QUERY]
+	if !hit {
+		return errors.New("missing query field for SetRawQueryAndContext() in query")
+	}
+	queryAsserted, assertPass := queryRaw.(string)
+	if !assertPass {
+		return errors.New("query field assert failed in SetRawQueryAndContext() method")
+
+	}
+	q.RawQuery = queryAsserted
+	contextRaw, hit := rawTemplate[FIELD_CONTEXT]
+	if !hit {
+		return errors.New("missing context field SetRawQueryAndContext() in query")
+	}
+	contextAsserted, assertPass := contextRaw.(map[string]interface{})
+	if !assertPass {
+		return errors.New("context field assert failed in SetRawQueryAndContext() method")
+
+	}
+	q.Context = contextAsserted
+	return nil
+}
@@ -0,0 +1,234 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+	"github.com/mitchellh/mapstructure"
+)
+
+// VectorStore is implemented by the pgvector and qdrant backends.
+type VectorStore interface {
+	Upsert(ctx context.Context, collection string, points []Point, vectorName string) error
+	Query(ctx context.Context, collection string, args QueryCommandArgs) ([]map[string]interface{}, error)
+	Delete(ctx context.Context, collection string, ids []interface{}, filter map[string]interface{}) error
+	ListCollections(ctx context.Context) ([]map[string]interface{}, error)
+	Close() error
+}
+
+func (v *Connector) getStoreWithOptions(ctx context.Context, resourceOptions map[string]interface{}) (VectorStore, error) {
+	if err := mapstructure.Decode(resourceOptions, &v.ResourceOpts); err != nil {
+		return nil, err
+	}
+
+	switch v.ResourceOpts.Backend {
+	case BACKEND_PGVECTOR:
+		return newPGVectorStore(ctx, v.ResourceOpts.Postgres)
+	case BACKEND_QDRANT:
+		return newQdrantStore(v.ResourceOpts.Qdrant), nil
+	default:
+		return nil, errors.New("unsupported vector database backend: " + v.ResourceOpts.Backend)
+	}
+}
+
+// validateBackend validates the options of the selected backend and embedding provider.
+func (r *Resource) validateBackend() error {
+	switch r.Backend {
+	case BACKEND_PGVECTOR:
+		if r.Postgres.Host == "" || r.Postgres.Port == "" || r.Postgres.DatabaseName == "" || r.Postgres.DatabaseUsername == "" {
+			return errors.New("host, port, database name and username are required for pgvector backend")
+		}
+	case BACKEND_QDRANT:
+		if r.Qdrant.URL == "" {
+			return errors.New("url is required for qdrant backend")
+		}
+	}
+	switch r.Embedding.Provider {
+	case EMBEDDING_PROVIDER_HFENDPOINT:
+		if r.Embedding.ResourceID <= 0 {
+			return errors.New("resource is required for hfendpoint embedding provider")
+		}
+	case EMBEDDING_PROVIDER_OPENAI:
+		if r.Embedding.ResourceID <= 0 || r.Embedding.Model == "" {
+			return errors.New("resource and model are required for openai embedding provider")
+		}
+	}
+	return nil
+}
+
+// resolveEndpoint resolves the endpoint and token from the options of the referenced embedding resource.
+func (o *EmbeddingOptions) resolveEndpoint() (string, string, error) {
+	if o.ResourceOptions == nil {
+		return "", "", errors.New("embedding resource is not loaded, please check the embedding resource exists")
+	}
+	switch o.Provider {
+	case EMBEDDING_PROVIDER_HFENDPOINT:
+		if o.ResourceType != resourcelist.TYPE_HFENDPOINT {
+			return "", "", errors.New("hfendpoint embedding provider requires a hfendpoint resource, got " + o.ResourceType)
+		}
+		hfendpointOptions := struct {
+			Endpoint string
+			Token    string
+		}{}
+		if err := mapstructure.Decode(o.ResourceOptions, &hfendpointOptions); err != nil {
+			return "", "", err
+		}
+		return hfendpointOptions.Endpoint, hfendpointOptions.Token, nil
+	case EMBEDDING_PROVIDER_OPENAI:
+		if o.ResourceType != resourcelist.TYPE_RESTAPI {
+			return "", "", errors.New("openai embedding provider requires a rest api resource, got " + o.ResourceType)
+		}
+		restAPIOptions := struct {
+			BaseURL        string
+			Authentication string
+			AuthContent    map[string]string
+		}{}
+		if err := mapstructure.Decode(o.ResourceOptions, &restAPIOptions); err != nil {
+			return "", "", err
+		}
+		switch restAPIOptions.Authentication {
+		case "", EMBEDDING_AUTHENTICATION_NONE:
+			return restAPIOptions.BaseURL, "", nil
+		case EMBEDDING_AUTHENTICATION_BEARER:
+			return restAPIOptions.BaseURL, restAPIOptions.AuthContent["token"], nil
+		default:
+			return "", "", errors.New("openai embedding provider requires the rest api resource with bearer authentication")
+		}
+	default:
+		return "", "", errors.New("embedding provider is not configured, the vector is required")
+	}
+}
+
+// embed computes the vectors of texts with the embedding provider, the vectors keep the order of texts.
+func embed(ctx context.Context, options EmbeddingOptions, texts []string) ([][]float64, error) {
+	endpoint, token, err := options.resolveEndpoint()
+	if err != nil {
+		return nil, err
+	}
+	client := resty.New().SetTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	switch options.Provider {
+	case EMBEDDING_PROVIDER_HFENDPOINT:
+		// the feature-extraction pipeline responds the sentence vectors, or the token vectors which are mean pooled
+		resp, err := client.R().SetContext(ctx).SetAuthToken(token).
+			SetBody(map[string]interface{}{"inputs": texts}).
+			Post(endpoint)
+		if err := checkResponse("hfendpoint", resp, err); err != nil {
+			return nil, err
+		}
+		var sentenceVectors [][]float64
+		if err := json.Unmarshal(resp.Body(), &sentenceVectors); err == nil && len(sentenceVectors) == len(texts) {
+			return sentenceVectors, nil
+		}
+		var tokenVectors [][][]float64
+		if err := json.Unmarshal(resp.Body(), &tokenVectors); err != nil || len(tokenVectors) != len(texts) {
+			return nil, errors.New("unexpected hfendpoint embedding response, a feature-extraction model is required")
+		}
+		vectors := make([][]float64, 0, len(tokenVectors))
+		for _, tokens := range tokenVectors {
+			vectors = append(vectors, meanPool(tokens))
+		}
+		return vectors, nil
+	case EMBEDDING_PROVIDER_OPENAI:
+		request := client.R().SetContext(ctx).SetBody(map[string]interface{}{"model": options.Model, "input": texts})
+		if token != "" {
+			request.SetAuthToken(token)
+		}
+		resp, err := request.Post(strings.TrimRight(endpoint, "/") + "/embeddings")
+		if err := checkResponse("openai", resp, err); err != nil {
+			return nil, err
+		}
+		embeddingResponse := struct {
+			Data []struct {
+				Index     int       `json:"index"`
+				Embedding []float64 `json:"embedding"`
+			} `json:"data"`
+		}{}
+		if err := json.Unmarshal(resp.Body(), &embeddingResponse); err != nil {
+			return nil, err
+		}
+		if len(embeddingResponse.Data) != len(texts) {
+			return nil, errors.New("unexpected openai embedding response, got " + strconv.Itoa(len(embeddingResponse.Data)) + " embeddings for " + strconv.Itoa(len(texts)) + " texts")
+		}
+		sort.Slice(embeddingResponse.Data, func(i, j int) bool { return embeddingResponse.Data[i].Index < embeddingResponse.Data[j].Index })
+		vectors := make([][]float64, 0, len(embeddingResponse.Data))
+		for _, data := range embeddingResponse.Data {
+			vectors = append(vectors, data.Embedding)
+		}
+		return vectors, nil
+	default:
+		return nil, errors.New("embedding provider is not configured, the vector is required")
+	}
+}
+
+func meanPool(tokens [][]float64) []float64 {
+	if len(tokens) == 0 {
+		return []float64{}
+	}
+	pooled := make([]float64, len(tokens[0]))
+	for _, token := range tokens {
+		for i := range pooled {
+			if i < len(token) {
+				pooled[i] += token[i]
+			}
+		}
+	}
+	for i := range pooled {
+		pooled[i] /= float64(len(tokens))
+	}
+	return pooled
+}
+
+// checkResponse converts the failed HTTP response into error with the response body.
+func checkResponse(service string, resp *resty.Response, err error) error {
+	if err != nil {
+		return err
+	}
+	if resp.IsSuccess() {
+		return nil
+	}
+	body := strings.TrimSpace(string(resp.Body()))
+	if len(body) > 512 {
+		body = body[:512] + "..."
+	}
+	return errors.New(service + " error: " + resp.Status() + " " + body)
+}
+
+// normalizeID converts the integral JSON number into int64, the qdrant point id is an unsigned integer or UUID.
+func normalizeID(id interface{}) interface{} {
+	if number, ok := id.(float64); ok && number == math.Trunc(number) {
+		return int64(number)
+	}
+	return id
+}
+
+// isSimpleFilter reports whether the filter is a {"key": "value"} equality filter instead of a native qdrant filter.
+func isSimpleFilter(filter map[string]interface{}) bool {
+	for _, key := range []string{"must", "should", "must_not", "min_should"} {
+		if _, hit := filter[key]; hit {
+			return false
+		}
+	}
+	return true
+}
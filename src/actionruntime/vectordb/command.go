@@ -0,0 +1,149 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectordb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type CommandExecutor struct {
+	ctx       context.Context
+	store     VectorStore
+	command   Action
+	embedding EmbeddingOptions
+}
+
+func (c *CommandExecutor) upsert() (common.RuntimeResult, error) {
+	var upsertCommandArgs UpsertCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &upsertCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate vector upsert action options
+	validate := validator.New()
+	if err := validate.Struct(upsertCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	// compute the vectors of points without vector in a single embedding request
+	texts := make([]string, 0)
+	indexes := make([]int, 0)
+	for i, point := range upsertCommandArgs.Points {
+		if len(point.Vector) > 0 {
+			continue
+		}
+		if point.Text == "" {
+			return common.RuntimeResult{Success: false}, errors.New("the vector or text of point is required")
+		}
+		texts = append(texts, point.Text)
+		indexes = append(indexes, i)
+	}
+	if len(texts) > 0 {
+		vectors, err := embed(c.ctx, c.embedding, texts)
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		for i, vector := range vectors {
+			upsertCommandArgs.Points[indexes[i]].Vector = vector
+		}
+	}
+
+	// upsert points
+	if err := c.store.Upsert(c.ctx, c.command.Collection, upsertCommandArgs.Points, upsertCommandArgs.VectorName); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	ids := make([]interface{}, 0, len(upsertCommandArgs.Points))
+	for _, point := range upsertCommandArgs.Points {
+		ids = append(ids, normalizeID(point.ID))
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{{"ids": ids, "upserted": len(ids), "embedded": len(texts)}},
+		Extra:   map[string]interface{}{"message": "upsert vectors successfully"},
+	}, nil
+}
+
+func (c *CommandExecutor) query() (common.RuntimeResult, error) {
+	var queryCommandArgs QueryCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &queryCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate vector query action options
+	validate := validator.New()
+	if err := validate.Struct(queryCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if queryCommandArgs.TopK <= 0 {
+		queryCommandArgs.TopK = DEFAULT_TOP_K
+	}
+	if queryCommandArgs.TopK > MAX_TOP_K {
+		queryCommandArgs.TopK = MAX_TOP_K
+	}
+
+	// compute the query vector from text
+	if len(queryCommandArgs.Vector) == 0 {
+		if queryCommandArgs.Text == "" {
+			return common.RuntimeResult{Success: false}, errors.New("the vector or text of query is required")
+		}
+		vectors, err := embed(c.ctx, c.embedding, []string{queryCommandArgs.Text})
+		if err != nil {
+			return common.RuntimeResult{Success: false}, err
+		}
+		queryCommandArgs.Vector = vectors[0]
+	}
+
+	// query nearest neighbours
+	rows, err := c.store.Query(c.ctx, c.command.Collection, queryCommandArgs)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    rows,
+	}, nil
+}
+
+func (c *CommandExecutor) delete() (common.RuntimeResult, error) {
+	var deleteCommandArgs DeleteCommandArgs
+	if err := mapstructure.Decode(c.command.CommandArgs, &deleteCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	// validate vector delete action options
+	validate := validator.New()
+	if err := validate.Struct(deleteCommandArgs); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+	if len(deleteCommandArgs.IDs) == 0 && len(deleteCommandArgs.Filter) == 0 {
+		return common.RuntimeResult{Success: false}, errors.New("the ids or filter of delete is required")
+	}
+
+	// delete vectors
+	if err := c.store.Delete(c.ctx, c.command.Collection, deleteCommandArgs.IDs, deleteCommandArgs.Filter); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	return common.RuntimeResult{
+		Success: true,
+		Rows:    []map[string]interface{}{},
+		Extra:   map[string]interface{}{"message": "delete vectors successfully"},
+	}, nil
+}
@@ -0,0 +1,282 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+	"github.com/jackc/pgx/v5"
+)
+
+const pgvectorColumnsSQL = `SELECT n.nspname, c.relname, a.attname, format_type(a.atttypid, a.atttypmod)
+FROM pg_attribute a
+JOIN pg_class c ON c.oid = a.attrelid
+JOIN pg_namespace n ON n.oid = c.relnamespace
+JOIN pg_type t ON t.oid = a.atttypid
+WHERE t.typname = 'vector' AND c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+AND n.nspname NOT IN ('pg_catalog', 'information_schema')
+ORDER BY n.nspname, c.relname, a.attnum`
+
+// pgvectorOperators are the distance operators of pgvector, the inner product operator returns the negative inner product.
+var pgvectorOperators = map[string]string{
+	METRIC_COSINE:        "<=>",
+	METRIC_L2:            "<->",
+	METRIC_INNER_PRODUCT: "<#>",
+}
+
+type pgvectorStore struct {
+	conn          *pgx.Conn
+	idColumn      string
+	vectorColumn  string
+	payloadColumn string
+}
+
+func newPGVectorStore(ctx context.Context, options PostgresOptions) (*pgvectorStore, error) {
+	// @NOTE: the postgres connection string must be escaped in password, same as the postgresql connector
+	escapedPassword := url.QueryEscape(options.DatabasePassword)
+	dsn := fmt.Sprintf("postgresql://%s:%s@%s:%s/%s", url.QueryEscape(options.DatabaseUsername),
+		escapedPassword, options.Host, options.Port, options.DatabaseName)
+	if options.SSL.SSL {
+		dsn += "?sslmode=require"
+	} else {
+		dsn += "?sslmode=disable"
+	}
+	pgCfg, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if options.SSL.SSL {
+		tlsConfig, err := common.BuildTLSConfig(options.SSL.VerificationMode, options.SSL.CACert, options.SSL.ClientCert, options.SSL.ClientKey)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.ServerName = options.Host
+		pgCfg.TLSConfig = tlsConfig
+		pgCfg.Fallbacks = nil
+	}
+	conn, err := pgx.ConnectConfig(ctx, pgCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	store := &pgvectorStore{conn: conn, idColumn: DEFAULT_ID_COLUMN, vectorColumn: DEFAULT_VECTOR_COLUMN, payloadColumn: DEFAULT_PAYLOAD_COLUMN}
+	if options.IDColumn != "" {
+		store.idColumn = options.IDColumn
+	}
+	if options.VectorColumn != "" {
+		store.vectorColumn = options.VectorColumn
+	}
+	if options.PayloadColumn != "" {
+		store.payloadColumn = options.PayloadColumn
+	}
+	return store, nil
+}
+
+func (p *pgvectorStore) Close() error {
+	return p.conn.Close(context.Background())
+}
+
+// tableIdentifier quotes the table name, the schema qualified name like "public.documents" is split by dot.
+func tableIdentifier(collection string) string {
+	return pgx.Identifier(strings.Split(collection, ".")).Sanitize()
+}
+
+func (p *pgvectorStore) vectorIdentifier(vectorName string) string {
+	if vectorName != "" {
+		return pgx.Identifier{vectorName}.Sanitize()
+	}
+	return pgx.Identifier{p.vectorColumn}.Sanitize()
+}
+
+// formatVector formats the vector in pgvector text representation like "[1,2,3]".
+func formatVector(vector []float64) string {
+	elements := make([]string, 0, len(vector))
+	for _, element := range vector {
+		elements = append(elements, strconv.FormatFloat(element, 'g', -1, 32))
+	}
+	return "[" + strings.Join(elements, ",") + "]"
+}
+
+func (p *pgvectorStore) Upsert(ctx context.Context, collection string, points []Point, vectorName string) error {
+	idColumn, vectorColumn, payloadColumn := pgx.Identifier{p.idColumn}.Sanitize(), p.vectorIdentifier(vectorName), pgx.Identifier{p.payloadColumn}.Sanitize()
+	upsertSQL := fmt.Sprintf("INSERT INTO %s (%s, %s, %s) VALUES ($1, $2::vector, $3::jsonb) ON CONFLICT (%s) DO UPDATE SET %s = EXCLUDED.%s, %s = EXCLUDED.%s",
+		tableIdentifier(collection), idColumn, vectorColumn, payloadColumn, idColumn, vectorColumn, vectorColumn, payloadColumn, payloadColumn)
+
+	// upsert all points in a transaction
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	batch := &pgx.Batch{}
+	for _, point := range points {
+		payload := point.Payload
+		if payload == nil {
+			payload = map[string]interface{}{}
+		}
+		payloadJSON, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		batch.Queue(upsertSQL, normalizeID(point.ID), formatVector(point.Vector), string(payloadJSON))
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// Query returns the nearest neighbours, the score is the cosine similarity, the inner product, or the L2 distance.
+// The score threshold is the minimum similarity for cosine and inner product, and the maximum distance for L2.
+func (p *pgvectorStore) Query(ctx context.Context, collection string, args QueryCommandArgs) ([]map[string]interface{}, error) {
+	metric := args.Metric
+	if metric == "" {
+		metric = METRIC_COSINE
+	}
+	distance := p.vectorIdentifier(args.VectorName) + " " + pgvectorOperators[metric] + " $1::vector"
+	score := distance
+	switch metric {
+	case METRIC_COSINE:
+		score = "1 - (" + distance + ")"
+	case METRIC_INNER_PRODUCT:
+		score = "(" + distance + ") * -1"
+	}
+
+	// build conditions of payload filter and score threshold
+	params := []interface{}{formatVector(args.Vector)}
+	conditions := make([]string, 0)
+	if len(args.Filter) > 0 {
+		if !isSimpleFilter(args.Filter) {
+			return nil, errors.New("the native qdrant filter is not supported by pgvector backend, use {\"key\": \"value\"} filter")
+		}
+		filterJSON, err := json.Marshal(args.Filter)
+		if err != nil {
+			return nil, err
+		}
+		params = append(params, string(filterJSON))
+		conditions = append(conditions, pgx.Identifier{p.payloadColumn}.Sanitize()+" @> $"+strconv.Itoa(len(params))+"::jsonb")
+	}
+	if args.ScoreThreshold != nil {
+		params = append(params, *args.ScoreThreshold)
+		if metric == METRIC_L2 {
+			conditions = append(conditions, score+" <= $"+strconv.Itoa(len(params)))
+		} else {
+			conditions = append(conditions, score+" >= $"+strconv.Itoa(len(params)))
+		}
+	}
+	selectColumns := []string{pgx.Identifier{p.idColumn}.Sanitize(), pgx.Identifier{p.payloadColumn}.Sanitize(), score}
+	if args.WithVector {
+		selectColumns = append(selectColumns, p.vectorIdentifier(args.VectorName)+"::text")
+	}
+	querySQL := "SELECT " + strings.Join(selectColumns, ", ") + " FROM " + tableIdentifier(collection)
+	if len(conditions) > 0 {
+		querySQL += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	querySQL += " ORDER BY " + distance + " LIMIT " + strconv.Itoa(args.TopK)
+
+	rows, err := p.conn.Query(ctx, querySQL, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		values, err := rows.Values()
+		if err != nil {
+			return nil, err
+		}
+		result := map[string]interface{}{"id": exportPGValue(values[0]), "payload": values[1], "score": values[2]}
+		if args.WithVector {
+			var vector []float64
+			if text, ok := values[3].(string); ok {
+				if err := json.Unmarshal([]byte(text), &vector); err != nil {
+					return nil, err
+				}
+			}
+			result["vector"] = vector
+		}
+		results = append(results, result)
+	}
+	return results, rows.Err()
+}
+
+// exportPGValue converts the uuid id into string.
+func exportPGValue(value interface{}) interface{} {
+	if bytes, ok := value.([16]byte); ok {
+		return uuid.UUID(bytes).String()
+	}
+	return value
+}
+
+func (p *pgvectorStore) Delete(ctx context.Context, collection string, ids []interface{}, filter map[string]interface{}) error {
+	// delete by filter
+	if len(ids) == 0 {
+		if !isSimpleFilter(filter) {
+			return errors.New("the native qdrant filter is not supported by pgvector backend, use {\"key\": \"value\"} filter")
+		}
+		filterJSON, err := json.Marshal(filter)
+		if err != nil {
+			return err
+		}
+		_, err = p.conn.Exec(ctx, "DELETE FROM "+tableIdentifier(collection)+" WHERE "+pgx.Identifier{p.payloadColumn}.Sanitize()+" @> $1::jsonb", string(filterJSON))
+		return err
+	}
+
+	// delete by ids in a transaction
+	tx, err := p.conn.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+	deleteSQL := "DELETE FROM " + tableIdentifier(collection) + " WHERE " + pgx.Identifier{p.idColumn}.Sanitize() + " = $1"
+	batch := &pgx.Batch{}
+	for _, id := range ids {
+		batch.Queue(deleteSQL, normalizeID(id))
+	}
+	if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// ListCollections lists the tables with vector columns.
+func (p *pgvectorStore) ListCollections(ctx context.Context) ([]map[string]interface{}, error) {
+	rows, err := p.conn.Query(ctx, pgvectorColumnsSQL)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	collections := make([]map[string]interface{}, 0)
+	for rows.Next() {
+		var schema, table, column, columnType string
+		if err := rows.Scan(&schema, &table, &column, &columnType); err != nil {
+			return nil, err
+		}
+		collections = append(collections, map[string]interface{}{
+			"name":         schema + "." + table,
+			"vectorColumn": column,
+			"vectorType":   columnType,
+		})
+	}
+	return collections, rows.Err()
+}
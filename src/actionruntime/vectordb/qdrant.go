@@ -0,0 +1,195 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectordb
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"strings"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+)
+
+type qdrantStore struct {
+	client *resty.Client
+}
+
+func newQdrantStore(options QdrantOptions) *qdrantStore {
+	client := resty.New().
+		SetBaseURL(strings.TrimRight(options.URL, "/")).
+		SetTimeout(common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	if options.APIKey != "" {
+		client.SetHeader("api-key", options.APIKey)
+	}
+	return &qdrantStore{client: client}
+}
+
+func (q *qdrantStore) Close() error {
+	return nil
+}
+
+// qdrantFilter converts the {"key": "value"} filter into qdrant "must" match conditions, the native filter is kept.
+func qdrantFilter(filter map[string]interface{}) map[string]interface{} {
+	if !isSimpleFilter(filter) {
+		return filter
+	}
+	conditions := make([]map[string]interface{}, 0, len(filter))
+	for key, value := range filter {
+		conditions = append(conditions, map[string]interface{}{"key": key, "match": map[string]interface{}{"value": value}})
+	}
+	return map[string]interface{}{"must": conditions}
+}
+
+// qdrantResult is the result envelope of qdrant API like {"result": ..., "status": "ok", "time": 0.001}.
+type qdrantResult struct {
+	Result json.RawMessage `json:"result"`
+}
+
+func (q *qdrantStore) do(ctx context.Context, method string, path string, body interface{}) (json.RawMessage, error) {
+	request := q.client.R().SetContext(ctx)
+	if body != nil {
+		request.SetBody(body)
+	}
+	resp, err := request.Execute(method, path)
+	if err := checkResponse("qdrant", resp, err); err != nil {
+		return nil, err
+	}
+	var result qdrantResult
+	if err := json.Unmarshal(resp.Body(), &result); err != nil {
+		return nil, err
+	}
+	return result.Result, nil
+}
+
+func collectionPath(collection string) string {
+	return "/collections/" + url.PathEscape(collection)
+}
+
+func (q *qdrantStore) Upsert(ctx context.Context, collection string, points []Point, vectorName string) error {
+	qdrantPoints := make([]map[string]interface{}, 0, len(points))
+	for _, point := range points {
+		var vector interface{} = point.Vector
+		if vectorName != "" {
+			vector = map[string]interface{}{vectorName: point.Vector}
+		}
+		payload := point.Payload
+		if payload == nil {
+			payload = map[string]interface{}{}
+		}
+		qdrantPoints = append(qdrantPoints, map[string]interface{}{"id": normalizeID(point.ID), "vector": vector, "payload": payload})
+	}
+	_, err := q.do(ctx, resty.MethodPut, collectionPath(collection)+"/points?wait=true", map[string]interface{}{"points": qdrantPoints})
+	return err
+}
+
+func (q *qdrantStore) Query(ctx context.Context, collection string, args QueryCommandArgs) ([]map[string]interface{}, error) {
+	var vector interface{} = args.Vector
+	if args.VectorName != "" {
+		vector = map[string]interface{}{"name": args.VectorName, "vector": args.Vector}
+	}
+	searchRequest := map[string]interface{}{
+		"vector":       vector,
+		"limit":        args.TopK,
+		"with_payload": true,
+		"with_vector":  args.WithVector,
+	}
+	if len(args.Filter) > 0 {
+		searchRequest["filter"] = qdrantFilter(args.Filter)
+	}
+	if args.ScoreThreshold != nil {
+		searchRequest["score_threshold"] = *args.ScoreThreshold
+	}
+	result, err := q.do(ctx, resty.MethodPost, collectionPath(collection)+"/points/search", searchRequest)
+	if err != nil {
+		return nil, err
+	}
+
+	scoredPoints := make([]struct {
+		ID      interface{}            `json:"id"`
+		Score   float64                `json:"score"`
+		Payload map[string]interface{} `json:"payload"`
+		Vector  interface{}            `json:"vector"`
+	}, 0)
+	if err := json.Unmarshal(result, &scoredPoints); err != nil {
+		return nil, err
+	}
+	results := make([]map[string]interface{}, 0, len(scoredPoints))
+	for _, scoredPoint := range scoredPoints {
+		row := map[string]interface{}{"id": scoredPoint.ID, "score": scoredPoint.Score, "payload": scoredPoint.Payload}
+		if args.WithVector {
+			row["vector"] = scoredPoint.Vector
+		}
+		results = append(results, row)
+	}
+	return results, nil
+}
+
+func (q *qdrantStore) Delete(ctx context.Context, collection string, ids []interface{}, filter map[string]interface{}) error {
+	selector := map[string]interface{}{}
+	if len(ids) > 0 {
+		normalizedIDs := make([]interface{}, 0, len(ids))
+		for _, id := range ids {
+			normalizedIDs = append(normalizedIDs, normalizeID(id))
+		}
+		selector["points"] = normalizedIDs
+	} else {
+		selector["filter"] = qdrantFilter(filter)
+	}
+	_, err := q.do(ctx, resty.MethodPost, collectionPath(collection)+"/points/delete?wait=true", selector)
+	return err
+}
+
+// ListCollections lists the collections with the vector config.
+func (q *qdrantStore) ListCollections(ctx context.Context) ([]map[string]interface{}, error) {
+	result, err := q.do(ctx, resty.MethodGet, "/collections", nil)
+	if err != nil {
+		return nil, err
+	}
+	collectionList := struct {
+		Collections []struct {
+			Name string `json:"name"`
+		} `json:"collections"`
+	}{}
+	if err := json.Unmarshal(result, &collectionList); err != nil {
+		return nil, err
+	}
+
+	collections := make([]map[string]interface{}, 0, len(collectionList.Collections))
+	for _, collection := range collectionList.Collections {
+		info, err := q.do(ctx, resty.MethodGet, collectionPath(collection.Name), nil)
+		if err != nil {
+			return nil, err
+		}
+		collectionInfo := struct {
+			PointsCount int64 `json:"points_count"`
+			Config      struct {
+				Params struct {
+					Vectors interface{} `json:"vectors"`
+				} `json:"params"`
+			} `json:"config"`
+		}{}
+		if err := json.Unmarshal(info, &collectionInfo); err != nil {
+			return nil, err
+		}
+		collections = append(collections, map[string]interface{}{
+			"name":        collection.Name,
+			"pointsCount": collectionInfo.PointsCount,
+			"vectors":     collectionInfo.Config.Params.Vectors,
+		})
+	}
+	return collections, nil
+}
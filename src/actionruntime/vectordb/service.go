@@ -0,0 +1,134 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectordb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/illacloud/builder-backend/src/actionruntime/common"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/mitchellh/mapstructure"
+)
+
+type Connector struct {
+	ResourceOpts Resource
+	ActionOpts   Action
+}
+
+func (v *Connector) ValidateResourceOptions(resourceOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format resource options
+	if err := mapstructure.Decode(resourceOptions, &v.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate vector database options
+	validate := validator.New()
+	if err := validate.Struct(v.ResourceOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	if err := v.ResourceOpts.validateBackend(); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (v *Connector) ValidateActionTemplate(actionOptions map[string]interface{}) (common.ValidateResult, error) {
+	// format action options
+	if err := mapstructure.Decode(actionOptions, &v.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+
+	// validate vector database options
+	validate := validator.New()
+	if err := validate.Struct(v.ActionOpts); err != nil {
+		return common.ValidateResult{Valid: false}, err
+	}
+	return common.ValidateResult{Valid: true}, nil
+}
+
+func (v *Connector) TestConnection(resourceOptions map[string]interface{}) (common.ConnectionResult, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+
+	// get vector store
+	store, err := v.getStoreWithOptions(ctx, resourceOptions)
+	if err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	defer store.Close()
+
+	// test connection
+	if _, err := store.ListCollections(ctx); err != nil {
+		return common.ConnectionResult{Success: false}, err
+	}
+	return common.ConnectionResult{Success: true}, nil
+}
+
+func (v *Connector) GetMetaInfo(resourceOptions map[string]interface{}) (common.MetaInfoResult, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+
+	// get vector store
+	store, err := v.getStoreWithOptions(ctx, resourceOptions)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+	defer store.Close()
+
+	// list collections
+	collections, err := store.ListCollections(ctx)
+	if err != nil {
+		return common.MetaInfoResult{Success: false}, err
+	}
+
+	return common.MetaInfoResult{
+		Success: true,
+		Schema:  map[string]interface{}{"collections": collections},
+	}, nil
+}
+
+func (v *Connector) Run(resourceOptions map[string]interface{}, actionOptions map[string]interface{}, rawActionOptions map[string]interface{}) (common.RuntimeResult, error) {
+	ctx, cancel := context.WithTimeout(context.TODO(), common.DEFAULT_QUERY_AND_EXEC_TIMEOUT)
+	defer cancel()
+
+	// get vector store
+	store, err := v.getStoreWithOptions(ctx, resourceOptions)
+	if err != nil {
+		return common.RuntimeResult{Success: false}, errors.New("failed to get vector database connection: " + err.Error())
+	}
+	defer store.Close()
+
+	// format vector database action
+	if err := mapstructure.Decode(actionOptions, &v.ActionOpts); err != nil {
+		return common.RuntimeResult{Success: false}, err
+	}
+
+	var result common.RuntimeResult
+	commandExecutor := CommandExecutor{ctx: ctx, store: store, command: v.ActionOpts, embedding: v.ResourceOpts.Embedding}
+	switch v.ActionOpts.Commands {
+	case UPSERT_COMMAND:
+		result, err = commandExecutor.upsert()
+	case QUERY_COMMAND:
+		result, err = commandExecutor.query()
+	case DELETE_COMMAND:
+		result, err = commandExecutor.delete()
+	default:
+		result, err = common.RuntimeResult{Success: false}, errors.New("unsupported vector database command: "+v.ActionOpts.Commands)
+	}
+
+	return result, err
+}
@@ -0,0 +1,140 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package vectordb
+
+const (
+	UPSERT_COMMAND = "upsert"
+	QUERY_COMMAND  = "query"
+	DELETE_COMMAND = "delete"
+)
+
+const (
+	BACKEND_PGVECTOR = "pgvector"
+	BACKEND_QDRANT   = "qdrant"
+)
+
+const (
+	EMBEDDING_PROVIDER_NONE       = "none"
+	EMBEDDING_PROVIDER_HFENDPOINT = "hfendpoint"
+	EMBEDDING_PROVIDER_OPENAI     = "openai"
+)
+
+const (
+	EMBEDDING_AUTHENTICATION_NONE   = "none"
+	EMBEDDING_AUTHENTICATION_BEARER = "bearer"
+)
+
+const (
+	METRIC_COSINE        = "cosine"
+	METRIC_L2            = "l2"
+	METRIC_INNER_PRODUCT = "innerProduct"
+)
+
+const (
+	DEFAULT_TOP_K          = 10
+	MAX_TOP_K              = 1000
+	MAX_UPSERT_POINTS      = 1000
+	DEFAULT_ID_COLUMN      = "id"
+	DEFAULT_VECTOR_COLUMN  = "embedding"
+	DEFAULT_PAYLOAD_COLUMN = "payload"
+)
+
+// Resource describe a vector store, the Postgres options are for the "pgvector" backend and the Qdrant options are for
+// the "qdrant" backend. The Embedding options compute the vectors from texts with another resource of the team.
+type Resource struct {
+	Backend   string `validate:"required,oneof=pgvector qdrant"`
+	Postgres  PostgresOptions
+	Qdrant    QdrantOptions
+	Embedding EmbeddingOptions
+}
+
+// PostgresOptions describe the Postgres database with pgvector extension, the collection of action is the table name
+// (schema qualified like "public.documents" is allowed), the table has the id, vector and jsonb payload columns.
+type PostgresOptions struct {
+	Host             string
+	Port             string
+	DatabaseName     string
+	DatabaseUsername string
+	DatabasePassword string
+	IDColumn         string
+	VectorColumn     string
+	PayloadColumn    string
+	SSL              SSLOptions
+}
+
+type SSLOptions struct {
+	SSL              bool
+	VerificationMode string `validate:"omitempty,oneof=full skip"`
+	CACert           string
+	ClientCert       string
+	ClientKey        string
+}
+
+type QdrantOptions struct {
+	URL    string
+	APIKey string
+}
+
+// EmbeddingOptions refers to the embedding resource by ResourceID, the "hfendpoint" provider uses a Hugging Face
+// Inference Endpoint resource (a feature-extraction model), and the "openai" provider uses a REST API resource which
+// base URL is OpenAI or an OpenAI-compatible API like "https://api.openai.com/v1" with bearer authentication.
+// The ResourceType and ResourceOptions are loaded by server from the referenced resource before running, so the
+// credentials of embedding service are never stored in the vector database resource.
+type EmbeddingOptions struct {
+	Provider        string `validate:"omitempty,oneof=none hfendpoint openai"`
+	ResourceID      int
+	Model           string // OpenAI embedding model like "text-embedding-3-small"
+	ResourceType    string
+	ResourceOptions map[string]interface{}
+}
+
+type Action struct {
+	Commands    string                 `validate:"required,oneof=upsert query delete"`
+	Collection  string                 `validate:"required"`
+	CommandArgs map[string]interface{} `validate:"required"`
+}
+
+// Point is a vector with payload, the Vector is computed from the Text by the embedding provider when it is empty.
+type Point struct {
+	ID      interface{}            `json:"id" validate:"required"`
+	Vector  []float64              `json:"vector"`
+	Text    string                 `json:"text"`
+	Payload map[string]interface{} `json:"payload"`
+}
+
+type UpsertCommandArgs struct {
+	Points     []Point `json:"points" validate:"required,gt=0,max=1000,dive"`
+	VectorName string  `json:"vectorName"` // named vector of qdrant collection
+}
+
+// QueryCommandArgs describe a nearest neighbour query by the Vector or the Text. The Filter matches the payload, the
+// {"key": "value"} filter matches by equality for all backends, and the native qdrant filter with "must", "should" or
+// "must_not" is passed to qdrant as is.
+type QueryCommandArgs struct {
+	Vector         []float64              `json:"vector"`
+	Text           string                 `json:"text"`
+	TopK           int                    `json:"topK"`
+	Filter         map[string]interface{} `json:"filter"`
+	Metric         string                 `json:"metric" validate:"omitempty,oneof=cosine l2 innerProduct"` // pgvector only, qdrant uses the metric of collection
+	ScoreThreshold *float64               `json:"scoreThreshold"`
+	WithVector     bool                   `json:"withVector"`
+	VectorName     string                 `json:"vectorName"`
+}
+
+// DeleteCommandArgs deletes the vectors by IDs, or by the payload Filter when the IDs is empty.
+type DeleteCommandArgs struct {
+	IDs    []interface{}          `json:"ids"`
+	Filter map[string]interface{} `json:"filter"`
+}
@@ -3,10 +3,12 @@ package main
 import (
 	"os"
 
+	"github.com/illacloud/builder-backend/src/actionruntime/smtp"
 	"github.com/illacloud/builder-backend/src/controller"
 	"github.com/illacloud/builder-backend/src/drive"
 	"github.com/illacloud/builder-backend/src/driver/awss3"
 	"github.com/illacloud/builder-backend/src/driver/postgres"
+	"github.com/illacloud/builder-backend/src/driver/redis"
 	"github.com/illacloud/builder-backend/src/internalrouter"
 	"github.com/illacloud/builder-backend/src/storage"
 	"github.com/illacloud/builder-backend/src/utils/accesscontrol"
@@ -55,6 +57,21 @@ func initDrive(globalConfig *config.Config, logger *zap.SugaredLogger) *drive.Dr
 	return nil
 }
 
+func initSendQueue(globalConfig *config.Config, storage *storage.Storage, logger *zap.SugaredLogger) {
+	redisDriver, err := redis.NewRedisConnectionByGlobalConfig(globalConfig, logger)
+	if err != nil {
+		logger.Errorw("Error in startup, smtp send queue init failed.")
+		return
+	}
+	smtp.StartDefaultSendQueue(redisDriver, func(teamID int, resourceID int) (map[string]interface{}, error) {
+		resource, errInRetrieveResource := storage.ResourceStorage.RetrieveByTeamIDAndResourceID(teamID, resourceID)
+		if errInRetrieveResource != nil {
+			return nil, errInRetrieveResource
+		}
+		return resource.ExportOptionsInMap(), nil
+	})
+}
+
 func initServer() (*Server, error) {
 	globalConfig := config.GetInstance()
 	engine := gin.New()
@@ -66,6 +83,7 @@ func initServer() (*Server, error) {
 	// init driver
 	storage := initStorage(globalConfig, sugaredLogger)
 	drive := initDrive(globalConfig, sugaredLogger)
+	initSendQueue(globalConfig, storage, sugaredLogger)
 
 	// init attribute group
 	attrg, errInNewAttributeGroup := accesscontrol.NewRawAttributeGroup()
@@ -3,6 +3,7 @@ package main
 import (
 	"os"
 
+	"github.com/illacloud/builder-backend/src/actionruntime/smtp"
 	"github.com/illacloud/builder-backend/src/cache"
 	"github.com/illacloud/builder-backend/src/controller"
 	"github.com/illacloud/builder-backend/src/drive"
@@ -65,6 +66,21 @@ func initDrive(globalConfig *config.Config, logger *zap.SugaredLogger) *drive.Dr
 	return nil
 }
 
+func initSendQueue(globalConfig *config.Config, storage *storage.Storage, logger *zap.SugaredLogger) {
+	redisDriver, err := redis.NewRedisConnectionByGlobalConfig(globalConfig, logger)
+	if err != nil {
+		logger.Errorw("Error in startup, smtp send queue init failed.")
+		return
+	}
+	smtp.StartDefaultSendQueue(redisDriver, func(teamID int, resourceID int) (map[string]interface{}, error) {
+		resource, errInRetrieveResource := storage.ResourceStorage.RetrieveByTeamIDAndResourceID(teamID, resourceID)
+		if errInRetrieveResource != nil {
+			return nil, errInRetrieveResource
+		}
+		return resource.ExportOptionsInMap(), nil
+	})
+}
+
 func initServer() (*Server, error) {
 	globalConfig := config.GetInstance()
 	engine := gin.New()
@@ -77,6 +93,7 @@ func initServer() (*Server, error) {
 	storage := initStorage(globalConfig, sugaredLogger)
 	cache := initCache(globalConfig, sugaredLogger)
 	drive := initDrive(globalConfig, sugaredLogger)
+	initSendQueue(globalConfig, storage, sugaredLogger)
 
 	// init attribute group
 	attrg, errInNewAttributeGroup := accesscontrol.NewRawAttributeGroup()
@@ -406,7 +406,12 @@ func (controller *Controller) RunAction(c *gin.Context) {
 	// run
 	log.Printf("[DUMP]action: %+v\n", action)
 	log.Printf("[DUMP] resource.ExportOptionsInMap(): %+v, action.ExportTemplateInMap(): %+v\n", resource.ExportOptionsInMap(), action.ExportTemplateInMap())
-	actionRunResult, errInRunAction := actionAssemblyLine.Run(resource.ExportOptionsInMap(), action.ExportTemplateInMap(), action.ExportRawTemplateInMap())
+	resourceOptions, errInExportResourceOptions := controller.ExportRuntimeResourceOptions(resource)
+	if errInExportResourceOptions != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_RESOURCE, errInExportResourceOptions.Error())
+		return
+	}
+	actionRunResult, errInRunAction := actionAssemblyLine.Run(resourceOptions, action.ExportTemplateInMap(), action.ExportRawTemplateInMap())
 	if errInRunAction != nil {
 		if strings.HasPrefix(errInRunAction.Error(), "Error 1064:") {
 			lineNumber, _ := strconv.Atoi(errInRunAction.Error()[len(errInRunAction.Error())-1:])
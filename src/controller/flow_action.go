@@ -409,7 +409,12 @@ func (controller *Controller) RunFlowAction(c *gin.Context) {
 	// run
 	log.Printf("[DUMP]flowAction: %+v\n", flowAction)
 	log.Printf("[DUMP] resource.ExportOptionsInMap(): %+v, flowAction.ExportTemplateInMap(): %+v\n", resource.ExportOptionsInMap(), flowAction.ExportTemplateInMap())
-	flowActionRunResult, errInRunAction := flowActionAssemblyLine.Run(resource.ExportOptionsInMap(), flowAction.ExportTemplateInMap(), flowAction.ExportRawTemplateInMap())
+	resourceOptions, errInExportResourceOptions := controller.ExportRuntimeResourceOptions(resource)
+	if errInExportResourceOptions != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_RESOURCE, errInExportResourceOptions.Error())
+		return
+	}
+	flowActionRunResult, errInRunAction := flowActionAssemblyLine.Run(resourceOptions, flowAction.ExportTemplateInMap(), flowAction.ExportRawTemplateInMap())
 	if errInRunAction != nil {
 		if strings.HasPrefix(errInRunAction.Error(), "Error 1064:") {
 			lineNumber, _ := strconv.Atoi(errInRunAction.Error()[len(errInRunAction.Error())-1:])
@@ -14,6 +14,7 @@ import (
 	"github.com/illacloud/builder-backend/src/model"
 	"github.com/illacloud/builder-backend/src/request"
 	"github.com/illacloud/builder-backend/src/response"
+	"github.com/illacloud/builder-backend/src/storage"
 	"github.com/illacloud/builder-backend/src/utils/illaresourcemanagersdk"
 	"gorm.io/gorm"
 )
@@ -36,6 +37,12 @@ func (controller *Controller) GetWorkflowAllFlowActionsInternal(c *gin.Context)
 		return
 	}
 
+	// resolve release version
+	version, errInResolveVersion := controller.resolveFlowActionVersion(c, teamID, workflowID, version)
+	if errInResolveVersion != nil {
+		return
+	}
+
 	// fetch data
 	flowActions, errInGetActions := controller.Storage.FlowActionStorage.RetrieveAll(teamID, workflowID, version)
 	if errors.Is(errInGetActions, gorm.ErrRecordNotFound) {
@@ -94,6 +101,12 @@ func (controller *Controller) GetWorkflowFlowActionsByTypeInternal(c *gin.Contex
 		return
 	}
 
+	// resolve release version
+	version, errInResolveVersion := controller.resolveFlowActionVersion(c, teamID, workflowID, version)
+	if errInResolveVersion != nil {
+		return
+	}
+
 	// fetch data
 	flowActions, errInGetActions := controller.Storage.FlowActionStorage.RetrieveByType(teamID, workflowID, version, actionType)
 	if errors.Is(errInGetActions, gorm.ErrRecordNotFound) {
@@ -270,7 +283,12 @@ func (controller *Controller) RunFlowActionInternal(c *gin.Context) {
 	// run
 	log.Printf("[DUMP]flowAction: %+v\n", flowAction)
 	log.Printf("[DUMP] resource.ExportOptionsInMap(): %+v, flowAction.ExportTemplateInMap(): %+v\n", resource.ExportOptionsInMap(), flowAction.ExportTemplateInMap())
-	flowActionRunResult, errInRunAction := flowActionAssemblyLine.Run(resource.ExportOptionsInMap(), flowAction.ExportTemplateInMap(), flowAction.ExportRawTemplateInMap())
+	resourceOptions, errInExportResourceOptions := controller.ExportRuntimeResourceOptions(resource)
+	if errInExportResourceOptions != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_RESOURCE, errInExportResourceOptions.Error())
+		return
+	}
+	flowActionRunResult, errInRunAction := flowActionAssemblyLine.Run(resourceOptions, flowAction.ExportTemplateInMap(), flowAction.ExportRawTemplateInMap())
 	if errInRunAction != nil {
 		if strings.HasPrefix(errInRunAction.Error(), "Error 1064:") {
 			lineNumber, _ := strconv.Atoi(errInRunAction.Error()[len(errInRunAction.Error())-1:])
@@ -352,3 +370,69 @@ func (controller *Controller) DuplicateFlowActionsInternal(c *gin.Context) {
 	controller.FeedbackOK(c, response.NewDuplicateWorkflowActionsResponse(idMap))
 	return
 }
+
+func (controller *Controller) GetWorkflowReleaseVersionInternal(c *gin.Context) {
+	// fetch needed param
+	teamID, errInGetTeamID := controller.GetMagicIntParamFromRequest(c, PARAM_TEAM_ID)
+	teamIDInString, errInGetTeamIDInString := controller.GetStringParamFromRequest(c, PARAM_TEAM_ID)
+	workflowID, errInGetWorkflowID := controller.GetMagicIntParamFromRequest(c, PARAM_WORKFLOW_ID)
+	workflowIDInString, errInGetWorkflowIDInString := controller.GetStringParamFromRequest(c, PARAM_WORKFLOW_ID)
+	if errInGetTeamID != nil || errInGetWorkflowID != nil || errInGetTeamIDInString != nil || errInGetWorkflowIDInString != nil {
+		return
+	}
+
+	// validate request data
+	validated, errInValidate := controller.ValidateRequestTokenFromHeader(c, teamIDInString, workflowIDInString)
+	if !validated && errInValidate != nil {
+		return
+	}
+
+	// fetch data
+	workflowRelease, errInRetrieveRelease := controller.Storage.WorkflowReleaseStorage.RetrieveCurrentRelease(teamID, workflowID)
+	if errors.Is(errInRetrieveRelease, gorm.ErrRecordNotFound) {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_WORKFLOW_RELEASE, "workflow has not been released.")
+		return
+	} else if errInRetrieveRelease != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_WORKFLOW_RELEASE, "get workflow release failed: "+errInRetrieveRelease.Error())
+		return
+	}
+
+	// feedback
+	controller.FeedbackOK(c, response.NewReleaseWorkflowResponse(workflowRelease))
+	return
+}
+
+// DeleteWorkflowFlowActionsInternal is called when the workflow is deleted, it cleans up all the flow actions and
+// release records of the workflow.
+func (controller *Controller) DeleteWorkflowFlowActionsInternal(c *gin.Context) {
+	// fetch needed param
+	teamID, errInGetTeamID := controller.GetMagicIntParamFromRequest(c, PARAM_TEAM_ID)
+	teamIDInString, errInGetTeamIDInString := controller.GetStringParamFromRequest(c, PARAM_TEAM_ID)
+	workflowID, errInGetWorkflowID := controller.GetMagicIntParamFromRequest(c, PARAM_WORKFLOW_ID)
+	workflowIDInString, errInGetWorkflowIDInString := controller.GetStringParamFromRequest(c, PARAM_WORKFLOW_ID)
+	if errInGetTeamID != nil || errInGetWorkflowID != nil || errInGetTeamIDInString != nil || errInGetWorkflowIDInString != nil {
+		return
+	}
+
+	// validate request data
+	validated, errInValidate := controller.ValidateRequestTokenFromHeader(c, teamIDInString, workflowIDInString)
+	if !validated && errInValidate != nil {
+		return
+	}
+
+	// delete flow actions and releases
+	errInDelete := controller.Storage.Transaction(func(txStorage *storage.Storage) error {
+		if errInDeleteFlowActions := txStorage.FlowActionStorage.DeleteFlowActionsByWorkflow(teamID, workflowID); errInDeleteFlowActions != nil {
+			return errInDeleteFlowActions
+		}
+		return txStorage.WorkflowReleaseStorage.DeleteAllByTeamIDAndWorkflowID(teamID, workflowID)
+	})
+	if errInDelete != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_DELETE_FLOW_ACTION, "delete workflow flowActions error: "+errInDelete.Error())
+		return
+	}
+
+	// feedback
+	controller.FeedbackOK(c, response.NewDeleteWorkflowFlowActionsResponse(workflowID))
+	return
+}
@@ -1,20 +1,34 @@
 package controller
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/gin-gonic/gin"
+	"github.com/illacloud/builder-backend/src/model"
+	"github.com/illacloud/builder-backend/src/storage"
 	"github.com/illacloud/builder-backend/src/utils/illaresourcemanagersdk"
 	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+	"gorm.io/gorm"
 )
 
 func (controller *Controller) duplicateFlowActionByVersion(c *gin.Context, fromTeamID int, toTeamID int, fromWorkflowID int, toWorkflowID int, fromVersion int, toVersion int, modifierID int, isForkWorkflow bool) (map[int]int, error) {
+	idMap, errorFlag, errInDuplicate := duplicateFlowActions(controller.Storage, fromTeamID, toTeamID, fromWorkflowID, toWorkflowID, fromVersion, toVersion, modifierID, isForkWorkflow)
+	if errInDuplicate != nil {
+		controller.FeedbackBadRequest(c, errorFlag, errInDuplicate.Error())
+		return nil, errInDuplicate
+	}
+	return idMap, nil
+}
+
+// duplicateFlowActions copies the flow actions with the given storage, so it can run in a storage transaction.
+// The returned error flag and message are for the feedback of the caller.
+func duplicateFlowActions(targetStorage *storage.Storage, fromTeamID int, toTeamID int, fromWorkflowID int, toWorkflowID int, fromVersion int, toVersion int, modifierID int, isForkWorkflow bool) (map[int]int, string, error) {
 	idMap := make(map[int]int, 0)
 	// get target version flow action from database
-	flowActions, errinRetrieveFlowAction := controller.Storage.FlowActionStorage.RetrieveFlowActionsByTeamIDWorkflowIDAndVersion(fromTeamID, fromWorkflowID, fromVersion)
+	flowActions, errinRetrieveFlowAction := targetStorage.FlowActionStorage.RetrieveFlowActionsByTeamIDWorkflowIDAndVersion(fromTeamID, fromWorkflowID, fromVersion)
 	if errinRetrieveFlowAction != nil {
-		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_ACTION, "get action failed: "+errinRetrieveFlowAction.Error())
-		return nil, errinRetrieveFlowAction
+		return nil, ERROR_FLAG_CAN_NOT_GET_ACTION, errors.New("get action failed: " + errinRetrieveFlowAction.Error())
 	}
 
 	// set fork info
@@ -27,7 +41,7 @@ func (controller *Controller) duplicateFlowActionByVersion(c *gin.Context, fromT
 	// and put them to the database as duplicate
 	resourceManagerSDK, errInNewResourceManagerSDK := illaresourcemanagersdk.NewIllaResourceManagerRestAPI()
 	if errInNewResourceManagerSDK != nil {
-		return nil, errInNewResourceManagerSDK
+		return nil, ERROR_FLAG_CAN_NOT_CREATE_ACTION, errors.New("error in init resource manager sdk: " + errInNewResourceManagerSDK.Error())
 	}
 
 	for serial, flowAction := range flowActions {
@@ -45,12 +59,28 @@ func (controller *Controller) duplicateFlowActionByVersion(c *gin.Context, fromT
 		fmt.Printf("[DUMP] DuplicateFlowActionByVersion() action: %+v\n", flowAction)
 
 		// create action
-		newFlowActionID, errInCreateFlowAction := controller.Storage.FlowActionStorage.Create(flowAction)
+		newFlowActionID, errInCreateFlowAction := targetStorage.FlowActionStorage.Create(flowAction)
 		if errInCreateFlowAction != nil {
-			controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_CREATE_ACTION, "create action failed: "+errInCreateFlowAction.Error())
-			return nil, errInCreateFlowAction
+			return nil, ERROR_FLAG_CAN_NOT_CREATE_ACTION, errors.New("create action failed: " + errInCreateFlowAction.Error())
 		}
 		idMap[oldIDList[serial]] = newFlowActionID
 	}
-	return idMap, nil
+	return idMap, "", nil
+}
+
+// resolve the FLOW_ACTION_AUTO_RELEASE_VERSION to the workflow current release version,
+// so the triggers and schedules always execute the released flow actions.
+func (controller *Controller) resolveFlowActionVersion(c *gin.Context, teamID int, workflowID int, version int) (int, error) {
+	if version != model.FLOW_ACTION_AUTO_RELEASE_VERSION {
+		return version, nil
+	}
+	workflowRelease, errInRetrieveRelease := controller.Storage.WorkflowReleaseStorage.RetrieveCurrentRelease(teamID, workflowID)
+	if errors.Is(errInRetrieveRelease, gorm.ErrRecordNotFound) {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_WORKFLOW_RELEASE, "workflow has not been released.")
+		return 0, errInRetrieveRelease
+	} else if errInRetrieveRelease != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_WORKFLOW_RELEASE, "get workflow release failed: "+errInRetrieveRelease.Error())
+		return 0, errInRetrieveRelease
+	}
+	return workflowRelease.ExportVersion(), nil
 }
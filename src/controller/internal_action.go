@@ -99,7 +99,7 @@ func (controller *Controller) GenerateSQL(c *gin.Context) {
 		controller.FeedbackBadRequest(c, ERROR_FLAG_VALIDATE_REQUEST_BODY_FAILED, "validate action type error: "+errInBuild.Error())
 		return
 	}
-	resourceMetaInfo, errInGetMetaInfo := actionAssemblyLine.GetMetaInfo(resource.ExportOptionsInMap())
+	resourceMetaInfo, errInGetMetaInfo := actionAssemblyLine.GetMetaInfo(injectRuntimeResourceOptions(resource, resource.ExportOptionsInMap()))
 	if errInGetMetaInfo != nil {
 		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_RESOURCE_META_INFO, "error in fetch resource meta info: "+errInGetMetaInfo.Error())
 		return
@@ -101,7 +101,12 @@ func (controller *Controller) RunPublicAction(c *gin.Context) {
 	}
 
 	// run
-	actionRunResult, errInRunAction := actionAssemblyLine.Run(resource.ExportOptionsInMap(), action.ExportTemplateInMap(), action.ExportRawTemplateInMap())
+	resourceOptions, errInExportResourceOptions := controller.ExportRuntimeResourceOptions(resource)
+	if errInExportResourceOptions != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_RESOURCE, errInExportResourceOptions.Error())
+		return
+	}
+	actionRunResult, errInRunAction := actionAssemblyLine.Run(resourceOptions, action.ExportTemplateInMap(), action.ExportRawTemplateInMap())
 	if errInRunAction != nil {
 		if strings.HasPrefix(errInRunAction.Error(), "Error 1064:") {
 			lineNumber, _ := strconv.Atoi(errInRunAction.Error()[len(errInRunAction.Error())-1:])
@@ -52,7 +52,7 @@ func (controller *Controller) TestResourceConnection(c *gin.Context, resource *m
 	}
 
 	// test connection
-	resourceConnection, errInTestConnection := resourceAssemblyLine.TestConnection(resource.ExportOptionsInMap())
+	resourceConnection, errInTestConnection := resourceAssemblyLine.TestConnection(injectRuntimeResourceOptions(resource, resource.ExportOptionsInMap()))
 	if errInTestConnection != nil {
 		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_TEST_RESOURCE_CONNECTION, "test resource connection error: "+errInTestConnection.Error())
 		return errInTestConnection
@@ -79,7 +79,7 @@ func (controller *Controller) GetResourceMetaInfo(c *gin.Context, resource *mode
 	}
 
 	// check template
-	resourceMetaInfo, errInGetMetaInfo := resourceAssemblyLine.GetMetaInfo(resource.ExportOptionsInMap())
+	resourceMetaInfo, errInGetMetaInfo := resourceAssemblyLine.GetMetaInfo(injectRuntimeResourceOptions(resource, resource.ExportOptionsInMap()))
 	if errInGetMetaInfo != nil {
 		controller.FeedbackBadRequest(c, ERROR_FLAG_VALIDATE_REQUEST_BODY_FAILED, "get resource meta info error: "+errInGetMetaInfo.Error())
 		return nil, errInGetMetaInfo
@@ -87,3 +87,35 @@ func (controller *Controller) GetResourceMetaInfo(c *gin.Context, resource *mode
 
 	return &resourceMetaInfo, nil
 }
+
+// ExportRuntimeResourceOptions exports the resource options for running action, the embedding resource referenced by
+// the resource is loaded from the same team.
+func (controller *Controller) ExportRuntimeResourceOptions(resource *model.Resource) (map[string]interface{}, error) {
+	embeddingResourceID := resource.ExportEmbeddingResourceID()
+	if embeddingResourceID == 0 {
+		return injectRuntimeResourceOptions(resource, resource.ExportOptionsInMapWithEmbeddingResource(nil)), nil
+	}
+	embeddingResource, errInRetrieveResource := controller.Storage.ResourceStorage.RetrieveByTeamIDAndResourceID(resource.TeamID, embeddingResourceID)
+	if errInRetrieveResource != nil {
+		return nil, errors.New("get embedding resource failed: " + errInRetrieveResource.Error())
+	}
+	return injectRuntimeResourceOptions(resource, resource.ExportOptionsInMapWithEmbeddingResource(embeddingResource)), nil
+}
+
+// injectRuntimeResourceOptions adds the team and resource info the action runtime needs into the resource options, the stored
+// options and the options responded to client never contain it.
+func injectRuntimeResourceOptions(resource *model.Resource, options map[string]interface{}) map[string]interface{} {
+	if options == nil {
+		return options
+	}
+	// the team drive backed resource need team info for download database file
+	if resourcelist.IsTeamDriveBackedResourceByIntType(resource.Type) {
+		options[model.RESOURCE_OPTIONS_FIELD_TEAM_ID] = resource.TeamID
+	}
+	// the resource which keeps shared state need team and resource info to scope the state
+	if resourcelist.IsSharedStateScopedResourceByIntType(resource.Type) {
+		options[model.RESOURCE_OPTIONS_FIELD_TEAM_ID] = resource.TeamID
+		options[model.RESOURCE_OPTIONS_FIELD_RESOURCE_ID] = resource.ID
+	}
+	return options
+}
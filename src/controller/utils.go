@@ -175,6 +175,11 @@ const (
 	ERROR_FLAG_EXECUTE_FLOW_ACTION_FAILED   = "ERROR_FLAG_EXECUTE_FLOW_ACTION_FAILED"
 	ERROR_FLAG_CAN_NOT_PARSE_EXPIRE_AT_TIME = "ERROR_FLAG_CAN_NOT_PARSE_EXPIRE_AT_TIME"
 	ERROR_FLAG_CAN_NOT_PROCESS_FLOW_ACTION  = "ERROR_FLAG_CAN_NOT_PROCESS_FLOW_ACTION"
+
+	// workflow release
+	ERROR_FLAG_CAN_NOT_GET_WORKFLOW_RELEASE = "ERROR_FLAG_CAN_NOT_GET_WORKFLOW_RELEASE"
+	ERROR_FLAG_CAN_NOT_RELEASE_WORKFLOW     = "ERROR_FLAG_CAN_NOT_RELEASE_WORKFLOW"
+	ERROR_FLAG_CAN_NOT_ROLLBACK_WORKFLOW    = "ERROR_FLAG_CAN_NOT_ROLLBACK_WORKFLOW"
 )
 
 var SKIPPING_MAGIC_ID = map[string]int{
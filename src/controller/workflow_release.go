@@ -0,0 +1,169 @@
+package controller
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+	"github.com/illacloud/builder-backend/src/model"
+	"github.com/illacloud/builder-backend/src/response"
+	"github.com/illacloud/builder-backend/src/storage"
+	"github.com/illacloud/builder-backend/src/utils/accesscontrol"
+	"gorm.io/gorm"
+)
+
+// For release workflow, we should:
+// - get workflow latest released version and bump it
+// - duplicate all flow actions from edit version to new release version
+// - save workflow release record, the latest record is the version which triggers and schedules execute
+func (controller *Controller) ReleaseWorkflow(c *gin.Context) {
+	// fetch needed param
+	teamID, errInGetTeamID := controller.GetMagicIntParamFromRequest(c, PARAM_TEAM_ID)
+	workflowID, errInGetWorkflowID := controller.GetMagicIntParamFromRequest(c, PARAM_WORKFLOW_ID)
+	userAuthToken, errInGetAuthToken := controller.GetUserAuthTokenFromHeader(c)
+	userID, errInGetUserID := controller.GetUserIDFromAuth(c)
+	if errInGetTeamID != nil || errInGetWorkflowID != nil || errInGetAuthToken != nil || errInGetUserID != nil {
+		return
+	}
+
+	// validate
+	canManage, errInCheckAttr := controller.AttributeGroup.CanManage(
+		teamID,
+		userAuthToken,
+		accesscontrol.UNIT_TYPE_WORKFLOW,
+		workflowID,
+		accesscontrol.ACTION_MANAGE_EDIT_WORKFLOW,
+	)
+	if errInCheckAttr != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_ACCESS_DENIED, "error in check attribute: "+errInCheckAttr.Error())
+		return
+	}
+	if !canManage {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_ACCESS_DENIED, "you can not access this attribute due to access control policy.")
+		return
+	}
+
+	// bump release version, snapshot edit version flow actions to release version and save release record in one
+	// transaction, the workflow lock serializes concurrent releases, so each release gets its own version
+	var workflowRelease *model.WorkflowRelease
+	errorFlag := ERROR_FLAG_CAN_NOT_RELEASE_WORKFLOW
+	errInRelease := controller.Storage.Transaction(func(txStorage *storage.Storage) error {
+		if errInLock := txStorage.WorkflowReleaseStorage.LockWorkflow(teamID, workflowID); errInLock != nil {
+			return errors.New("lock workflow failed: " + errInLock.Error())
+		}
+		latestVersion, errInRetrieveLatestVersion := txStorage.WorkflowReleaseStorage.RetrieveLatestVersion(teamID, workflowID)
+		if errInRetrieveLatestVersion != nil {
+			return errors.New("get workflow latest release version failed: " + errInRetrieveLatestVersion.Error())
+		}
+		releaseVersion := latestVersion + 1
+
+		_, duplicateErrorFlag, errInDuplicate := duplicateFlowActions(txStorage, teamID, teamID, workflowID, workflowID, model.FLOW_ACTION_EDIT_VERSION, releaseVersion, userID, false)
+		if errInDuplicate != nil {
+			errorFlag = duplicateErrorFlag
+			return errInDuplicate
+		}
+
+		workflowRelease = model.NewWorkflowRelease(teamID, workflowID, releaseVersion, userID)
+		if _, errInCreateRelease := txStorage.WorkflowReleaseStorage.Create(workflowRelease); errInCreateRelease != nil {
+			return errors.New("create workflow release failed: " + errInCreateRelease.Error())
+		}
+		return nil
+	})
+	if errInRelease != nil {
+		controller.FeedbackBadRequest(c, errorFlag, errInRelease.Error())
+		return
+	}
+
+	// feedback
+	controller.FeedbackOK(c, response.NewReleaseWorkflowResponse(workflowRelease))
+	return
+}
+
+func (controller *Controller) GetWorkflowReleaseList(c *gin.Context) {
+	// fetch needed param
+	teamID, errInGetTeamID := controller.GetMagicIntParamFromRequest(c, PARAM_TEAM_ID)
+	workflowID, errInGetWorkflowID := controller.GetMagicIntParamFromRequest(c, PARAM_WORKFLOW_ID)
+	userAuthToken, errInGetAuthToken := controller.GetUserAuthTokenFromHeader(c)
+	if errInGetTeamID != nil || errInGetWorkflowID != nil || errInGetAuthToken != nil {
+		return
+	}
+
+	// validate
+	canAccess, errInCheckAttr := controller.AttributeGroup.CanAccess(
+		teamID,
+		userAuthToken,
+		accesscontrol.UNIT_TYPE_WORKFLOW,
+		workflowID,
+		accesscontrol.ACTION_ACCESS_VIEW,
+	)
+	if errInCheckAttr != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_ACCESS_DENIED, "error in check attribute: "+errInCheckAttr.Error())
+		return
+	}
+	if !canAccess {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_ACCESS_DENIED, "you can not access this attribute due to access control policy.")
+		return
+	}
+
+	// fetch data
+	workflowReleases, errInRetrieveReleases := controller.Storage.WorkflowReleaseStorage.RetrieveByTeamIDAndWorkflowID(teamID, workflowID)
+	if errInRetrieveReleases != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_GET_WORKFLOW_RELEASE, "get workflow release list failed: "+errInRetrieveReleases.Error())
+		return
+	}
+
+	// feedback
+	controller.FeedbackOK(c, response.NewGetWorkflowReleaseListResponse(workflowReleases))
+	return
+}
+
+// Rollback will not touch any flow action, it just point the workflow current release to a prior release version.
+func (controller *Controller) RollbackWorkflowRelease(c *gin.Context) {
+	// fetch needed param
+	teamID, errInGetTeamID := controller.GetMagicIntParamFromRequest(c, PARAM_TEAM_ID)
+	workflowID, errInGetWorkflowID := controller.GetMagicIntParamFromRequest(c, PARAM_WORKFLOW_ID)
+	version, errInGetVersion := controller.GetIntParamFromRequest(c, PARAM_VERSION)
+	userAuthToken, errInGetAuthToken := controller.GetUserAuthTokenFromHeader(c)
+	userID, errInGetUserID := controller.GetUserIDFromAuth(c)
+	if errInGetTeamID != nil || errInGetWorkflowID != nil || errInGetVersion != nil || errInGetAuthToken != nil || errInGetUserID != nil {
+		return
+	}
+
+	// validate
+	canManage, errInCheckAttr := controller.AttributeGroup.CanManage(
+		teamID,
+		userAuthToken,
+		accesscontrol.UNIT_TYPE_WORKFLOW,
+		workflowID,
+		accesscontrol.ACTION_MANAGE_EDIT_WORKFLOW,
+	)
+	if errInCheckAttr != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_ACCESS_DENIED, "error in check attribute: "+errInCheckAttr.Error())
+		return
+	}
+	if !canManage {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_ACCESS_DENIED, "you can not access this attribute due to access control policy.")
+		return
+	}
+
+	// check target version was released
+	_, errInRetrieveTargetRelease := controller.Storage.WorkflowReleaseStorage.RetrieveByTeamIDWorkflowIDAndVersion(teamID, workflowID, version)
+	if errors.Is(errInRetrieveTargetRelease, gorm.ErrRecordNotFound) {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_ROLLBACK_WORKFLOW, "target version has not been released.")
+		return
+	} else if errInRetrieveTargetRelease != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_ROLLBACK_WORKFLOW, "get workflow release failed: "+errInRetrieveTargetRelease.Error())
+		return
+	}
+
+	// save rollback record
+	workflowRollback := model.NewWorkflowRollback(teamID, workflowID, version, userID)
+	_, errInCreateRollback := controller.Storage.WorkflowReleaseStorage.Create(workflowRollback)
+	if errInCreateRollback != nil {
+		controller.FeedbackBadRequest(c, ERROR_FLAG_CAN_NOT_ROLLBACK_WORKFLOW, "create workflow rollback failed: "+errInCreateRollback.Error())
+		return
+	}
+
+	// feedback
+	controller.FeedbackOK(c, response.NewReleaseWorkflowResponse(workflowRollback))
+	return
+}
@@ -35,7 +35,9 @@ func (r *Router) RegisterRouters(engine *gin.Engine) {
 	flowActionRouter.GET("/version/:version/all", r.Controller.GetWorkflowAllFlowActionsInternal)
 	flowActionRouter.GET("/version/:version/type/:actionType", r.Controller.GetWorkflowFlowActionsByTypeInternal)
 	flowActionRouter.GET("/id/:actionID", r.Controller.GetWorkflowFlowActionByIDInternal)
+	flowActionRouter.GET("/releaseVersion", r.Controller.GetWorkflowReleaseVersionInternal)
 	flowActionRouter.POST("/:flowActionID/run", r.Controller.RunFlowActionInternal)
+	flowActionRouter.DELETE("", r.Controller.DeleteWorkflowFlowActionsInternal)
 	flowActionRDuplicateouter.POST("", r.Controller.DuplicateFlowActionsInternal)
 
 }
@@ -5,22 +5,33 @@ import (
 
 	"github.com/illacloud/builder-backend/src/actionruntime/aiagent"
 	"github.com/illacloud/builder-backend/src/actionruntime/airtable"
+	"github.com/illacloud/builder-backend/src/actionruntime/amqp"
 	"github.com/illacloud/builder-backend/src/actionruntime/appwrite"
+	"github.com/illacloud/builder-backend/src/actionruntime/cassandra"
 	"github.com/illacloud/builder-backend/src/actionruntime/clickhouse"
 	"github.com/illacloud/builder-backend/src/actionruntime/common"
 	"github.com/illacloud/builder-backend/src/actionruntime/condition"
 	"github.com/illacloud/builder-backend/src/actionruntime/couchdb"
+	"github.com/illacloud/builder-backend/src/actionruntime/duckdb"
 	"github.com/illacloud/builder-backend/src/actionruntime/dynamodb"
 	"github.com/illacloud/builder-backend/src/actionruntime/elasticsearch"
 	"github.com/illacloud/builder-backend/src/actionruntime/firebase"
 	"github.com/illacloud/builder-backend/src/actionruntime/googlesheets"
 	"github.com/illacloud/builder-backend/src/actionruntime/graphql"
+	"github.com/illacloud/builder-backend/src/actionruntime/grpc"
 	"github.com/illacloud/builder-backend/src/actionruntime/hfendpoint"
 	"github.com/illacloud/builder-backend/src/actionruntime/huggingface"
 	"github.com/illacloud/builder-backend/src/actionruntime/illadrive"
+	"github.com/illacloud/builder-backend/src/actionruntime/imap"
+	"github.com/illacloud/builder-backend/src/actionruntime/influxdb"
+	"github.com/illacloud/builder-backend/src/actionruntime/kafka"
+	"github.com/illacloud/builder-backend/src/actionruntime/ldap"
 	"github.com/illacloud/builder-backend/src/actionruntime/mongodb"
+	"github.com/illacloud/builder-backend/src/actionruntime/mqtt"
 	"github.com/illacloud/builder-backend/src/actionruntime/mssql"
 	"github.com/illacloud/builder-backend/src/actionruntime/mysql"
+	"github.com/illacloud/builder-backend/src/actionruntime/nats"
+	"github.com/illacloud/builder-backend/src/actionruntime/neo4j"
 	"github.com/illacloud/builder-backend/src/actionruntime/oracle"
 	"github.com/illacloud/builder-backend/src/actionruntime/oracle9i"
 	"github.com/illacloud/builder-backend/src/actionruntime/postgresql"
@@ -28,9 +39,13 @@ import (
 	"github.com/illacloud/builder-backend/src/actionruntime/restapi"
 	"github.com/illacloud/builder-backend/src/actionruntime/s3"
 	"github.com/illacloud/builder-backend/src/actionruntime/serversidetransformer"
+	"github.com/illacloud/builder-backend/src/actionruntime/sftp"
 	"github.com/illacloud/builder-backend/src/actionruntime/smtp"
 	"github.com/illacloud/builder-backend/src/actionruntime/snowflake"
+	"github.com/illacloud/builder-backend/src/actionruntime/soap"
+	"github.com/illacloud/builder-backend/src/actionruntime/sqlite"
 	"github.com/illacloud/builder-backend/src/actionruntime/trigger"
+	"github.com/illacloud/builder-backend/src/actionruntime/vectordb"
 	"github.com/illacloud/builder-backend/src/actionruntime/webhookresponse"
 	"github.com/illacloud/builder-backend/src/utils/resourcelist"
 )
@@ -143,6 +158,51 @@ func (f *ActionFactory) Build() (common.DataConnector, error) {
 	case resourcelist.TYPE_WEBHOOK_RESPONSE_ID:
 		webhookResponseAction := &webhookresponse.WebhookResponseConnector{}
 		return webhookResponseAction, nil
+	case resourcelist.TYPE_SQLITE_ID:
+		sqliteAction := &sqlite.Connector{}
+		return sqliteAction, nil
+	case resourcelist.TYPE_DUCKDB_ID:
+		duckdbAction := &duckdb.Connector{}
+		return duckdbAction, nil
+	case resourcelist.TYPE_KAFKA_ID:
+		kafkaAction := &kafka.Connector{}
+		return kafkaAction, nil
+	case resourcelist.TYPE_AMQP_ID:
+		amqpAction := &amqp.Connector{}
+		return amqpAction, nil
+	case resourcelist.TYPE_MQTT_ID:
+		mqttAction := &mqtt.Connector{}
+		return mqttAction, nil
+	case resourcelist.TYPE_NATS_ID:
+		natsAction := &nats.Connector{}
+		return natsAction, nil
+	case resourcelist.TYPE_CASSANDRA_ID:
+		cassandraAction := &cassandra.Connector{}
+		return cassandraAction, nil
+	case resourcelist.TYPE_NEO4J_ID:
+		neo4jAction := &neo4j.Connector{}
+		return neo4jAction, nil
+	case resourcelist.TYPE_INFLUXDB_ID:
+		influxdbAction := &influxdb.Connector{}
+		return influxdbAction, nil
+	case resourcelist.TYPE_SFTP_ID:
+		sftpAction := &sftp.Connector{}
+		return sftpAction, nil
+	case resourcelist.TYPE_LDAP_ID:
+		ldapAction := &ldap.Connector{}
+		return ldapAction, nil
+	case resourcelist.TYPE_GRPC_ID:
+		grpcAction := &grpc.Connector{}
+		return grpcAction, nil
+	case resourcelist.TYPE_SOAP_ID:
+		soapAction := &soap.Connector{}
+		return soapAction, nil
+	case resourcelist.TYPE_IMAP_ID:
+		imapAction := &imap.Connector{}
+		return imapAction, nil
+	case resourcelist.TYPE_VECTORDB_ID:
+		vectordbAction := &vectordb.Connector{}
+		return vectordbAction, nil
 	default:
 		return nil, errors.New("invalid ActionType: unsupported type " + resourcelist.GetResourceIDMappedType(f.Type))
 	}
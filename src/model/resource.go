@@ -9,6 +9,18 @@ import (
 	"github.com/illacloud/builder-backend/src/utils/resourcelist"
 )
 
+const (
+	RESOURCE_OPTIONS_FIELD_TEAM_ID     = "teamID"
+	RESOURCE_OPTIONS_FIELD_RESOURCE_ID = "resourceID"
+)
+
+const (
+	RESOURCE_OPTIONS_FIELD_EMBEDDING                  = "embedding"
+	RESOURCE_OPTIONS_FIELD_EMBEDDING_RESOURCE_ID      = "resourceID"
+	RESOURCE_OPTIONS_FIELD_EMBEDDING_RESOURCE_TYPE    = "resourceType"
+	RESOURCE_OPTIONS_FIELD_EMBEDDING_RESOURCE_OPTIONS = "resourceOptions"
+)
+
 type Resource struct {
 	ID        int       `gorm:"column:id;type:bigserial;primary_key"`
 	UID       uuid.UUID `gorm:"column:uid;type:uuid;not null"`
@@ -104,6 +116,36 @@ func (resource *Resource) ExportOptionsInMap() map[string]interface{} {
 	return options
 }
 
+// ExportEmbeddingResourceID exports the id of the embedding resource referenced by the resource, 0 for none.
+func (resource *Resource) ExportEmbeddingResourceID() int {
+	if !resourcelist.IsEmbeddingResourceReferencedByIntType(resource.Type) {
+		return 0
+	}
+	embedding, ok := resource.ExportOptionsInMap()[RESOURCE_OPTIONS_FIELD_EMBEDDING].(map[string]interface{})
+	if !ok {
+		return 0
+	}
+	resourceID, _ := embedding[RESOURCE_OPTIONS_FIELD_EMBEDDING_RESOURCE_ID].(float64)
+	return int(resourceID)
+}
+
+// ExportOptionsInMapWithEmbeddingResource exports the options with the type and options of the embedding resource
+// for action runtime, the options given by user for these fields are always dropped.
+func (resource *Resource) ExportOptionsInMapWithEmbeddingResource(embeddingResource *Resource) map[string]interface{} {
+	options := resource.ExportOptionsInMap()
+	embedding, ok := options[RESOURCE_OPTIONS_FIELD_EMBEDDING].(map[string]interface{})
+	if !ok {
+		return options
+	}
+	delete(embedding, RESOURCE_OPTIONS_FIELD_EMBEDDING_RESOURCE_TYPE)
+	delete(embedding, RESOURCE_OPTIONS_FIELD_EMBEDDING_RESOURCE_OPTIONS)
+	if embeddingResource != nil {
+		embedding[RESOURCE_OPTIONS_FIELD_EMBEDDING_RESOURCE_TYPE] = embeddingResource.ExportTypeInString()
+		embedding[RESOURCE_OPTIONS_FIELD_EMBEDDING_RESOURCE_OPTIONS] = embeddingResource.ExportOptionsInMap()
+	}
+	return options
+}
+
 func (resource *Resource) CanCreateOAuthToken() bool {
 	return resourcelist.CanCreateOAuthToken(resource.Type)
 }
@@ -0,0 +1,66 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	FLOW_ACTION_AUTO_RELEASE_VERSION = -2 // -2 for get current release version automatically
+	FLOW_ACTION_NO_RELEASE_VERSION   = -1 // workflow have not been released yet
+)
+
+const (
+	WORKFLOW_RELEASE_TRIGGER_MODE_RELEASE  = 1
+	WORKFLOW_RELEASE_TRIGGER_MODE_ROLLBACK = 2
+)
+
+// WorkflowRelease records every release (or rollback) of a workflow.
+// The flow actions of a release are duplicated from edit version to WorkflowRelease.Version,
+// and the latest record always point to the version that triggers and schedules should execute.
+type WorkflowRelease struct {
+	ID          int       `json:"id" gorm:"column:id;type:bigserial;primary_key;unique"`
+	UID         uuid.UUID `json:"uid" gorm:"column:uid;type:uuid;not null"`
+	TeamID      int       `json:"teamID" gorm:"column:team_id;type:bigserial"`
+	WorkflowID  int       `json:"workflowID" gorm:"column:workflow_id;type:bigint;not null"`
+	Version     int       `json:"version" gorm:"column:version;type:bigint;not null"`
+	TriggerMode int       `json:"triggerMode" gorm:"column:trigger_mode;type:smallint;not null"`
+	CreatedAt   time.Time `json:"createdAt" gorm:"column:created_at;type:timestamp;not null"`
+	CreatedBy   int       `json:"createdBy" gorm:"column:created_by;type:bigint;not null"`
+}
+
+func NewWorkflowRelease(teamID int, workflowID int, version int, userID int) *WorkflowRelease {
+	workflowRelease := &WorkflowRelease{
+		TeamID:      teamID,
+		WorkflowID:  workflowID,
+		Version:     version,
+		TriggerMode: WORKFLOW_RELEASE_TRIGGER_MODE_RELEASE,
+		CreatedBy:   userID,
+	}
+	workflowRelease.InitUID()
+	workflowRelease.InitCreatedAt()
+	return workflowRelease
+}
+
+func NewWorkflowRollback(teamID int, workflowID int, version int, userID int) *WorkflowRelease {
+	workflowRelease := NewWorkflowRelease(teamID, workflowID, version, userID)
+	workflowRelease.TriggerMode = WORKFLOW_RELEASE_TRIGGER_MODE_ROLLBACK
+	return workflowRelease
+}
+
+func (workflowRelease *WorkflowRelease) InitUID() {
+	workflowRelease.UID = uuid.New()
+}
+
+func (workflowRelease *WorkflowRelease) InitCreatedAt() {
+	workflowRelease.CreatedAt = time.Now().UTC()
+}
+
+func (workflowRelease *WorkflowRelease) ExportVersion() int {
+	return workflowRelease.Version
+}
+
+func (workflowRelease *WorkflowRelease) IsRollback() bool {
+	return workflowRelease.TriggerMode == WORKFLOW_RELEASE_TRIGGER_MODE_ROLLBACK
+}
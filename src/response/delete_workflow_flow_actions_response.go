@@ -0,0 +1,20 @@
+package response
+
+import (
+	"github.com/illacloud/builder-backend/src/utils/idconvertor"
+)
+
+type DeleteWorkflowFlowActionsResponse struct {
+	WorkflowID string `json:"workflowID"`
+}
+
+func NewDeleteWorkflowFlowActionsResponse(workflowID int) *DeleteWorkflowFlowActionsResponse {
+	resp := &DeleteWorkflowFlowActionsResponse{
+		WorkflowID: idconvertor.ConvertIntToString(workflowID),
+	}
+	return resp
+}
+
+func (resp *DeleteWorkflowFlowActionsResponse) ExportForFeedback() interface{} {
+	return resp
+}
@@ -0,0 +1,56 @@
+package response
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/illacloud/builder-backend/src/model"
+	"github.com/illacloud/builder-backend/src/utils/idconvertor"
+)
+
+type WorkflowReleaseForExport struct {
+	ID         string    `json:"workflowReleaseID"`
+	UID        uuid.UUID `json:"uid"`
+	TeamID     string    `json:"teamID"`
+	WorkflowID string    `json:"workflowID"`
+	Version    int       `json:"version"`
+	IsRollback bool      `json:"isRollback"`
+	IsCurrent  bool      `json:"isCurrent"`
+	CreatedAt  time.Time `json:"createdAt"`
+	CreatedBy  string    `json:"createdBy"`
+}
+
+type GetWorkflowReleaseListResponse struct {
+	ReleaseVersion int                         `json:"releaseVersion"`
+	ReleaseList    []*WorkflowReleaseForExport `json:"releaseList"`
+}
+
+// the workflowReleases must be sorted by id desc, so the first one is the current release
+func NewGetWorkflowReleaseListResponse(workflowReleases []*model.WorkflowRelease) *GetWorkflowReleaseListResponse {
+	resp := &GetWorkflowReleaseListResponse{
+		ReleaseVersion: model.FLOW_ACTION_NO_RELEASE_VERSION,
+		ReleaseList:    make([]*WorkflowReleaseForExport, 0),
+	}
+	for serial, workflowRelease := range workflowReleases {
+		isCurrent := serial == 0
+		if isCurrent {
+			resp.ReleaseVersion = workflowRelease.ExportVersion()
+		}
+		resp.ReleaseList = append(resp.ReleaseList, &WorkflowReleaseForExport{
+			ID:         idconvertor.ConvertIntToString(workflowRelease.ID),
+			UID:        workflowRelease.UID,
+			TeamID:     idconvertor.ConvertIntToString(workflowRelease.TeamID),
+			WorkflowID: idconvertor.ConvertIntToString(workflowRelease.WorkflowID),
+			Version:    workflowRelease.ExportVersion(),
+			IsRollback: workflowRelease.IsRollback(),
+			IsCurrent:  isCurrent,
+			CreatedAt:  workflowRelease.CreatedAt,
+			CreatedBy:  idconvertor.ConvertIntToString(workflowRelease.CreatedBy),
+		})
+	}
+	return resp
+}
+
+func (resp *GetWorkflowReleaseListResponse) ExportForFeedback() interface{} {
+	return resp
+}
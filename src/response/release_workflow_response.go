@@ -0,0 +1,27 @@
+package response
+
+import (
+	"github.com/illacloud/builder-backend/src/model"
+	"github.com/illacloud/builder-backend/src/utils/idconvertor"
+)
+
+type ReleaseWorkflowResponse struct {
+	WorkflowID     string `json:"workflowID"`
+	Version        int    `json:"version"`
+	ReleaseVersion int    `json:"releaseVersion"`
+	IsRollback     bool   `json:"isRollback"`
+}
+
+func NewReleaseWorkflowResponse(workflowRelease *model.WorkflowRelease) *ReleaseWorkflowResponse {
+	resp := &ReleaseWorkflowResponse{
+		WorkflowID:     idconvertor.ConvertIntToString(workflowRelease.WorkflowID),
+		Version:        workflowRelease.ExportVersion(),
+		ReleaseVersion: workflowRelease.ExportVersion(),
+		IsRollback:     workflowRelease.IsRollback(),
+	}
+	return resp
+}
+
+func (resp *ReleaseWorkflowResponse) ExportForFeedback() interface{} {
+	return resp
+}
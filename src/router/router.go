@@ -35,6 +35,7 @@ func (r *Router) RegisterRouters(engine *gin.Engine) {
 	statusRouter := routerGroup.Group("/status")
 	oauth2Router := routerGroup.Group("/oauth2")
 	flowActionRouter := routerGroup.Group("/teams/:teamID/workflow/:workflowID/flowActions")
+	workflowReleaseRouter := routerGroup.Group("/teams/:teamID/workflow/:workflowID/releases")
 
 	// register auth
 	builderRouter.Use(remotejwtauth.RemoteJWTAuth())
@@ -45,6 +46,7 @@ func (r *Router) RegisterRouters(engine *gin.Engine) {
 	internalActionRouter.Use(remotejwtauth.RemoteJWTAuth())
 	resourceRouter.Use(remotejwtauth.RemoteJWTAuth())
 	flowActionRouter.Use(remotejwtauth.RemoteJWTAuth())
+	workflowReleaseRouter.Use(remotejwtauth.RemoteJWTAuth())
 
 	// builder routers
 	builderRouter.GET("/desc", r.Controller.GetTeamBuilderDesc)
@@ -116,6 +118,11 @@ func (r *Router) RegisterRouters(engine *gin.Engine) {
 	flowActionRouter.POST("/:flowActionID/run", r.Controller.RunFlowAction)
 	flowActionRouter.PUT("/byBatch", r.Controller.UpdateFlowActionByBatch)
 
+	// workflow release routers
+	workflowReleaseRouter.POST("", r.Controller.ReleaseWorkflow)
+	workflowReleaseRouter.GET("", r.Controller.GetWorkflowReleaseList)
+	workflowReleaseRouter.POST("/version/:version/rollback", r.Controller.RollbackWorkflowRelease)
+
 	// status router
 	statusRouter.GET("", r.Controller.GetStatus)
 
@@ -6,25 +6,39 @@ import (
 )
 
 type Storage struct {
-	AppStorage         *AppStorage
-	ActionStorage      *ActionStorage
-	FlowActionStorage  *FlowActionStorage
-	AppSnapshotStorage *AppSnapshotStorage
-	KVStateStorage     *KVStateStorage
-	ResourceStorage    *ResourceStorage
-	SetStateStorage    *SetStateStorage
-	TreeStateStorage   *TreeStateStorage
+	AppStorage             *AppStorage
+	ActionStorage          *ActionStorage
+	FlowActionStorage      *FlowActionStorage
+	WorkflowReleaseStorage *WorkflowReleaseStorage
+	AppSnapshotStorage     *AppSnapshotStorage
+	KVStateStorage         *KVStateStorage
+	ResourceStorage        *ResourceStorage
+	SetStateStorage        *SetStateStorage
+	TreeStateStorage       *TreeStateStorage
+	logger                 *zap.SugaredLogger
+	db                     *gorm.DB
 }
 
 func NewStorage(postgresDriver *gorm.DB, logger *zap.SugaredLogger) *Storage {
 	return &Storage{
-		AppStorage:         NewAppStorage(logger, postgresDriver),
-		ActionStorage:      NewActionStorage(logger, postgresDriver),
-		FlowActionStorage:  NewFlowActionStorage(logger, postgresDriver),
-		AppSnapshotStorage: NewAppSnapshotStorage(logger, postgresDriver),
-		KVStateStorage:     NewKVStateStorage(logger, postgresDriver),
-		ResourceStorage:    NewResourceStorage(logger, postgresDriver),
-		SetStateStorage:    NewSetStateStorage(logger, postgresDriver),
-		TreeStateStorage:   NewTreeStateStorage(logger, postgresDriver),
+		AppStorage:             NewAppStorage(logger, postgresDriver),
+		ActionStorage:          NewActionStorage(logger, postgresDriver),
+		FlowActionStorage:      NewFlowActionStorage(logger, postgresDriver),
+		WorkflowReleaseStorage: NewWorkflowReleaseStorage(logger, postgresDriver),
+		AppSnapshotStorage:     NewAppSnapshotStorage(logger, postgresDriver),
+		KVStateStorage:         NewKVStateStorage(logger, postgresDriver),
+		ResourceStorage:        NewResourceStorage(logger, postgresDriver),
+		SetStateStorage:        NewSetStateStorage(logger, postgresDriver),
+		TreeStateStorage:       NewTreeStateStorage(logger, postgresDriver),
+		logger:                 logger,
+		db:                     postgresDriver,
 	}
 }
+
+// Transaction runs fc with a Storage bound to one database transaction, the transaction is rolled back when fc
+// returns an error.
+func (impl *Storage) Transaction(fc func(txStorage *Storage) error) error {
+	return impl.db.Transaction(func(tx *gorm.DB) error {
+		return fc(NewStorage(tx, impl.logger))
+	})
+}
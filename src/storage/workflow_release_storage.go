@@ -0,0 +1,91 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package storage
+
+import (
+	"fmt"
+
+	"github.com/illacloud/builder-backend/src/model"
+	"go.uber.org/zap"
+	"gorm.io/gorm"
+)
+
+type WorkflowReleaseStorage struct {
+	logger *zap.SugaredLogger
+	db     *gorm.DB
+}
+
+func NewWorkflowReleaseStorage(logger *zap.SugaredLogger, db *gorm.DB) *WorkflowReleaseStorage {
+	return &WorkflowReleaseStorage{
+		logger: logger,
+		db:     db,
+	}
+}
+
+func (impl *WorkflowReleaseStorage) Create(workflowRelease *model.WorkflowRelease) (int, error) {
+	if err := impl.db.Create(workflowRelease).Error; err != nil {
+		return 0, err
+	}
+	return workflowRelease.ID, nil
+}
+
+// the latest record is the current release of workflow
+func (impl *WorkflowReleaseStorage) RetrieveCurrentRelease(teamID int, workflowID int) (*model.WorkflowRelease, error) {
+	var workflowRelease *model.WorkflowRelease
+	if err := impl.db.Where("team_id = ? AND workflow_id = ?", teamID, workflowID).Order("id desc").First(&workflowRelease).Error; err != nil {
+		return nil, err
+	}
+	return workflowRelease, nil
+}
+
+func (impl *WorkflowReleaseStorage) RetrieveByTeamIDAndWorkflowID(teamID int, workflowID int) ([]*model.WorkflowRelease, error) {
+	var workflowReleases []*model.WorkflowRelease
+	if err := impl.db.Where("team_id = ? AND workflow_id = ?", teamID, workflowID).Order("id desc").Find(&workflowReleases).Error; err != nil {
+		return nil, err
+	}
+	return workflowReleases, nil
+}
+
+func (impl *WorkflowReleaseStorage) RetrieveByTeamIDWorkflowIDAndVersion(teamID int, workflowID int, version int) (*model.WorkflowRelease, error) {
+	var workflowRelease *model.WorkflowRelease
+	if err := impl.db.Where("team_id = ? AND workflow_id = ? AND version = ? AND trigger_mode = ?", teamID, workflowID, version, model.WORKFLOW_RELEASE_TRIGGER_MODE_RELEASE).First(&workflowRelease).Error; err != nil {
+		return nil, err
+	}
+	return workflowRelease, nil
+}
+
+// LockWorkflow serializes the releases of a workflow until the transaction ends, it must be called in a transaction.
+func (impl *WorkflowReleaseStorage) LockWorkflow(teamID int, workflowID int) error {
+	lockKey := fmt.Sprintf("workflow_releases:%d:%d", teamID, workflowID)
+	if err := impl.db.Exec("SELECT pg_advisory_xact_lock(hashtext(?))", lockKey).Error; err != nil {
+		return err
+	}
+	return nil
+}
+
+func (impl *WorkflowReleaseStorage) RetrieveLatestVersion(teamID int, workflowID int) (int, error) {
+	var latestVersion int
+	if err := impl.db.Model(&model.WorkflowRelease{}).Select("COALESCE(MAX(version), 0)").Where("team_id = ? AND workflow_id = ?", teamID, workflowID).Scan(&latestVersion).Error; err != nil {
+		return 0, err
+	}
+	return latestVersion, nil
+}
+
+func (impl *WorkflowReleaseStorage) DeleteAllByTeamIDAndWorkflowID(teamID int, workflowID int) error {
+	if err := impl.db.Where("team_id = ? AND workflow_id = ?", teamID, workflowID).Delete(&model.WorkflowRelease{}).Error; err != nil {
+		return err
+	}
+	return nil
+}
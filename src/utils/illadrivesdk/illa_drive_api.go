@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"log"
 	"net/http"
+	"os"
 	"strconv"
 
 	"github.com/fatih/structs"
@@ -410,6 +411,46 @@ func (r *IllaDriveRestAPI) GetMultipleDownloadAddres(fileIDs []string) ([]map[st
 	return ret, nil
 }
 
+// DownloadFileToTemp download the team drive file into a local temp file, and return the temp file path.
+// The caller should remove the temp file after used.
+func (r *IllaDriveRestAPI) DownloadFileToTemp(fileID string, pattern string) (string, error) {
+	downloadAddress, errInGetDownloadAddress := r.GetDownloadAddress(fileID)
+	if errInGetDownloadAddress != nil {
+		return "", errInGetDownloadAddress
+	}
+	// self-host have no drive file download address
+	if downloadAddress == nil {
+		return "", errors.New("illa drive is not available in self-host mode")
+	}
+	downloadURL, assertPass := downloadAddress["downloadURL"].(string)
+	if !assertPass || downloadURL == "" {
+		return "", errors.New("can not get download url of illa drive file " + fileID)
+	}
+
+	// download file
+	tempFile, errInCreateTemp := os.CreateTemp("", pattern)
+	if errInCreateTemp != nil {
+		return "", errInCreateTemp
+	}
+	tempFile.Close()
+	client := resty.New()
+	resp, errInGet := client.R().
+		SetOutput(tempFile.Name()).
+		Get(downloadURL)
+	if r.Debug {
+		log.Printf("[DUMP] IllaDriveSDK.DownloadFileToTemp()  fileID: %+v, output: %+v, err: %+v \n", fileID, tempFile.Name(), errInGet)
+	}
+	if errInGet != nil {
+		os.Remove(tempFile.Name())
+		return "", errInGet
+	}
+	if resp.StatusCode() != http.StatusOK {
+		os.Remove(tempFile.Name())
+		return "", errors.New("download illa drive file failed with status: " + resp.Status())
+	}
+	return tempFile.Name(), nil
+}
+
 func (r *IllaDriveRestAPI) DeleteFile(fileID string) (map[string]interface{}, error) {
 	return r.DeleteMultipleFile([]string{fileID})
 }
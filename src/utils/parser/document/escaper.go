@@ -0,0 +1,490 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_document
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+)
+
+const (
+	FIELD_CONTEXT = "context"
+
+	// BINDING_SENTINEL_PREFIX marks a string token standing for a bare "{{ }}" leaf while the template is parsed.
+	BINDING_SENTINEL_PREFIX = "\x00illa-binding:"
+)
+
+// OperatorKeyPrefixList holds the key prefixes that turn a key into a query operator (or an expression placeholder).
+var OperatorKeyPrefixList = map[int][]string{
+	resourcelist.TYPE_MONGODB_ID:  {"$"},
+	resourcelist.TYPE_COUCHDB_ID:  {"$"},
+	resourcelist.TYPE_DYNAMODB_ID: {":", "#"},
+}
+
+// StringOperatorPrefixList holds the prefixes that give a string value a special meaning, like mongodb field paths.
+var StringOperatorPrefixList = map[int]string{
+	resourcelist.TYPE_MONGODB_ID: "$",
+	resourcelist.TYPE_COUCHDB_ID: "$",
+}
+
+// ObjectBindingForbiddenList holds the resources which query DSL has no operator prefix, any bound object could
+// be a query clause, so only scalars and arrays of scalars can be bound.
+var ObjectBindingForbiddenList = map[int]bool{
+	resourcelist.TYPE_ELASTICSEARCH_ID: true,
+}
+
+// CodeKeyList holds the keys which value is evaluated as code, no variable can be bound below them.
+var CodeKeyList = map[int]map[string]bool{
+	resourcelist.TYPE_MONGODB_ID: {
+		"$where":       true,
+		"$function":    true,
+		"$accumulator": true,
+	},
+	resourcelist.TYPE_ELASTICSEARCH_ID: {
+		"script": true,
+	},
+	resourcelist.TYPE_DYNAMODB_ID: {
+		"Statement": true,
+	},
+}
+
+// CodeKeySuffixList holds the key suffixes which value is evaluated as an expression, like dynamodb FilterExpression.
+var CodeKeySuffixList = map[int]string{
+	resourcelist.TYPE_DYNAMODB_ID: "Expression",
+}
+
+// DocumentEscaper renders the "{{ }}" variables of a JSON query document in safe mode. The document is parsed
+// before any variable is bound, a bare "{{ }}" leaf is replaced with the typed context value and a variable inside
+// a string leaf is interpolated as text. So a context value can never change the structure of the document.
+type DocumentEscaper struct {
+	ResourceType int `json:"resourceType"`
+}
+
+func NewDocumentEscaper(resourceType int) *DocumentEscaper {
+	return &DocumentEscaper{
+		ResourceType: resourceType,
+	}
+}
+
+// ExportContextFromRawTemplate returns the context of the raw action template with trimmed variable names.
+func ExportContextFromRawTemplate(rawTemplate map[string]interface{}) (map[string]interface{}, error) {
+	contextRaw, hit := rawTemplate[FIELD_CONTEXT]
+	if !hit || contextRaw == nil {
+		return map[string]interface{}{}, nil
+	}
+	contextAsserted, assertPass := contextRaw.(map[string]interface{})
+	if !assertPass {
+		return nil, errors.New("context field assert failed in ExportContextFromRawTemplate() method")
+	}
+	return buildArgsLookupTable(contextAsserted), nil
+}
+
+// orderedObject keeps the key order of a JSON object, the order matters for mongodb sort and pipeline stages.
+type orderedObject struct {
+	Keys   []string
+	Values []interface{}
+}
+
+func (o *orderedObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range o.Keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		keyInByte, err := marshalJSON(key)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(keyInByte)
+		buf.WriteByte(':')
+		valueInByte, err := marshalJSON(o.Values[i])
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(valueInByte)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+func marshalJSON(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(value); err != nil {
+		return nil, err
+	}
+	return bytes.TrimRight(buf.Bytes(), "\n"), nil
+}
+
+// EscapeDocumentTemplate renders a JSON document template and returns the rendered JSON document.
+func (escaper *DocumentEscaper) EscapeDocumentTemplate(template string, args map[string]interface{}) (string, error) {
+	if strings.TrimSpace(template) == "" {
+		return template, nil
+	}
+	args = buildArgsLookupTable(args)
+	tokenizedTemplate, bindings, err := tokenizeBareBindings(template)
+	if err != nil {
+		return "", err
+	}
+	decoder := json.NewDecoder(strings.NewReader(tokenizedTemplate))
+	decoder.UseNumber()
+	document, err := decodeOrdered(decoder)
+	if err != nil {
+		return "", errors.New("safe mode requires a valid JSON document: " + err.Error())
+	}
+	if _, err := decoder.Token(); err != io.EOF {
+		return "", errors.New("safe mode requires a single JSON document")
+	}
+	escaped, err := escaper.escapeNode(document, bindings, args, false)
+	if err != nil {
+		return "", err
+	}
+	escapedInByte, err := marshalJSON(escaped)
+	if err != nil {
+		return "", err
+	}
+	return string(escapedInByte), nil
+}
+
+// EscapeDocumentValue renders a document template field, a string field is rendered as a JSON document template,
+// and an already decoded object or array field is rendered in place. A decoded string leaf which is exactly one
+// "{{ }}" is the only way to write a bare leaf there, so it is bound with the typed context value too.
+func (escaper *DocumentEscaper) EscapeDocumentValue(template interface{}, args map[string]interface{}) (interface{}, error) {
+	if templateInString, ok := template.(string); ok {
+		return escaper.EscapeDocumentTemplate(templateInString, args)
+	}
+	bindings := make([]string, 0)
+	tokenizedTemplate := tokenizeStructureBindings(template, &bindings)
+	return escaper.escapeNode(tokenizedTemplate, bindings, buildArgsLookupTable(args), false)
+}
+
+// tokenizeStructureBindings replaces each decoded string leaf which is exactly one "{{ }}" with a sentinel string.
+func tokenizeStructureBindings(node interface{}, bindings *[]string) interface{} {
+	switch typedNode := node.(type) {
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(typedNode))
+		for key, value := range typedNode {
+			ret[key] = tokenizeStructureBindings(value, bindings)
+		}
+		return ret
+	case []interface{}:
+		ret := make([]interface{}, len(typedNode))
+		for i, value := range typedNode {
+			ret[i] = tokenizeStructureBindings(value, bindings)
+		}
+		return ret
+	case string:
+		trimmed := strings.TrimSpace(typedNode)
+		if !strings.HasPrefix(trimmed, "{{") || !strings.HasSuffix(trimmed, "}}") || strings.Count(trimmed, "{{") != 1 || strings.Count(trimmed, "}}") != 1 {
+			return node
+		}
+		*bindings = append(*bindings, strings.TrimSpace(trimmed[2:len(trimmed)-2]))
+		return BINDING_SENTINEL_PREFIX + strconv.Itoa(len(*bindings)-1)
+	default:
+		return node
+	}
+}
+
+func buildArgsLookupTable(args map[string]interface{}) map[string]interface{} {
+	lookupTable := make(map[string]interface{}, len(args))
+	for key, value := range args {
+		lookupTable[strings.TrimSpace(key)] = value
+	}
+	return lookupTable
+}
+
+// tokenizeBareBindings replaces each "{{ }}" outside of JSON strings with a sentinel string token, so the template
+// can be parsed as JSON. The returned list maps the sentinel serial to the variable name.
+func tokenizeBareBindings(template string) (string, []string, error) {
+	var ret strings.Builder
+	bindings := make([]string, 0)
+	inString := false
+	escaped := false
+	for i := 0; i < len(template); i++ {
+		c := template[i]
+		if inString {
+			ret.WriteByte(c)
+			if escaped {
+				escaped = false
+			} else if c == '\\' {
+				escaped = true
+			} else if c == '"' {
+				inString = false
+			}
+			continue
+		}
+		if c == '"' {
+			inString = true
+			ret.WriteByte(c)
+			continue
+		}
+		if c == '{' && strings.HasPrefix(template[i:], "{{") {
+			end := strings.Index(template[i+2:], "}}")
+			if end < 0 {
+				return "", nil, errors.New("unclosed variable in document template")
+			}
+			sentinel, _ := marshalJSON(BINDING_SENTINEL_PREFIX + strconv.Itoa(len(bindings)))
+			bindings = append(bindings, strings.TrimSpace(template[i+2:i+2+end]))
+			ret.Write(sentinel)
+			i += end + 3
+			continue
+		}
+		ret.WriteByte(c)
+	}
+	return ret.String(), bindings, nil
+}
+
+func decodeOrdered(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch delim := token.(type) {
+	case json.Delim:
+		if delim == '{' {
+			object := &orderedObject{Keys: []string{}, Values: []interface{}{}}
+			for decoder.More() {
+				keyToken, err := decoder.Token()
+				if err != nil {
+					return nil, err
+				}
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				object.Keys = append(object.Keys, keyToken.(string))
+				object.Values = append(object.Values, value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return object, nil
+		}
+		if delim == '[' {
+			array := make([]interface{}, 0)
+			for decoder.More() {
+				value, err := decodeOrdered(decoder)
+				if err != nil {
+					return nil, err
+				}
+				array = append(array, value)
+			}
+			if _, err := decoder.Token(); err != nil {
+				return nil, err
+			}
+			return array, nil
+		}
+		return nil, fmt.Errorf("unexpected delimiter %s", delim)
+	default:
+		return token, nil
+	}
+}
+
+func (escaper *DocumentEscaper) escapeNode(node interface{}, bindings []string, args map[string]interface{}, inCode bool) (interface{}, error) {
+	switch typedNode := node.(type) {
+	case *orderedObject:
+		ret := &orderedObject{Keys: typedNode.Keys, Values: make([]interface{}, len(typedNode.Values))}
+		for i, key := range typedNode.Keys {
+			if err := checkTemplateKey(key); err != nil {
+				return nil, err
+			}
+			value, err := escaper.escapeNode(typedNode.Values[i], bindings, args, inCode || escaper.isCodeKey(key))
+			if err != nil {
+				return nil, err
+			}
+			ret.Values[i] = value
+		}
+		return ret, nil
+	case map[string]interface{}:
+		ret := make(map[string]interface{}, len(typedNode))
+		for key, value := range typedNode {
+			if err := checkTemplateKey(key); err != nil {
+				return nil, err
+			}
+			escapedValue, err := escaper.escapeNode(value, bindings, args, inCode || escaper.isCodeKey(key))
+			if err != nil {
+				return nil, err
+			}
+			ret[key] = escapedValue
+		}
+		return ret, nil
+	case []interface{}:
+		ret := make([]interface{}, len(typedNode))
+		for i, value := range typedNode {
+			escapedValue, err := escaper.escapeNode(value, bindings, args, inCode)
+			if err != nil {
+				return nil, err
+			}
+			ret[i] = escapedValue
+		}
+		return ret, nil
+	case string:
+		if strings.HasPrefix(typedNode, BINDING_SENTINEL_PREFIX) {
+			serial, err := strconv.Atoi(strings.TrimPrefix(typedNode, BINDING_SENTINEL_PREFIX))
+			if err != nil || serial < 0 || serial >= len(bindings) {
+				return nil, errors.New("invalid variable in document template")
+			}
+			return escaper.bindLeaf(bindings[serial], args, inCode)
+		}
+		return escaper.interpolateString(typedNode, args, inCode)
+	default:
+		return node, nil
+	}
+}
+
+func checkTemplateKey(key string) error {
+	if strings.HasPrefix(key, BINDING_SENTINEL_PREFIX) || strings.Contains(key, "{{") {
+		return errors.New("variables can not be used as object keys in safe mode")
+	}
+	return nil
+}
+
+func (escaper *DocumentEscaper) isCodeKey(key string) bool {
+	if CodeKeyList[escaper.ResourceType][key] {
+		return true
+	}
+	suffix, hit := CodeKeySuffixList[escaper.ResourceType]
+	return hit && strings.HasSuffix(key, suffix)
+}
+
+func (escaper *DocumentEscaper) isOperatorKey(key string) bool {
+	for _, prefix := range OperatorKeyPrefixList[escaper.ResourceType] {
+		if strings.HasPrefix(key, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func lookupVariable(variable string, args map[string]interface{}) (interface{}, error) {
+	value, hit := args[variable]
+	if !hit {
+		return nil, fmt.Errorf("missing context value for variable {{%s}}", variable)
+	}
+	return value, nil
+}
+
+// bindLeaf binds a bare "{{ }}" leaf with the typed context value.
+func (escaper *DocumentEscaper) bindLeaf(variable string, args map[string]interface{}, inCode bool) (interface{}, error) {
+	if inCode {
+		return nil, fmt.Errorf("variable {{%s}} can not be used inside code or expression in safe mode", variable)
+	}
+	value, err := lookupVariable(variable, args)
+	if err != nil {
+		return nil, err
+	}
+	if err := escaper.checkBoundValue(variable, value); err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// checkBoundValue rejects operator keys and operator prefixed strings at every depth of the bound value,
+// a nested "$field" string is still a field path inside an aggregation expression.
+func (escaper *DocumentEscaper) checkBoundValue(variable string, value interface{}) error {
+	switch typedValue := value.(type) {
+	case map[string]interface{}:
+		if ObjectBindingForbiddenList[escaper.ResourceType] {
+			return fmt.Errorf("variable {{%s}} must be a scalar or an array in safe mode", variable)
+		}
+		for key, item := range typedValue {
+			if escaper.isOperatorKey(key) {
+				return fmt.Errorf("variable {{%s}} contains the operator key \"%s\" which is not allowed in safe mode", variable, key)
+			}
+			if err := escaper.checkBoundValue(variable, item); err != nil {
+				return err
+			}
+		}
+	case []interface{}:
+		for _, item := range typedValue {
+			if err := escaper.checkBoundValue(variable, item); err != nil {
+				return err
+			}
+		}
+	case string:
+		prefix, hit := StringOperatorPrefixList[escaper.ResourceType]
+		if hit && strings.HasPrefix(typedValue, prefix) {
+			return fmt.Errorf("variable {{%s}} can not start with \"%s\" in safe mode", variable, prefix)
+		}
+	}
+	return nil
+}
+
+// interpolateString renders the variables inside a string leaf as text, the leaf stays a string.
+func (escaper *DocumentEscaper) interpolateString(template string, args map[string]interface{}, inCode bool) (interface{}, error) {
+	if !strings.Contains(template, "{{") {
+		return template, nil
+	}
+	var ret strings.Builder
+	rest := template
+	for {
+		start := strings.Index(rest, "{{")
+		if start < 0 {
+			ret.WriteString(rest)
+			break
+		}
+		end := strings.Index(rest[start+2:], "}}")
+		if end < 0 {
+			ret.WriteString(rest)
+			break
+		}
+		variable := strings.TrimSpace(rest[start+2 : start+2+end])
+		if inCode {
+			return nil, fmt.Errorf("variable {{%s}} can not be used inside code or expression in safe mode", variable)
+		}
+		value, err := lookupVariable(variable, args)
+		if err != nil {
+			return nil, err
+		}
+		valueInString, err := exportValueAsText(value)
+		if err != nil {
+			return nil, err
+		}
+		ret.WriteString(rest[:start])
+		ret.WriteString(valueInString)
+		rest = rest[start+2+end+2:]
+	}
+	rendered := ret.String()
+	// the rendered string must not turn into a field path or an operator
+	prefix, hit := StringOperatorPrefixList[escaper.ResourceType]
+	if hit && strings.HasPrefix(rendered, prefix) && !strings.HasPrefix(template, prefix) {
+		return nil, fmt.Errorf("rendered value \"%s\" can not start with \"%s\" in safe mode", rendered, prefix)
+	}
+	return rendered, nil
+}
+
+func exportValueAsText(value interface{}) (string, error) {
+	switch typedValue := value.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return typedValue, nil
+	default:
+		valueInByte, err := marshalJSON(typedValue)
+		if err != nil {
+			return "", err
+		}
+		return string(valueInByte), nil
+	}
+}
@@ -0,0 +1,188 @@
+// Copyright 2022 The ILLA Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//	http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package parser_document
+
+import (
+	"testing"
+
+	"github.com/illacloud/builder-backend/src/utils/resourcelist"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEscapeDocumentTemplateMongoDBTypedLeaf(t *testing.T) {
+	template := `{"name": {{ input1.value }}, "age": {"$gte": {{input2.value}}}, "tags": {{input3.value}}}`
+	args := map[string]interface{}{
+		" input1.value ": "pan",
+		"input2.value":   float64(18),
+		"input3.value":   []interface{}{"a", "b"},
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	escapedDocument, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, `{"name":"pan","age":{"$gte":18},"tags":["a","b"]}`, escapedDocument, "the document should be equal")
+}
+
+func TestEscapeDocumentTemplateMongoDBStringInterpolation(t *testing.T) {
+	template := `{"name": "{{input1.value}}", "title": "mr. {{input1.value}}"}`
+	args := map[string]interface{}{
+		"input1.value": `pan", "$where": "sleep(1000)`,
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	escapedDocument, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, `{"name":"pan\", \"$where\": \"sleep(1000)","title":"mr. pan\", \"$where\": \"sleep(1000)"}`, escapedDocument, "the document should be equal")
+}
+
+func TestEscapeDocumentTemplateMongoDBOperatorInjection(t *testing.T) {
+	template := `{"password": {{input1.value}}}`
+	args := map[string]interface{}{
+		"input1.value": map[string]interface{}{"$ne": nil},
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+}
+
+func TestEscapeDocumentTemplateMongoDBFieldPathInjection(t *testing.T) {
+	template := `[{"$match": {"owner": "{{input1.value}}"}}]`
+	args := map[string]interface{}{
+		"input1.value": "$owner",
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+}
+
+func TestEscapeDocumentTemplateMongoDBNestedFieldPathInjection(t *testing.T) {
+	template := `[{"$match": {"owner": {{input1.value}}}}]`
+	args := map[string]interface{}{
+		"input1.value": map[string]interface{}{"name": map[string]interface{}{"first": "$owner"}},
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+}
+
+func TestEscapeDocumentTemplateMongoDBArrayFieldPathInjection(t *testing.T) {
+	template := `{"owner": {"$in": {{input1.value}}}}`
+	args := map[string]interface{}{
+		"input1.value": []interface{}{"pan", []interface{}{"$owner"}},
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+
+	args["input1.value"] = []interface{}{"pan", "$owner"}
+	_, errInEscape = documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+
+	args["input1.value"] = []interface{}{"pan", map[string]interface{}{"name": "owner"}}
+	escapedDocument, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, `{"owner":{"$in":["pan",{"name":"owner"}]}}`, escapedDocument, "the document should be equal")
+}
+
+func TestEscapeDocumentTemplateMongoDBVariableKey(t *testing.T) {
+	template := `{ {{input1.value}}: 1, "{{input2.value}}": 2}`
+	args := map[string]interface{}{
+		"input1.value": "$where",
+		"input2.value": "$where",
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+}
+
+func TestEscapeDocumentTemplateMongoDBWhere(t *testing.T) {
+	template := `{"$where": "this.name == '{{input1.value}}'"}`
+	args := map[string]interface{}{
+		"input1.value": "pan",
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+}
+
+func TestEscapeDocumentTemplateElasticsearchObjectBinding(t *testing.T) {
+	template := `{"query": {"match": {"title": {{input1.value}}}}}`
+	args := map[string]interface{}{
+		"input1.value": map[string]interface{}{"query": "a"},
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_ELASTICSEARCH_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+}
+
+func TestEscapeDocumentTemplateDynamoDBExpression(t *testing.T) {
+	template := `{"KeyConditionExpression": "id = {{input1.value}}"}`
+	args := map[string]interface{}{
+		"input1.value": "1 OR 1 = 1",
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_DYNAMODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+
+	template = `{"KeyConditionExpression": "id = :id", "ExpressionAttributeValues": {":id": {{input1.value}}}}`
+	escapedDocument, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, `{"KeyConditionExpression":"id = :id","ExpressionAttributeValues":{":id":"1 OR 1 = 1"}}`, escapedDocument, "the document should be equal")
+}
+
+func TestEscapeDocumentTemplateDynamoDBStatement(t *testing.T) {
+	template := `{"Statement": "SELECT * FROM users WHERE id = '{{input1.value}}'"}`
+	args := map[string]interface{}{
+		"input1.value": "1' OR '1' = '1",
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_DYNAMODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.NotNil(t, errInEscape)
+
+	template = `{"Statement": "SELECT * FROM users WHERE id = ?", "Parameters": [{{input1.value}}]}`
+	escapedDocument, errInEscape := documentEscaper.EscapeDocumentTemplate(template, args)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, `{"Statement":"SELECT * FROM users WHERE id = ?","Parameters":["1' OR '1' = '1"]}`, escapedDocument, "the document should be equal")
+}
+
+func TestEscapeDocumentValueCouchDB(t *testing.T) {
+	template := map[string]interface{}{
+		"selector": map[string]interface{}{"name": "mr. {{input1.value}}", "age": "{{ input2.value }}"},
+	}
+	args := map[string]interface{}{
+		"input1.value": "pan",
+		"input2.value": float64(18),
+	}
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_COUCHDB_ID)
+	escapedDocument, errInEscape := documentEscaper.EscapeDocumentValue(template, args)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, map[string]interface{}{"selector": map[string]interface{}{"name": "mr. pan", "age": float64(18)}}, escapedDocument, "the document should be equal")
+
+	args["input2.value"] = map[string]interface{}{"$gt": nil}
+	_, errInEscape = documentEscaper.EscapeDocumentValue(template, args)
+	assert.NotNil(t, errInEscape)
+
+	args["input2.value"] = map[string]interface{}{"age": map[string]interface{}{"value": "$gt"}}
+	_, errInEscape = documentEscaper.EscapeDocumentValue(template, args)
+	assert.NotNil(t, errInEscape)
+
+	args["input2.value"] = []interface{}{float64(18), []interface{}{"$age"}}
+	_, errInEscape = documentEscaper.EscapeDocumentValue(template, args)
+	assert.NotNil(t, errInEscape)
+}
+
+func TestEscapeDocumentTemplateMissingVariable(t *testing.T) {
+	documentEscaper := NewDocumentEscaper(resourcelist.TYPE_MONGODB_ID)
+	_, errInEscape := documentEscaper.EscapeDocumentTemplate(`{"name": {{input1.value}}}`, map[string]interface{}{})
+	assert.NotNil(t, errInEscape)
+}
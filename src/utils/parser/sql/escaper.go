@@ -14,12 +14,26 @@ var SerializedParameterizedSQLList = map[int]bool{
 	resourcelist.TYPE_POSTGRESQL_ID: true,
 	resourcelist.TYPE_ORACLE_9I_ID:  true,
 	resourcelist.TYPE_ORACLE_ID:     true,
+	resourcelist.TYPE_NEO4J_ID:      true,
 }
 
 var SerializedParameterPrefixMap = map[int]string{
 	resourcelist.TYPE_POSTGRESQL_ID: "$",
 	resourcelist.TYPE_ORACLE_9I_ID:  ":",
 	resourcelist.TYPE_ORACLE_ID:     ":",
+	resourcelist.TYPE_NEO4J_ID:      "$param",
+}
+
+// StringConcatOperatorMap holds the resources which query language has no CONCAT() function,
+// the string template is joined with the operator instead.
+var StringConcatOperatorMap = map[int]string{
+	resourcelist.TYPE_NEO4J_ID: " + ",
+}
+
+// StringConcatForbiddenList holds the resources which query language can not concatenate strings at all,
+// a string template mixing variables and text is rejected.
+var StringConcatForbiddenList = map[int]bool{
+	resourcelist.TYPE_CASSANDRA_ID: true,
 }
 
 var ParameterTextTypeCastList = map[int]string{
@@ -49,6 +63,16 @@ func (sqlEscaper *SQLEscaper) GetSerializedParameterPrefixMap() string {
 	return prefix
 }
 
+func (sqlEscaper *SQLEscaper) GetStringConcatOperator() (string, bool) {
+	operator, hit := StringConcatOperatorMap[sqlEscaper.ResourceType]
+	return operator, hit
+}
+
+func (sqlEscaper *SQLEscaper) IsStringConcatForbidden() bool {
+	itIs, hit := StringConcatForbiddenList[sqlEscaper.ResourceType]
+	return itIs && hit
+}
+
 func (sqlEscaper *SQLEscaper) GetParameterTextTypeCastList() string {
 	typeIDF, hit := ParameterTextTypeCastList[sqlEscaper.ResourceType]
 	if !hit {
@@ -526,7 +550,10 @@ func (sqlEscaper *SQLEscaper) EscapeSQLActionTemplate(sql string, args map[strin
 			}
 
 			// not escape, it is string finish quote
-			concatResult := formatConcatTarget(sqlEscaper, concatStringTargets, singleQuoteStart, doubleQuoteStart)
+			concatResult, errInFormatConcat := formatConcatTarget(sqlEscaper, concatStringTargets, singleQuoteStart, doubleQuoteStart)
+			if errInFormatConcat != nil {
+				return "", nil, errInFormatConcat
+			}
 			fmt.Printf("-- [DUMP] single quote concatStringTargets: %v\n", concatStringTargets)
 			fmt.Printf("-- [DUMP] single quote concatResult: %s\n", concatResult)
 			ret.WriteString(concatResult)
@@ -558,7 +585,11 @@ func (sqlEscaper *SQLEscaper) EscapeSQLActionTemplate(sql string, args map[strin
 		// double quote end, form concat function to sql
 		if c == '"' && doubleQuoteStart && !singleQuoteStart {
 			// double quete have no escape, it is string finish quote
-			ret.WriteString(formatConcatTarget(sqlEscaper, concatStringTargets, singleQuoteStart, doubleQuoteStart))
+			concatResult, errInFormatConcat := formatConcatTarget(sqlEscaper, concatStringTargets, singleQuoteStart, doubleQuoteStart)
+			if errInFormatConcat != nil {
+				return "", nil, errInFormatConcat
+			}
+			ret.WriteString(concatResult)
 
 			// clean status
 			doubleQuoteStart = false
@@ -596,7 +627,7 @@ func (sqlEscaper *SQLEscaper) EscapeSQLActionTemplate(sql string, args map[strin
 	return ret.String(), userArgs, nil
 }
 
-func formatConcatTarget(sqlEscaper *SQLEscaper, concatStringTargets []*stringConcatTarget, singleQuoteStart bool, doubleQuoteStart bool) string {
+func formatConcatTarget(sqlEscaper *SQLEscaper, concatStringTargets []*stringConcatTarget, singleQuoteStart bool, doubleQuoteStart bool) (string, error) {
 	var ret strings.Builder
 	haveVariable := false
 	exportedTarget := make([]string, 0)
@@ -613,7 +644,9 @@ func formatConcatTarget(sqlEscaper *SQLEscaper, concatStringTargets []*stringCon
 			ret.WriteString(concatStringTargets[0].Export(singleQuoteStart, doubleQuoteStart))
 		} else {
 			// multi variable
-			ret.WriteString("CONCAT(")
+			if sqlEscaper.IsStringConcatForbidden() {
+				return "", errors.New("string concatenation is not supported by this resource, bind the whole string as a single variable like '{{ value }}' instead")
+			}
 			for _, target := range concatStringTargets {
 				fmt.Printf("----- [DUMP] target: %+v\n", string(target.Target.String()))
 				// process variable type cast
@@ -622,8 +655,15 @@ func formatConcatTarget(sqlEscaper *SQLEscaper, concatStringTargets []*stringCon
 				}
 				exportedTarget = append(exportedTarget, target.Export(singleQuoteStart, doubleQuoteStart))
 			}
-			ret.WriteString(strings.Join(exportedTarget, ", "))
-			ret.WriteString(")")
+			if operator, hit := sqlEscaper.GetStringConcatOperator(); hit {
+				ret.WriteString("(")
+				ret.WriteString(strings.Join(exportedTarget, operator))
+				ret.WriteString(")")
+			} else {
+				ret.WriteString("CONCAT(")
+				ret.WriteString(strings.Join(exportedTarget, ", "))
+				ret.WriteString(")")
+			}
 		}
 	} else {
 		if singleQuoteStart {
@@ -640,6 +680,6 @@ func formatConcatTarget(sqlEscaper *SQLEscaper, concatStringTargets []*stringCon
 			ret.WriteString("\"")
 		}
 	}
-	return ret.String()
+	return ret.String(), nil
 
 }
@@ -45,6 +45,50 @@ func TestEscapeSQLActionTemplateSingleQuoteStringTemplatePostgres(t *testing.T)
 	assert.Equal(t, "SELECT * FROM actions where name like CONCAT('%', $1::text, '.', $2::text, ' sir%') or name like CONCAT('%', $3::text, '%');", escapedSQL, "the token should be equal")
 }
 
+func TestEscapeSQLActionTemplateSingleQuoteStringTemplateNeo4j(t *testing.T) {
+	sql_1 := `MATCH (n:Person) WHERE n.name STARTS WITH '%{{ !input1.value }}.{{input2.value}} sir%' OR n.name = '{{input3}}' RETURN n`
+	args := map[string]interface{}{
+		" !input1.value ": "122 pan",
+		"input2.value":    "222 pan",
+		"input3":          "333 pan",
+	}
+	sqlEscaper := NewSQLEscaper(resourcelist.TYPE_NEO4J_ID)
+	escapedSQL, usedArgs, errInEscape := sqlEscaper.EscapeSQLActionTemplate(sql_1, args, true)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, []interface{}{"122 pan", "222 pan", "333 pan"}, usedArgs, "the usedArgs should be equal")
+	assert.Equal(t, "MATCH (n:Person) WHERE n.name STARTS WITH ('%' + $param1 + '.' + $param2 + ' sir%') OR n.name = $param3 RETURN n", escapedSQL, "the token should be equal")
+}
+
+func TestEscapeSQLActionTemplateNeo4jWithoutQuote(t *testing.T) {
+	sql_1 := `MATCH (n:Person {name: {{input1.value}}}) WHERE n.age > {{input2.value}} RETURN n`
+	args := map[string]interface{}{
+		"input1.value": "pan",
+		"input2.value": 18,
+	}
+	sqlEscaper := NewSQLEscaper(resourcelist.TYPE_NEO4J_ID)
+	escapedSQL, usedArgs, errInEscape := sqlEscaper.EscapeSQLActionTemplate(sql_1, args, true)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, []interface{}{"pan", 18}, usedArgs, "the usedArgs should be equal")
+	assert.Equal(t, "MATCH (n:Person {name: $param1}) WHERE n.age > $param2 RETURN n", escapedSQL, "the token should be equal")
+}
+
+func TestEscapeSQLActionTemplateSingleQuoteStringTemplateCassandra(t *testing.T) {
+	sql_1 := `SELECT * FROM users WHERE name = '{{input1.value}}' AND city = '{{ input2.value }}';`
+	args := map[string]interface{}{
+		"input1.value": "pan",
+		"input2.value": "paris",
+	}
+	sqlEscaper := NewSQLEscaper(resourcelist.TYPE_CASSANDRA_ID)
+	escapedSQL, usedArgs, errInEscape := sqlEscaper.EscapeSQLActionTemplate(sql_1, args, true)
+	assert.Nil(t, errInEscape)
+	assert.Equal(t, []interface{}{"pan", "paris"}, usedArgs, "the usedArgs should be equal")
+	assert.Equal(t, "SELECT * FROM users WHERE name = ? AND city = ?;", escapedSQL, "the token should be equal")
+
+	sql_2 := `SELECT * FROM users WHERE name = 'mr. {{input1.value}}';`
+	_, _, errInEscape = sqlEscaper.EscapeSQLActionTemplate(sql_2, args, true)
+	assert.NotNil(t, errInEscape)
+}
+
 func TestEscapeSQLActionTemplateSingleQuoteStringTemplateMySQL(t *testing.T) {
 	sql_1 := `SELECT * FROM actions where name like '%{{ !input1.value }}.{{input2.value}} sir%' or name like '%{{input3}}%';`
 	args := map[string]interface{}{
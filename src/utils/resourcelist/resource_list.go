@@ -36,6 +36,21 @@ var (
 	TYPE_SERVER_SIDE_TRANSFORMER = "serversidetransformer"
 	TYPE_CONDITION               = "condition"
 	TYPE_WEBHOOK_RESPONSE        = "webhookresponse"
+	TYPE_SQLITE                  = "sqlite"
+	TYPE_DUCKDB                  = "duckdb"
+	TYPE_KAFKA                   = "kafka"
+	TYPE_AMQP                    = "amqp"
+	TYPE_MQTT                    = "mqtt"
+	TYPE_NATS                    = "nats"
+	TYPE_CASSANDRA               = "cassandra"
+	TYPE_NEO4J                   = "neo4j"
+	TYPE_INFLUXDB                = "influxdb"
+	TYPE_SFTP                    = "sftp"
+	TYPE_LDAP                    = "ldap"
+	TYPE_GRPC                    = "grpc"
+	TYPE_SOAP                    = "soap"
+	TYPE_IMAP                    = "imap"
+	TYPE_VECTORDB                = "vectordb"
 )
 
 var (
@@ -74,6 +89,21 @@ var (
 	TYPE_SERVER_SIDE_TRANSFORMER_ID = 32
 	TYPE_CONDITION_ID               = 33
 	TYPE_WEBHOOK_RESPONSE_ID        = 34
+	TYPE_SQLITE_ID                  = 35
+	TYPE_DUCKDB_ID                  = 36
+	TYPE_KAFKA_ID                   = 37
+	TYPE_AMQP_ID                    = 38
+	TYPE_MQTT_ID                    = 39
+	TYPE_NATS_ID                    = 40
+	TYPE_CASSANDRA_ID               = 41
+	TYPE_NEO4J_ID                   = 42
+	TYPE_INFLUXDB_ID                = 43
+	TYPE_SFTP_ID                    = 44
+	TYPE_LDAP_ID                    = 45
+	TYPE_GRPC_ID                    = 46
+	TYPE_SOAP_ID                    = 47
+	TYPE_IMAP_ID                    = 48
+	TYPE_VECTORDB_ID                = 49
 )
 
 var type_array = []string{
@@ -112,6 +142,21 @@ var type_array = []string{
 	32: TYPE_SERVER_SIDE_TRANSFORMER,
 	33: TYPE_CONDITION,
 	34: TYPE_WEBHOOK_RESPONSE,
+	35: TYPE_SQLITE,
+	36: TYPE_DUCKDB,
+	37: TYPE_KAFKA,
+	38: TYPE_AMQP,
+	39: TYPE_MQTT,
+	40: TYPE_NATS,
+	41: TYPE_CASSANDRA,
+	42: TYPE_NEO4J,
+	43: TYPE_INFLUXDB,
+	44: TYPE_SFTP,
+	45: TYPE_LDAP,
+	46: TYPE_GRPC,
+	47: TYPE_SOAP,
+	48: TYPE_IMAP,
+	49: TYPE_VECTORDB,
 }
 
 var type_map = map[string]int{
@@ -150,6 +195,21 @@ var type_map = map[string]int{
 	TYPE_SERVER_SIDE_TRANSFORMER: TYPE_SERVER_SIDE_TRANSFORMER_ID,
 	TYPE_CONDITION:               TYPE_CONDITION_ID,
 	TYPE_WEBHOOK_RESPONSE:        TYPE_WEBHOOK_RESPONSE_ID,
+	TYPE_SQLITE:                  TYPE_SQLITE_ID,
+	TYPE_DUCKDB:                  TYPE_DUCKDB_ID,
+	TYPE_KAFKA:                   TYPE_KAFKA_ID,
+	TYPE_AMQP:                    TYPE_AMQP_ID,
+	TYPE_MQTT:                    TYPE_MQTT_ID,
+	TYPE_NATS:                    TYPE_NATS_ID,
+	TYPE_CASSANDRA:               TYPE_CASSANDRA_ID,
+	TYPE_NEO4J:                   TYPE_NEO4J_ID,
+	TYPE_INFLUXDB:                TYPE_INFLUXDB_ID,
+	TYPE_SFTP:                    TYPE_SFTP_ID,
+	TYPE_LDAP:                    TYPE_LDAP_ID,
+	TYPE_GRPC:                    TYPE_GRPC_ID,
+	TYPE_SOAP:                    TYPE_SOAP_ID,
+	TYPE_IMAP:                    TYPE_IMAP_ID,
+	TYPE_VECTORDB:                TYPE_VECTORDB_ID,
 }
 
 var virtualResourceList = map[string]bool{
@@ -175,6 +235,22 @@ var canCreateOAuthTokenResourceList = map[string]bool{
 	TYPE_GOOGLESHEETS: true,
 }
 
+// the resource which database file can be stored in team drive, need team info for download it
+var teamDriveBackedResourceList = map[string]bool{
+	TYPE_SQLITE: true,
+	TYPE_DUCKDB: true,
+}
+
+// the resource which keeps state in redis across instances, need team and resource info to scope the state
+var sharedStateScopedResourceList = map[string]bool{
+	TYPE_SMTP: true,
+}
+
+// the resource which refers to an embedding resource of the same team, the options of it are loaded for running
+var embeddingResourceReferencedResourceList = map[string]bool{
+	TYPE_VECTORDB: true,
+}
+
 var needFetchResourceInfoFromSourceManagerList = map[string]bool{
 	TYPE_AI_AGENT: true,
 }
@@ -233,6 +309,24 @@ func CanCreateOAuthToken(resourceType int) bool {
 	return canDo && hit
 }
 
+func IsTeamDriveBackedResourceByIntType(resourceType int) bool {
+	resourceTypeString := GetResourceIDMappedType(resourceType)
+	itIs, hit := teamDriveBackedResourceList[resourceTypeString]
+	return itIs && hit
+}
+
+func IsSharedStateScopedResourceByIntType(resourceType int) bool {
+	resourceTypeString := GetResourceIDMappedType(resourceType)
+	itIs, hit := sharedStateScopedResourceList[resourceTypeString]
+	return itIs && hit
+}
+
+func IsEmbeddingResourceReferencedByIntType(resourceType int) bool {
+	resourceTypeString := GetResourceIDMappedType(resourceType)
+	itIs, hit := embeddingResourceReferencedResourceList[resourceTypeString]
+	return itIs && hit
+}
+
 func NeedFetchResourceInfoFromSourceManager(resourceType string) bool {
 	itIs, hit := needFetchResourceInfoFromSourceManagerList[resourceType]
 	return itIs && hit